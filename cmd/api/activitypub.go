@@ -0,0 +1,732 @@
+package main
+
+import (
+	"context"
+	"crypto/rsa"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/JaskiratAnand/go-social/internal/activitypub"
+	"github.com/JaskiratAnand/go-social/internal/store"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+const (
+	// inboxMaxBytes bounds activities accepted from remote servers.
+	inboxMaxBytes = 1 << 20
+	// signatureMaxSkew is how far a signed Date may be from our clock.
+	signatureMaxSkew = 5 * time.Minute
+
+	outboxPageSize = 20
+
+	deliveryBatchSize   = 50
+	deliveryLease       = 5 * time.Minute
+	deliveryMaxAttempts = 8
+	deliveryMaxBackoff  = 12 * time.Hour
+)
+
+// apUsersPath and apPostsPath prefix the ids of local actors and notes.
+const (
+	apUsersPath = "/v1/ap/users/"
+	apPostsPath = "/v1/ap/posts/"
+)
+
+func (app *application) actorIRI(username string) string {
+	return app.config.activityPub.baseURL + apUsersPath + username
+}
+
+func (app *application) noteIRI(postID uuid.UUID) string {
+	return app.config.activityPub.baseURL + apPostsPath + postID.String()
+}
+
+// localPostID returns the post a local note id refers to.
+func (app *application) localPostID(iri string) (uuid.UUID, bool) {
+	id, ok := strings.CutPrefix(iri, app.config.activityPub.baseURL+apPostsPath)
+	if !ok {
+		return uuid.Nil, false
+	}
+
+	postID, err := uuid.Parse(id)
+	return postID, err == nil
+}
+
+func writeActivityJSON(w http.ResponseWriter, status int, contentType string, data any) error {
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(status)
+	return json.NewEncoder(w).Encode(data)
+}
+
+// WebFinger godoc
+//
+//	@Summary		Resolves an account for federation
+//	@Description	Maps acct:username@domain to the ActivityPub actor of a public user.
+//	@Tags			activitypub
+//	@Produce		json
+//	@Param			resource	query		string	true	"acct:username@domain"
+//	@Success		200			{object}	activitypub.WebFinger
+//	@Failure		400			{object}	error	"Bad Request"
+//	@Failure		404			{object}	error	"Record Not Found"
+//	@Router			/.well-known/webfinger [get]
+func (app *application) webfingerHandler(w http.ResponseWriter, r *http.Request) {
+	resource := r.URL.Query().Get("resource")
+
+	var username string
+	if u, domain, ok := activitypub.ParseAccount(resource); ok {
+		if !strings.EqualFold(domain, app.config.activityPub.domain) {
+			app.recordNotFoundResponse(w, r, errors.New("unknown domain"))
+			return
+		}
+		username = u
+	} else if u, ok := strings.CutPrefix(resource, app.actorIRI("")); ok {
+		username = u
+	} else {
+		app.badRequestResponse(w, r, errors.New("resource must be an acct: uri or an actor id"))
+		return
+	}
+
+	user, ok := app.federatedUser(w, r, username)
+	if !ok {
+		return
+	}
+
+	jrd := activitypub.WebFinger{
+		Subject: fmt.Sprintf("acct:%s@%s", user.Username, app.config.activityPub.domain),
+		Aliases: []string{app.actorIRI(user.Username)},
+		Links: []activitypub.WebFingerLink{
+			{Rel: "self", Type: activitypub.ContentType, Href: app.actorIRI(user.Username)},
+			{Rel: "http://webfinger.net/rel/profile-page", Type: "text/html", Href: fmt.Sprintf("%s/users/%s", app.config.frontendURL, user.Username)},
+		},
+	}
+
+	if err := writeActivityJSON(w, http.StatusOK, activitypub.JRDContentType, jrd); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// GetActor godoc
+//
+//	@Summary		Fetches the ActivityPub actor of a user
+//	@Tags			activitypub
+//	@Produce		json
+//	@Param			username	path		string	true	"Username"
+//	@Success		200			{object}	activitypub.Actor
+//	@Failure		404			{object}	error	"Record Not Found"
+//	@Failure		500			{object}	error	"Server encountered a problem"
+//	@Router			/ap/users/{username} [get]
+func (app *application) getActorHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := app.federatedUser(w, r, chi.URLParam(r, "username"))
+	if !ok {
+		return
+	}
+
+	key, _, err := app.actorKey(r.Context(), user.ID)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	id := app.actorIRI(user.Username)
+	actor := activitypub.Actor{
+		Context:           []string{activitypub.ContextActivityStreams, activitypub.ContextSecurity},
+		ID:                id,
+		Type:              "Person",
+		PreferredUsername: user.Username,
+		Name:              user.Username,
+		URL:               fmt.Sprintf("%s/users/%s", app.config.frontendURL, user.Username),
+		Inbox:             id + "/inbox",
+		Outbox:            id + "/outbox",
+		Followers:         id + "/followers",
+		Published:         user.CreatedAt.UTC().Format(time.RFC3339),
+		PublicKey: activitypub.PublicKey{
+			ID:           id + "#main-key",
+			Owner:        id,
+			PublicKeyPem: key.PublicKeyPem,
+		},
+	}
+
+	if err := writeActivityJSON(w, http.StatusOK, activitypub.ContentType, actor); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// GetOutbox godoc
+//
+//	@Summary		Fetches the ActivityPub outbox of a user
+//	@Description	Without page the collection only links its first page, which holds the latest Create activities. Pages link the next older page.
+//	@Tags			activitypub
+//	@Produce		json
+//	@Param			username	path		string	true	"Username"
+//	@Param			page		query		bool	false	"Return a page"
+//	@Param			cursor		query		string	false	"Cursor from the next link of the previous page"
+//	@Success		200			{object}	activitypub.OrderedCollection
+//	@Failure		400			{object}	error	"Bad Request"
+//	@Failure		404			{object}	error	"Record Not Found"
+//	@Failure		500			{object}	error	"Server encountered a problem"
+//	@Router			/ap/users/{username}/outbox [get]
+func (app *application) getOutboxHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := app.federatedUser(w, r, chi.URLParam(r, "username"))
+	if !ok {
+		return
+	}
+
+	ctx := r.Context()
+
+	count, err := app.store.CountPostsByUserId(ctx, user.ID)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	id := app.actorIRI(user.Username) + "/outbox"
+
+	if r.URL.Query().Get("page") != "true" {
+		collection := activitypub.OrderedCollection{
+			Context:    activitypub.ContextActivityStreams,
+			ID:         id,
+			Type:       "OrderedCollection",
+			TotalItems: count,
+			First:      id + "?page=true",
+		}
+		if err := writeActivityJSON(w, http.StatusOK, activitypub.ContentType, collection); err != nil {
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	// pages are keyset paginated, newest first, one extra post tells
+	// whether there is a next page
+	params := store.GetPostsByUserIdParams{UserID: user.ID, Limit: outboxPageSize + 1}
+	pageID := id + "?page=true"
+	if token := r.URL.Query().Get("cursor"); token != "" {
+		cursor, err := app.cursorCodec.Decode(token)
+		if err != nil {
+			app.badRequestResponse(w, r, err)
+			return
+		}
+		params.CursorCreatedAt = sql.NullTime{Time: cursor.CreatedAt, Valid: true}
+		params.CursorID = uuid.NullUUID{UUID: cursor.ID, Valid: true}
+		pageID += "&cursor=" + url.QueryEscape(token)
+	}
+
+	posts, err := app.store.GetPostsByUserId(ctx, params)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	page := activitypub.OrderedCollection{
+		Context:      activitypub.ContextActivityStreams,
+		ID:           pageID,
+		Type:         "OrderedCollectionPage",
+		TotalItems:   count,
+		PartOf:       id,
+		OrderedItems: []any{},
+	}
+
+	if len(posts) > outboxPageSize {
+		posts = posts[:outboxPageSize]
+		last := posts[len(posts)-1]
+		next := app.cursorCodec.Encode(store.FeedCursor{CreatedAt: last.CreatedAt, ID: last.ID})
+		page.Next = id + "?page=true&cursor=" + url.QueryEscape(next)
+	}
+
+	for _, post := range posts {
+		note := app.note(user.Username, post.ID, post.Title, post.Content, post.Tags, post.CreatedAt, post.UpdatedAt)
+
+		create, err := app.createActivity(note)
+		if err != nil {
+			app.internalServerError(w, r, err)
+			return
+		}
+		create.Context = nil
+		page.OrderedItems = append(page.OrderedItems, create)
+	}
+
+	if err := writeActivityJSON(w, http.StatusOK, activitypub.ContentType, page); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// GetFollowersCollection godoc
+//
+//	@Summary		Fetches the size of a user's remote followers collection
+//	@Tags			activitypub
+//	@Produce		json
+//	@Param			username	path		string	true	"Username"
+//	@Success		200			{object}	activitypub.OrderedCollection
+//	@Failure		404			{object}	error	"Record Not Found"
+//	@Failure		500			{object}	error	"Server encountered a problem"
+//	@Router			/ap/users/{username}/followers [get]
+func (app *application) getFollowersCollectionHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := app.federatedUser(w, r, chi.URLParam(r, "username"))
+	if !ok {
+		return
+	}
+
+	count, err := app.store.CountRemoteFollowers(r.Context(), user.ID)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	// followers are counted but not listed
+	collection := activitypub.OrderedCollection{
+		Context:    activitypub.ContextActivityStreams,
+		ID:         app.actorIRI(user.Username) + "/followers",
+		Type:       "OrderedCollection",
+		TotalItems: count,
+	}
+
+	if err := writeActivityJSON(w, http.StatusOK, activitypub.ContentType, collection); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// GetNote godoc
+//
+//	@Summary		Fetches a post as an ActivityPub note
+//	@Tags			activitypub
+//	@Produce		json
+//	@Param			postID	path		string	true	"Post ID"
+//	@Success		200		{object}	activitypub.Note
+//	@Failure		404		{object}	error	"Record Not Found"
+//	@Failure		500		{object}	error	"Server encountered a problem"
+//	@Router			/ap/posts/{postID} [get]
+func (app *application) getNoteHandler(w http.ResponseWriter, r *http.Request) {
+	postID, err := uuid.Parse(chi.URLParam(r, "postID"))
+	if err != nil {
+		app.recordNotFoundResponse(w, r, err)
+		return
+	}
+
+	post, author, err := app.federatedPost(r.Context(), postID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			app.recordNotFoundResponse(w, r, err)
+			return
+		}
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	note := app.note(author.Username, post.ID, post.Title, post.Content, post.Tags, post.CreatedAt, post.UpdatedAt)
+	note.Context = activitypub.ContextActivityStreams
+
+	if err := writeActivityJSON(w, http.StatusOK, activitypub.ContentType, note); err != nil {
+		app.internalServerError(w, r, err)
+	}
+}
+
+// PostInbox godoc
+//
+//	@Summary		Receives activities from remote servers
+//	@Description	Accepts Follow, Undo, Create and Like activities signed with HTTP Signatures. Other activities are acknowledged and ignored.
+//	@Tags			activitypub
+//	@Accept			json
+//	@Param			username	path	string	true	"Username"
+//	@Success		202
+//	@Failure		400	{object}	error	"Bad Request"
+//	@Failure		401	{object}	error	"Unauthorized"
+//	@Failure		404	{object}	error	"Record Not Found"
+//	@Failure		500	{object}	error	"Server encountered a problem"
+//	@Router			/ap/users/{username}/inbox [post]
+func (app *application) postInboxHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := app.federatedUser(w, r, chi.URLParam(r, "username"))
+	if !ok {
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, inboxMaxBytes))
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	ctx := r.Context()
+
+	var actor *activitypub.Actor
+	_, err = activitypub.VerifyRequest(r, body, signatureMaxSkew, func(keyID string) (*rsa.PublicKey, error) {
+		a, key, err := app.apClient.PublicKey(ctx, keyID)
+		actor = a
+		return key, err
+	})
+	if err != nil {
+		app.unauthorizedErrorResponse(w, r, err)
+		return
+	}
+
+	var activity activitypub.Activity
+	if err := json.Unmarshal(body, &activity); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	// servers may only speak for their own actors
+	if activity.Actor != actor.ID {
+		app.unauthorizedErrorResponse(w, r, errors.New("activity actor does not match the signature"))
+		return
+	}
+
+	switch activity.Type {
+	case "Follow":
+		err = app.handleFollowActivity(ctx, &user, actor, &activity, body)
+	case "Undo":
+		err = app.handleUndoActivity(ctx, &user, actor, &activity)
+	case "Create":
+		err = app.handleCreateActivity(ctx, actor, &activity)
+	case "Like":
+		err = app.handleLikeActivity(ctx, actor, activity.ObjectID())
+	}
+	if err != nil {
+		if errors.Is(err, errUnsupportedActivity) {
+			app.badRequestResponse(w, r, err)
+			return
+		}
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+var errUnsupportedActivity = errors.New("unsupported activity")
+
+// handleFollowActivity records the remote follower and accepts right away.
+// Private users are not federated, so there is nothing to approve.
+func (app *application) handleFollowActivity(ctx context.Context, user *store.Users, actor *activitypub.Actor, follow *activitypub.Activity, raw json.RawMessage) error {
+	if follow.ObjectID() != app.actorIRI(user.Username) {
+		return fmt.Errorf("%w: follow is not addressed to %s", errUnsupportedActivity, user.Username)
+	}
+
+	err := app.store.AddRemoteFollower(ctx, store.AddRemoteFollowerParams{
+		UserID:      user.ID,
+		ActorID:     actor.ID,
+		Inbox:       actor.Inbox,
+		SharedInbox: sql.NullString{String: actor.SharedInbox(), Valid: actor.SharedInbox() != actor.Inbox},
+	})
+	if err != nil {
+		return err
+	}
+
+	accept := activitypub.Activity{
+		Context: activitypub.ContextActivityStreams,
+		ID:      app.actorIRI(user.Username) + "#accepts/" + uuid.NewString(),
+		Type:    "Accept",
+		Actor:   app.actorIRI(user.Username),
+		Object:  raw,
+	}
+
+	return app.enqueueActivity(ctx, user.ID, []string{actor.Inbox}, accept)
+}
+
+func (app *application) handleUndoActivity(ctx context.Context, user *store.Users, actor *activitypub.Actor, undo *activitypub.Activity) error {
+	inner, err := undo.EmbeddedActivity()
+	if err != nil {
+		return fmt.Errorf("%w: %w", errUnsupportedActivity, err)
+	}
+	if inner.Actor != actor.ID {
+		return fmt.Errorf("%w: undone activity belongs to another actor", errUnsupportedActivity)
+	}
+
+	switch inner.Type {
+	case "Follow":
+		_, err := app.store.RemoveRemoteFollower(ctx, store.RemoveRemoteFollowerParams{
+			UserID:  user.ID,
+			ActorID: actor.ID,
+		})
+		return err
+	case "Like":
+		postID, ok := app.localPostID(inner.ObjectID())
+		if !ok {
+			return nil
+		}
+		return app.store.RemoveRemoteLike(ctx, store.RemoveRemoteLikeParams{
+			PostID:  postID,
+			ActorID: actor.ID,
+		})
+	}
+
+	return nil
+}
+
+// handleCreateActivity keeps notes that reply to local posts. Everything
+// else a remote account posts is of no interest to us.
+func (app *application) handleCreateActivity(ctx context.Context, actor *activitypub.Actor, create *activitypub.Activity) error {
+	note, err := create.EmbeddedNote()
+	if err != nil {
+		return nil
+	}
+
+	postID, ok := app.localPostID(note.InReplyTo)
+	if !ok {
+		return nil
+	}
+
+	if _, _, err := app.federatedPost(ctx, postID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		return err
+	}
+
+	if note.ID == "" || note.AttributedTo != actor.ID {
+		return fmt.Errorf("%w: note is not attributed to the sender", errUnsupportedActivity)
+	}
+
+	published, err := time.Parse(time.RFC3339, note.Published)
+	if err != nil {
+		published = time.Now()
+	}
+
+	return app.store.CreateRemoteReply(ctx, store.CreateRemoteReplyParams{
+		ID:          note.ID,
+		PostID:      postID,
+		ActorID:     actor.ID,
+		Content:     note.Content,
+		PublishedAt: published,
+	})
+}
+
+func (app *application) handleLikeActivity(ctx context.Context, actor *activitypub.Actor, object string) error {
+	postID, ok := app.localPostID(object)
+	if !ok {
+		return nil
+	}
+
+	if _, _, err := app.federatedPost(ctx, postID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		return err
+	}
+
+	return app.store.AddRemoteLike(ctx, store.AddRemoteLikeParams{
+		PostID:  postID,
+		ActorID: actor.ID,
+	})
+}
+
+// federatedUser loads a user visible to other servers. Private users are
+// reported as missing so their existence is not leaked.
+func (app *application) federatedUser(w http.ResponseWriter, r *http.Request, username string) (store.Users, bool) {
+	user, err := app.store.GetUserByUsername(r.Context(), username)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			app.recordNotFoundResponse(w, r, err)
+			return user, false
+		}
+		app.internalServerError(w, r, err)
+		return user, false
+	}
+
+	if user.IsPrivate || !user.Verified || user.BannedAt.Valid {
+		app.recordNotFoundResponse(w, r, errors.New("user is not federated"))
+		return user, false
+	}
+
+	return user, true
+}
+
+// federatedPost loads a post of a federated user, or sql.ErrNoRows.
+func (app *application) federatedPost(ctx context.Context, postID uuid.UUID) (store.Posts, store.Users, error) {
+	post, err := app.store.GetPostsById(ctx, postID)
+	if err != nil {
+		return post, store.Users{}, err
+	}
+	if post.HiddenAt.Valid {
+		return post, store.Users{}, sql.ErrNoRows
+	}
+
+	author, err := app.store.GetUserByUserId(ctx, post.UserID)
+	if err != nil {
+		return post, author, err
+	}
+
+	if author.IsPrivate || !author.Verified || author.BannedAt.Valid {
+		return post, author, sql.ErrNoRows
+	}
+
+	return post, author, nil
+}
+
+// actorKey returns the signing key of a user, creating it on first use.
+func (app *application) actorKey(ctx context.Context, userID uuid.UUID) (store.ActorKeys, *rsa.PrivateKey, error) {
+	key, err := app.store.GetActorKey(ctx, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		pub, priv, genErr := activitypub.GenerateKey()
+		if genErr != nil {
+			return key, nil, genErr
+		}
+
+		// a concurrent request may win the insert, so read back whichever key was kept
+		if err := app.store.CreateActorKey(ctx, store.CreateActorKeyParams{
+			UserID:        userID,
+			PublicKeyPem:  pub,
+			PrivateKeyPem: priv,
+		}); err != nil {
+			return key, nil, err
+		}
+		key, err = app.store.GetActorKey(ctx, userID)
+	}
+	if err != nil {
+		return key, nil, err
+	}
+
+	private, err := activitypub.ParsePrivateKey(key.PrivateKeyPem)
+	return key, private, err
+}
+
+func (app *application) note(username string, postID uuid.UUID, title, content string, tags []string, createdAt, updatedAt time.Time) activitypub.Note {
+	note := activitypub.Note{
+		ID:           app.noteIRI(postID),
+		Type:         "Note",
+		AttributedTo: app.actorIRI(username),
+		Content:      fmt.Sprintf("<p><strong>%s</strong></p><p>%s</p>", html.EscapeString(title), html.EscapeString(content)),
+		URL:          fmt.Sprintf("%s/posts/%s", app.config.frontendURL, postID),
+		Published:    createdAt.UTC().Format(time.RFC3339),
+		To:           []string{activitypub.Public},
+		Cc:           []string{app.actorIRI(username) + "/followers"},
+	}
+
+	if updatedAt.After(createdAt) {
+		note.Updated = updatedAt.UTC().Format(time.RFC3339)
+	}
+
+	for _, tag := range tags {
+		note.Tag = append(note.Tag, activitypub.Hashtag{
+			Type: "Hashtag",
+			Href: fmt.Sprintf("%s/tags/%s", app.config.frontendURL, tag),
+			Name: "#" + tag,
+		})
+	}
+
+	return note
+}
+
+func (app *application) createActivity(note activitypub.Note) (activitypub.Activity, error) {
+	create, err := activitypub.NewActivity(note.ID+"/activity", "Create", note.AttributedTo, note)
+	if err != nil {
+		return create, err
+	}
+
+	create.To = note.To
+	create.Cc = note.Cc
+	create.Published = note.Published
+
+	return create, nil
+}
+
+func (app *application) enqueueActivity(ctx context.Context, senderID uuid.UUID, inboxes []string, activity activitypub.Activity) error {
+	raw, err := json.Marshal(activity)
+	if err != nil {
+		return err
+	}
+
+	for _, inbox := range inboxes {
+		err := app.store.EnqueueDelivery(ctx, store.EnqueueDeliveryParams{
+			SenderID: senderID,
+			Inbox:    inbox,
+			Activity: raw,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// federatePost queues a Create for every server following the author. It
+// runs in the background like the other post fan-outs.
+func (app *application) federatePost(author *store.Users, post store.Posts) {
+	if !app.config.activityPub.enabled || author.IsPrivate {
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), QueryTimeoutDuration)
+		defer cancel()
+
+		inboxes, err := app.store.GetRemoteFollowerInboxes(ctx, author.ID)
+		if err == nil && len(inboxes) > 0 {
+			var create activitypub.Activity
+			create, err = app.createActivity(app.note(author.Username, post.ID, post.Title, post.Content, post.Tags, post.CreatedAt, post.UpdatedAt))
+			if err == nil {
+				err = app.enqueueActivity(ctx, author.ID, inboxes, create)
+			}
+		}
+		if err != nil {
+			app.logger.Warnw("error federating post", "post", post.ID, "error", err.Error())
+		}
+	}()
+}
+
+// deliverActivities sends a batch of queued activities. Claimed jobs are
+// leased so a crashed worker only delays them.
+func (app *application) deliverActivities(ctx context.Context) error {
+	jobs, err := app.store.ClaimDeliveries(ctx, store.ClaimDeliveriesParams{
+		LeaseUntil: time.Now().Add(deliveryLease),
+		Limit:      deliveryBatchSize,
+	})
+	if err != nil {
+		return err
+	}
+
+	keys := make(map[uuid.UUID]*rsa.PrivateKey)
+	usernames := make(map[uuid.UUID]string)
+
+	for _, job := range jobs {
+		key, ok := keys[job.SenderID]
+		if !ok {
+			sender, err := app.store.GetUserByUserId(ctx, job.SenderID)
+			if err != nil {
+				return err
+			}
+			_, key, err = app.actorKey(ctx, job.SenderID)
+			if err != nil {
+				return err
+			}
+			keys[job.SenderID], usernames[job.SenderID] = key, sender.Username
+		}
+
+		keyID := app.actorIRI(usernames[job.SenderID]) + "#main-key"
+		err := app.apClient.Deliver(ctx, job.Inbox, job.Activity, keyID, key)
+		if err := app.finishDelivery(ctx, job, err); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (app *application) finishDelivery(ctx context.Context, job store.ClaimDeliveriesRow, deliveryErr error) error {
+	if deliveryErr == nil {
+		return app.store.CompleteDelivery(ctx, job.ID)
+	}
+
+	lastError := sql.NullString{String: deliveryErr.Error(), Valid: true}
+
+	var derr *activitypub.DeliveryError
+	if (errors.As(deliveryErr, &derr) && derr.Permanent()) || job.Attempts >= deliveryMaxAttempts {
+		app.logger.Warnw("giving up on activity delivery", "inbox", job.Inbox, "attempts", job.Attempts, "error", deliveryErr.Error())
+		return app.store.FailDelivery(ctx, store.FailDeliveryParams{ID: job.ID, LastError: lastError})
+	}
+
+	// back off exponentially from one minute
+	backoff := min(time.Minute<<(job.Attempts-1), deliveryMaxBackoff)
+
+	return app.store.RetryDelivery(ctx, store.RetryDeliveryParams{
+		ID:            job.ID,
+		NextAttemptAt: time.Now().Add(backoff),
+		LastError:     lastError,
+	})
+}
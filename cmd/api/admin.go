@@ -0,0 +1,301 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/JaskiratAnand/go-social/internal/audit"
+	"github.com/JaskiratAnand/go-social/internal/mailer"
+	"github.com/JaskiratAnand/go-social/internal/realtime"
+	"github.com/JaskiratAnand/go-social/internal/store"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// accountRestriction explains why user may not sign in or use the API, or
+// returns an empty string when nothing stops them. Suspensions end on their
+// own once suspended_until has passed.
+func accountRestriction(user *store.Users, now time.Time) string {
+	if user.BannedAt.Valid {
+		return "account banned" + reasonSuffix(user.BanReason)
+	}
+	if user.SuspendedUntil.Valid && now.Before(user.SuspendedUntil.Time) {
+		return fmt.Sprintf("account suspended until %s%s", user.SuspendedUntil.Time.UTC().Format(time.RFC3339), reasonSuffix(user.SuspensionReason))
+	}
+	return ""
+}
+
+func reasonSuffix(reason string) string {
+	if reason == "" {
+		return ""
+	}
+	return ": " + reason
+}
+
+// notifyAccountAction emails user about an action taken on their account.
+// It runs in the background, a failed email does not undo the action.
+func (app *application) notifyAccountAction(user store.Users, templateFile, reason string, until time.Time) {
+	vars := struct {
+		Username string
+		Reason   string
+		Until    string
+	}{
+		Username: user.Username,
+		Reason:   reason,
+		Until:    until.UTC().Format("January 2, 2006 15:04 MST"),
+	}
+
+	isProdEnv := app.config.env == "production"
+
+	go func() {
+		statusCode, err := app.mailer.Send(templateFile, user.Username, user.Email, vars, !isProdEnv)
+		if err != nil {
+			app.logger.Errorw("error sending account action email", "template", templateFile, "user", user.ID, "error", err)
+			return
+		}
+		app.logger.Infow("Email sent", "template", templateFile, "status code", statusCode)
+	}()
+}
+
+type SuspendUserPayload struct {
+	Hours  int    `json:"hours" validate:"required,gte=1,lte=8760"`
+	Reason string `json:"reason" validate:"required,max=1000"`
+}
+
+// SuspendUser godoc
+//
+//	@Summary		Suspends a user
+//	@Description	Stops a user from signing in or using the API for a number of hours.
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Param			userID	path	string				true	"User ID"
+//	@Param			payload	body	SuspendUserPayload	true	"Suspension"
+//	@Success		204		"User suspended"
+//	@Failure		400		{object}	error	"Bad Request"
+//	@Failure		403		{object}	error	"Forbidden"
+//	@Failure		404		{object}	error	"Record Not Found"
+//	@Failure		500		{object}	error	"Server encountered a problem"
+//	@Security		ApiKeyAuth
+//	@Router			/admin/users/{userID}/suspend [put]
+func (app *application) suspendUserHandler(w http.ResponseWriter, r *http.Request) {
+	var payload SuspendUserPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	target, ok := app.sanctionTarget(w, r)
+	if !ok {
+		return
+	}
+
+	ctx := r.Context()
+	until := time.Now().Add(time.Duration(payload.Hours) * time.Hour)
+
+	after := target
+	after.SuspendedUntil = sql.NullTime{Time: until, Valid: true}
+	after.SuspensionReason = payload.Reason
+
+	err := app.store.ExecTx(ctx, func(q *store.Queries) error {
+		if err := q.SuspendUser(ctx, store.SuspendUserParams{
+			ID:               target.ID,
+			SuspendedUntil:   after.SuspendedUntil,
+			SuspensionReason: after.SuspensionReason,
+		}); err != nil {
+			return err
+		}
+		return audit.Record(ctx, q, app.auditEntry(r, audit.UserSuspend, "user", target.ID.String(), sanctionsOf(target), sanctionsOf(after)))
+	})
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	app.applySuspension(ctx, target, payload.Reason, until)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type BanUserPayload struct {
+	Reason string `json:"reason" validate:"required,max=1000"`
+}
+
+// BanUser godoc
+//
+//	@Summary		Bans a user
+//	@Description	Stops a user from signing in until reinstated, and hides their posts from feeds.
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Param			userID	path	string			true	"User ID"
+//	@Param			payload	body	BanUserPayload	true	"Ban"
+//	@Success		204		"User banned"
+//	@Failure		400		{object}	error	"Bad Request"
+//	@Failure		403		{object}	error	"Forbidden"
+//	@Failure		404		{object}	error	"Record Not Found"
+//	@Failure		500		{object}	error	"Server encountered a problem"
+//	@Security		ApiKeyAuth
+//	@Router			/admin/users/{userID}/ban [put]
+func (app *application) banUserHandler(w http.ResponseWriter, r *http.Request) {
+	var payload BanUserPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	target, ok := app.sanctionTarget(w, r)
+	if !ok {
+		return
+	}
+
+	ctx := r.Context()
+
+	after := target
+	after.BannedAt = sql.NullTime{Time: time.Now(), Valid: true}
+	after.BanReason = payload.Reason
+
+	err := app.store.ExecTx(ctx, func(q *store.Queries) error {
+		if err := q.BanUser(ctx, store.BanUserParams{
+			ID:        target.ID,
+			BanReason: payload.Reason,
+		}); err != nil {
+			return err
+		}
+		return audit.Record(ctx, q, app.auditEntry(r, audit.UserBan, "user", target.ID.String(), sanctionsOf(target), sanctionsOf(after)))
+	})
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	app.cacheStorage.Users.Delete(ctx, target.ID)
+	app.disconnectUser(ctx, target.ID)
+	// cached feed pages and the explore feed still hold their posts, cached
+	// posts are checked against their author in getPost
+	app.invalidatePostFeeds(target.ID, nil)
+	app.cacheStorage.Explore.Delete(ctx)
+	app.notifyAccountAction(target, mailer.UserBannedTemplate, payload.Reason, time.Time{})
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ReinstateUser godoc
+//
+//	@Summary		Reinstates a user
+//	@Description	Lifts the suspension or ban of a user.
+//	@Tags			admin
+//	@Produce		json
+//	@Param			userID	path	string	true	"User ID"
+//	@Success		204		"User reinstated"
+//	@Failure		400		{object}	error	"Bad Request"
+//	@Failure		403		{object}	error	"Forbidden"
+//	@Failure		404		{object}	error	"Record Not Found"
+//	@Failure		500		{object}	error	"Server encountered a problem"
+//	@Security		ApiKeyAuth
+//	@Router			/admin/users/{userID}/reinstate [put]
+func (app *application) reinstateUserHandler(w http.ResponseWriter, r *http.Request) {
+	target, ok := app.sanctionTarget(w, r)
+	if !ok {
+		return
+	}
+
+	ctx := r.Context()
+
+	if accountRestriction(&target, time.Now()) == "" {
+		app.badRequestResponse(w, r, errors.New("user is not suspended or banned"))
+		return
+	}
+
+	err := app.store.ExecTx(ctx, func(q *store.Queries) error {
+		if err := q.ReinstateUser(ctx, target.ID); err != nil {
+			return err
+		}
+		return audit.Record(ctx, q, app.auditEntry(r, audit.UserReinstate, "user", target.ID.String(), sanctionsOf(target), userSanctions{}))
+	})
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	app.cacheStorage.Users.Delete(ctx, target.ID)
+	if target.BannedAt.Valid {
+		app.invalidatePostFeeds(target.ID, nil)
+		app.cacheStorage.Explore.Delete(ctx)
+	}
+	app.notifyAccountAction(target, mailer.UserReinstatedTemplate, "", time.Time{})
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// applySuspension makes a suspension stored for user take effect right away,
+// whether it came from an admin or the moderation queue: the cached user is
+// dropped, their open connections are closed and they are told by email.
+func (app *application) applySuspension(ctx context.Context, user store.Users, reason string, until time.Time) {
+	app.cacheStorage.Users.Delete(ctx, user.ID)
+	app.disconnectUser(ctx, user.ID)
+	app.notifyAccountAction(user, mailer.UserSuspendedTemplate, reason, until)
+}
+
+// disconnectUser closes the open streams and websockets of a user who was
+// just suspended or banned. Reconnecting fails in AuthTokenMiddleware.
+func (app *application) disconnectUser(ctx context.Context, userID uuid.UUID) {
+	if err := app.hub.Disconnect(ctx, realtime.UserTopic(userID)); err != nil {
+		app.logger.Warnw("error disconnecting user", "user", userID, "error", err.Error())
+	}
+}
+
+// sanctionTarget loads the user named by the userID param, writing the
+// response when it cannot or when the caller may not act on them. Admins
+// cannot sanction themselves or each other.
+func (app *application) sanctionTarget(w http.ResponseWriter, r *http.Request) (store.Users, bool) {
+	userID, err := uuid.Parse(chi.URLParam(r, "userID"))
+	if err != nil {
+		app.badRequestResponse(w, r, errors.New("invalid user-id"))
+		return store.Users{}, false
+	}
+
+	ctx := r.Context()
+	admin := app.GetUserFromCtx(r)
+
+	if userID == admin.ID {
+		app.badRequestResponse(w, r, errors.New("you cannot sanction yourself"))
+		return store.Users{}, false
+	}
+
+	target, err := app.store.GetUserByUserId(ctx, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			app.recordNotFoundResponse(w, r, err)
+			return target, false
+		}
+		app.internalServerError(w, r, err)
+		return target, false
+	}
+
+	isAdmin, err := app.checkRolePrecedence(ctx, &target, "ADMIN")
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return target, false
+	}
+	if isAdmin {
+		app.forbiddenResponse(w, r)
+		return target, false
+	}
+
+	return target, true
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/JaskiratAnand/go-social/internal/store"
+)
+
+// postingKind is what a posting quota counts.
+type postingKind string
+
+const (
+	postingPosts    postingKind = "posts"
+	postingComments postingKind = "comments"
+)
+
+// quotaFor returns the first quota matching the account, or nil when none
+// does.
+func (cfg antiSpamConfig) quotaFor(user *store.Users, role string, now time.Time) *postingQuota {
+	for i := range cfg.quotas {
+		q := &cfg.quotas[i]
+
+		if len(q.roles) > 0 && !slices.Contains(q.roles, role) {
+			continue
+		}
+		if q.maxAccountAge > 0 && now.Sub(user.CreatedAt) >= q.maxAccountAge {
+			continue
+		}
+		return q
+	}
+	return nil
+}
+
+func (q *postingQuota) limit(kind postingKind) int {
+	if kind == postingComments {
+		return q.comments
+	}
+	return q.posts
+}
+
+// reason explains a quota to the user who hit it.
+func (q *postingQuota) reason(kind postingKind) string {
+	if q.maxAccountAge > 0 {
+		return fmt.Sprintf("posting quota exceeded: new accounts can create %d %s per %s during their first %s",
+			q.limit(kind), kind, formatPeriod(q.window), formatPeriod(q.maxAccountAge))
+	}
+	return fmt.Sprintf("posting quota exceeded: accounts can create %d %s per %s", q.limit(kind), kind, formatPeriod(q.window))
+}
+
+func formatPeriod(d time.Duration) string {
+	switch {
+	case d == time.Hour:
+		return "hour"
+	case d%time.Hour == 0:
+		return fmt.Sprintf("%d hours", d/time.Hour)
+	case d == time.Minute:
+		return "minute"
+	case d%time.Minute == 0:
+		return fmt.Sprintf("%d minutes", d/time.Minute)
+	}
+	return d.String()
+}
+
+// checkPostingQuota counts what user created in the window of its quota.
+// A non empty reason means the quota is used up until retryAfter.
+func (app *application) checkPostingQuota(ctx context.Context, q *store.Queries, user *store.Users, kind postingKind) (reason string, retryAfter time.Duration, err error) {
+	now := time.Now()
+
+	quota := app.config.antiSpam.quotaFor(user, app.roleNames[user.RoleID], now)
+	if quota == nil || quota.limit(kind) == 0 {
+		return "", 0, nil
+	}
+
+	since := now.Add(-quota.window)
+
+	var count int64
+	var oldest time.Time
+	switch kind {
+	case postingComments:
+		row, err := q.CountUserCommentsSince(ctx, store.CountUserCommentsSinceParams{UserID: user.ID, CreatedAt: since})
+		if err != nil {
+			return "", 0, err
+		}
+		count, oldest = row.Count, row.Oldest
+	default:
+		row, err := q.CountUserPostsSince(ctx, store.CountUserPostsSinceParams{UserID: user.ID, CreatedAt: since})
+		if err != nil {
+			return "", 0, err
+		}
+		count, oldest = row.Count, row.Oldest
+	}
+
+	if count < int64(quota.limit(kind)) {
+		return "", 0, nil
+	}
+
+	// a slot frees up once the oldest counted item leaves the window
+	return quota.reason(kind), oldest.Add(quota.window).Sub(now), nil
+}
+
+// postingRefusal is returned by checkPosting when user may not post.
+type postingRefusal struct {
+	status     int
+	reason     string
+	retryAfter time.Duration
+}
+
+func (e *postingRefusal) Error() string {
+	return e.reason
+}
+
+// checkPosting applies the posting quota and duplicate check of kind. It
+// must run in the transaction that inserts the content: the user's posting
+// lock is held until that commits, so parallel requests cannot all pass the
+// count before any of them inserts.
+func (app *application) checkPosting(ctx context.Context, q *store.Queries, user *store.Users, kind postingKind, isDuplicate func(since time.Time) (bool, error)) error {
+	if !app.config.antiSpam.enabled {
+		return nil
+	}
+
+	if err := q.LockUserPosting(ctx, user.ID); err != nil {
+		return err
+	}
+
+	reason, retryAfter, err := app.checkPostingQuota(ctx, q, user, kind)
+	if err != nil {
+		return err
+	}
+	if reason != "" {
+		return &postingRefusal{status: http.StatusTooManyRequests, reason: reason, retryAfter: retryAfter}
+	}
+
+	duplicate, err := isDuplicate(time.Now().Add(-app.config.antiSpam.duplicateWindow))
+	if err != nil {
+		return err
+	}
+	if duplicate {
+		return &postingRefusal{
+			status: http.StatusConflict,
+			reason: fmt.Sprintf("duplicate content: the same %s was posted in the last %s", kind[:len(kind)-1], formatPeriod(app.config.antiSpam.duplicateWindow)),
+		}
+	}
+
+	return nil
+}
+
+// postingRefused writes the response for a refusal from checkPosting.
+func (app *application) postingRefused(w http.ResponseWriter, r *http.Request, err error) bool {
+	var refusal *postingRefusal
+	if !errors.As(err, &refusal) {
+		return false
+	}
+
+	if refusal.status == http.StatusTooManyRequests {
+		app.quotaExceededResponse(w, r, refusal.reason, refusal.retryAfter)
+	} else {
+		app.customErrorResponse(w, r, refusal.status, refusal.reason)
+	}
+	return true
+}
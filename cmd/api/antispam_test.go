@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/JaskiratAnand/go-social/internal/filter"
+	"github.com/JaskiratAnand/go-social/internal/store"
+	"github.com/google/uuid"
+)
+
+func TestPostingQuotas(t *testing.T) {
+	cfg := antiSpamConfig{
+		quotas: []postingQuota{
+			{roles: []string{"MODERATOR", "ADMIN"}},
+			{maxAccountAge: 24 * time.Hour, posts: 5, comments: 20, window: time.Hour},
+			{posts: 30, comments: 120, window: time.Hour},
+		},
+	}
+
+	now := time.Now()
+	fresh := &store.Users{CreatedAt: now.Add(-time.Hour)}
+	old := &store.Users{CreatedAt: now.Add(-48 * time.Hour)}
+
+	tests := []struct {
+		name  string
+		user  *store.Users
+		role  string
+		posts int
+	}{
+		{"new accounts get the strict quota", fresh, "USER", 5},
+		{"older accounts get the regular quota", old, "USER", 30},
+		{"moderators are not limited", fresh, "MODERATOR", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := cfg.quotaFor(tt.user, tt.role, now)
+			if q == nil {
+				t.Fatal("expected a quota")
+			}
+			if got := q.limit(postingPosts); got != tt.posts {
+				t.Errorf("expected %d posts; got %d", tt.posts, got)
+			}
+		})
+	}
+
+	want := "posting quota exceeded: new accounts can create 5 posts per hour during their first 24 hours"
+	if got := cfg.quotas[1].reason(postingPosts); got != want {
+		t.Errorf("expected reason %q; got %q", want, got)
+	}
+}
+
+func TestCheckPostingInTransaction(t *testing.T) {
+	user := store.Users{ID: uuid.New(), RoleID: 1, CreatedAt: time.Now().Add(-48 * time.Hour)}
+
+	newApp := func(t *testing.T) (*application, sqlmock.Sqlmock) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { db.Close() })
+
+		app := TestMockApplication(t, config{
+			antiSpam: antiSpamConfig{
+				enabled:         true,
+				quotas:          []postingQuota{{posts: 1, window: time.Hour}},
+				duplicateWindow: time.Hour,
+			},
+		})
+		app.store = store.New(db)
+
+		f, _ := filter.New(nil)
+		app.contentFilter.Store(f)
+		return app, mock
+	}
+
+	createPost := func(app *application) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/v1/posts", strings.NewReader(`{"title":"Good morning","content":"hello"}`))
+		req = req.WithContext(context.WithValue(req.Context(), userCtx, user))
+		return executeRequest(req, http.HandlerFunc(app.createPostHandler))
+	}
+
+	t.Run("quota is counted under the posting lock", func(t *testing.T) {
+		app, mock := newApp(t)
+
+		mock.ExpectBegin()
+		mock.ExpectExec("SELECT pg_advisory_xact_lock").WithArgs(user.ID).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery("SELECT COUNT").
+			WillReturnRows(sqlmock.NewRows([]string{"count", "oldest"}).AddRow(1, time.Now().Add(-30*time.Minute)))
+		mock.ExpectRollback()
+
+		rr := createPost(app)
+		checkResponseCode(t, http.StatusTooManyRequests, rr.Code)
+		if rr.Header().Get("Retry-After") == "" {
+			t.Error("expected a Retry-After header")
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("duplicates are the author's own", func(t *testing.T) {
+		app, mock := newApp(t)
+
+		mock.ExpectBegin()
+		mock.ExpectExec("SELECT pg_advisory_xact_lock").WithArgs(user.ID).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery("SELECT COUNT").
+			WillReturnRows(sqlmock.NewRows([]string{"count", "oldest"}).AddRow(0, time.Now()))
+		mock.ExpectQuery("SELECT EXISTS").
+			WithArgs(user.ID, "Good morning", "hello", sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+		mock.ExpectRollback()
+
+		rr := createPost(app)
+		checkResponseCode(t, http.StatusConflict, rr.Code)
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+}
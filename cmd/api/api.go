@@ -6,17 +6,24 @@ import (
 	"expvar"
 	"fmt"
 	"net/http"
+	"net/netip"
 	"os"
 	"os/signal"
+	"sync/atomic"
 	"syscall"
 	"time"
 
+	"github.com/JaskiratAnand/go-social/internal/activitypub"
 	"github.com/JaskiratAnand/go-social/internal/auth"
 	"github.com/JaskiratAnand/go-social/internal/env"
+	"github.com/JaskiratAnand/go-social/internal/filter"
 	"github.com/JaskiratAnand/go-social/internal/mailer"
+	"github.com/JaskiratAnand/go-social/internal/ranking"
 	"github.com/JaskiratAnand/go-social/internal/ratelimiter"
+	"github.com/JaskiratAnand/go-social/internal/realtime"
 	"github.com/JaskiratAnand/go-social/internal/store"
 	"github.com/JaskiratAnand/go-social/internal/store/cache"
+	"github.com/JaskiratAnand/go-social/internal/timeline"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/cors"
@@ -30,10 +37,19 @@ type application struct {
 	config        config
 	store         *store.Queries
 	cacheStorage  cache.Storage
+	cacheBus      *cache.InvalidationBus
 	logger        *zap.SugaredLogger
 	mailer        mailer.Client
 	authenticator auth.Authenticator
-	rateLimiter   *ratelimiter.FixedWindowRateLimiter
+	rateLimiter   *ratelimiter.Policies
+	janitor       *ratelimiter.Janitor
+	cursorCodec   *store.CursorCodec
+	timeline      *timeline.RedisTimeline
+	ranker        *ranking.Ranker
+	hub           *realtime.Hub
+	apClient      *activitypub.Client
+	roleNames     map[int32]string
+	contentFilter atomic.Pointer[filter.Filter]
 }
 
 type config struct {
@@ -45,7 +61,77 @@ type config struct {
 	frontendURL string
 	auth        authConfig
 	redisCfg    redisConfig
+	cache       cacheConfig
 	ratelimiter ratelimiter.Config
+	suggestions suggestionsConfig
+	explore     exploreConfig
+	feed        feedConfig
+	timeline    timeline.Config
+	realtime    realtime.Config
+	activityPub activityPubConfig
+	antiSpam    antiSpamConfig
+	filters     filtersConfig
+
+	// trustedProxies may set the client address through forwarding
+	// headers. Every other client is known by its connection address.
+	trustedProxies []netip.Prefix
+}
+
+type feedConfig struct {
+	cursorSecret string
+}
+
+type suggestionsConfig struct {
+	enabled  bool
+	interval time.Duration
+}
+
+type exploreConfig struct {
+	enabled  bool
+	interval time.Duration
+}
+
+// cacheConfig sizes the in-process cache, used on its own when Redis is
+// disabled and in front of Redis when tiered is set.
+type cacheConfig struct {
+	tiered   bool
+	memory   cache.MemoryConfig
+	localTTL time.Duration
+}
+
+// antiSpamConfig caps posting by the first quota matching an account, and
+// rejects content repeated within duplicateWindow.
+type antiSpamConfig struct {
+	enabled         bool
+	quotas          []postingQuota
+	duplicateWindow time.Duration
+}
+
+type postingQuota struct {
+	// roles left empty match every role
+	roles []string
+	// maxAccountAge matches accounts younger than it, zero matches all
+	maxAccountAge time.Duration
+	// posts and comments per window, zero is unlimited
+	posts    int
+	comments int
+	window   time.Duration
+}
+
+// filtersConfig enables the admin managed content filter rules, which are
+// reloaded every refreshInterval to pick up changes made on other instances.
+type filtersConfig struct {
+	enabled         bool
+	refreshInterval time.Duration
+}
+
+type activityPubConfig struct {
+	enabled bool
+	baseURL string
+	domain  string
+	// allowInsecure lets the client reach http and private addresses,
+	// for federating between instances on a development machine.
+	allowInsecure bool
 }
 
 type redisConfig struct {
@@ -93,21 +179,25 @@ func (app *application) mount() http.Handler {
 		AllowedOrigins:   []string{env.GetString("CORS_ALLOWED_ORIGIN", "http://localhost:5174")},
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "PATCH", "OPTIONS"},
 		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSFR-Token"},
-		ExposedHeaders:   []string{"Link"},
+		ExposedHeaders:   []string{"Link", "Retry-After", "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset"},
 		AllowCredentials: false,
 		MaxAge:           300,
 	}))
 
-	r.Use(middleware.RequestID)
-	r.Use(middleware.RealIP)
+	r.Use(app.RequestIDMiddleware())
+	r.Use(app.RealIPMiddleware())
 	r.Use(middleware.Logger)
 	r.Use(middleware.Recoverer)
-	r.Use(app.RateLimiterMiddleware)
+	r.Use(app.RateLimiterMiddleware(r))
 
-	r.Use(middleware.Timeout(60 * time.Second))
+	r.Use(app.TimeoutMiddleware(60 * time.Second))
 
 	r.Use(app.ContextMiddlware())
 
+	if app.config.activityPub.enabled {
+		r.Get("/.well-known/webfinger", app.webfingerHandler)
+	}
+
 	r.Route("/v1", func(r chi.Router) {
 		r.With(app.BasicAuthMiddleware()).Get("/health", app.healthCheckHandler)
 
@@ -125,6 +215,12 @@ func (app *application) mount() http.Handler {
 			r.Post("/token", app.createTokenHandler)
 		})
 
+		// realtime gateway
+		r.With(app.AuthTokenMiddleware()).Get("/ws", app.websocketHandler)
+
+		// explore
+		r.Get("/explore", app.getExploreHandler)
+
 		// posts
 		r.Route("/posts", func(r chi.Router) {
 			r.Use(app.AuthTokenMiddleware())
@@ -144,19 +240,126 @@ func (app *application) mount() http.Handler {
 
 		// users
 		r.Route("/users", func(r chi.Router) {
-			r.Use(app.AuthTokenMiddleware())
+			// syndication feeds for feed readers are public
+			r.Get("/{username}/feed.{format}", app.getUserSyndicationFeedHandler)
+
+			r.Group(func(r chi.Router) {
+				r.Use(app.AuthTokenMiddleware())
+
+				r.Route("/{userID}", func(r chi.Router) {
+					r.Get("/", app.getUserByIdHandler)
 
-			r.Route("/{userID}", func(r chi.Router) {
-				r.Get("/", app.getUserByIdHandler)
+					r.Put("/follow", app.followUserHandler)
+					r.Put("/unfollow", app.unfollowUserHandler)
+				})
+
+				r.Get("/username/{username}", app.getUserByUsernameHandler)
+
+				r.Route("/me", func(r chi.Router) {
+					r.Put("/privacy", app.updatePrivacyHandler)
+					r.Get("/suggestions", app.getSuggestionsHandler)
+
+					r.Route("/muted-words", func(r chi.Router) {
+						r.Get("/", app.getMutedWordsHandler)
+						r.Put("/", app.muteWordHandler)
+						r.Delete("/{word}", app.unmuteWordHandler)
+					})
+				})
+
+				r.Route("/follow-requests", func(r chi.Router) {
+					r.Get("/", app.getFollowRequestsHandler)
+					r.Put("/{userID}/approve", app.approveFollowRequestHandler)
+					r.Put("/{userID}/reject", app.rejectFollowRequestHandler)
+				})
 
-				r.Put("/follow", app.followUserHandler)
-				r.Put("/unfollow", app.unfollowUserHandler)
+				r.Group(func(r chi.Router) {
+					r.Get("/feed", app.getUserFeedHandler)
+					r.Get("/feed/stream", app.streamFeedHandler)
+				})
 			})
+		})
 
-			r.Get("/username/{username}", app.getUserByUsernameHandler)
+		// tags
+		r.Route("/tags", func(r chi.Router) {
+			r.Get("/trending", app.getTrendingTagsHandler)
+			r.Get("/{tag}/feed.{format}", app.getTagSyndicationFeedHandler)
 
 			r.Group(func(r chi.Router) {
-				r.Get("/feed", app.getUserFeedHandler)
+				r.Use(app.AuthTokenMiddleware())
+
+				r.Get("/followed", app.getFollowedTagsHandler)
+				r.Put("/{tag}/follow", app.followTagHandler)
+				r.Put("/{tag}/unfollow", app.unfollowTagHandler)
+			})
+		})
+
+		// reports
+		r.With(app.AuthTokenMiddleware()).Post("/reports", app.createReportHandler)
+
+		// moderation
+		r.Route("/moderation", func(r chi.Router) {
+			r.Use(app.AuthTokenMiddleware())
+			r.Use(app.requireRoleMiddleware("MODERATOR"))
+
+			r.Route("/reports", func(r chi.Router) {
+				r.Get("/", app.getReportsHandler)
+				r.Put("/{reportID}/claim", app.claimReportHandler)
+				r.Put("/{reportID}/resolve", app.resolveReportHandler)
+				r.Put("/{reportID}/dismiss", app.dismissReportHandler)
+			})
+		})
+
+		// admin
+		r.Route("/admin", func(r chi.Router) {
+			r.Use(app.AuthTokenMiddleware())
+			r.Use(app.requireRoleMiddleware("ADMIN"))
+
+			r.Get("/audit-log", app.getAuditLogHandler)
+			r.Get("/audit-log/export", app.exportAuditLogHandler)
+
+			r.Route("/filter-rules", func(r chi.Router) {
+				r.Get("/", app.getFilterRulesHandler)
+				r.Post("/", app.createFilterRuleHandler)
+				r.Delete("/{ruleID}", app.deleteFilterRuleHandler)
+			})
+
+			r.Route("/users/{userID}", func(r chi.Router) {
+				r.Put("/suspend", app.suspendUserHandler)
+				r.Put("/ban", app.banUserHandler)
+				r.Put("/reinstate", app.reinstateUserHandler)
+			})
+		})
+
+		// federation, signed by remote servers instead of tokens
+		if app.config.activityPub.enabled {
+			r.Route("/ap", func(r chi.Router) {
+				r.Route("/users/{username}", func(r chi.Router) {
+					r.Get("/", app.getActorHandler)
+					r.Get("/outbox", app.getOutboxHandler)
+					r.Get("/followers", app.getFollowersCollectionHandler)
+					r.Post("/inbox", app.postInboxHandler)
+				})
+				r.Get("/posts/{postID}", app.getNoteHandler)
+			})
+		}
+
+		// lists
+		r.Route("/lists", func(r chi.Router) {
+			r.Use(app.AuthTokenMiddleware())
+
+			r.Post("/", app.createListHandler)
+			r.Get("/", app.getListsHandler)
+
+			r.Route("/{listID}", func(r chi.Router) {
+				r.Use(app.listContextMiddleware)
+
+				r.Get("/", app.getListHandler)
+				r.Patch("/", app.checkListOwnership(app.updateListHandler))
+				r.Delete("/", app.checkListOwnership(app.deleteListHandler))
+				r.Get("/feed", app.getListFeedHandler)
+
+				r.Put("/members/{userID}", app.checkListOwnership(app.addListMemberHandler))
+				r.Delete("/members/{userID}", app.checkListOwnership(app.removeListMemberHandler))
 			})
 		})
 	})
@@ -178,8 +381,25 @@ func (app *application) run(mux http.Handler) error {
 		IdleTimeout:  time.Minute,
 	}
 
+	// end open event streams so Shutdown does not wait on them
+	srv.RegisterOnShutdown(app.hub.Close)
+
 	shutdown := make(chan error)
 
+	// rules are loaded before listening, or content posted right after
+	// startup would skip filtering until the first refresh
+	filterCtx, cancel := context.WithTimeout(context.Background(), QueryTimeoutDuration)
+	err := app.reloadContentFilter(filterCtx)
+	cancel()
+	if err != nil {
+		return fmt.Errorf("loading content filter rules: %w", err)
+	}
+
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+
+	app.startBackgroundJobs(jobsCtx)
+
 	go func() {
 		quit := make(chan os.Signal, 1)
 
@@ -196,7 +416,7 @@ func (app *application) run(mux http.Handler) error {
 
 	app.logger.Infow("Server started", "addr", app.config.addr, "env", app.config.env)
 
-	err := srv.ListenAndServe()
+	err = srv.ListenAndServe()
 	if !errors.Is(err, http.ErrServerClosed) {
 		return err
 	}
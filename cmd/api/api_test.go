@@ -3,10 +3,18 @@ package main
 import (
 	"net/http"
 	"net/http/httptest"
+	"net/netip"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/JaskiratAnand/go-social/internal/ratelimiter"
+	"github.com/JaskiratAnand/go-social/internal/store"
+	"github.com/JaskiratAnand/go-social/internal/store/cache"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/stretchr/testify/mock"
 )
 
 func TestRateLimiterMiddleware(t *testing.T) {
@@ -52,3 +60,186 @@ func TestRateLimiterMiddleware(t *testing.T) {
 		}
 	}
 }
+
+func TestRateLimiterForwardedHeaders(t *testing.T) {
+	newApp := func(t *testing.T, trustedProxies ...netip.Prefix) http.Handler {
+		cfg := config{
+			ratelimiter: ratelimiter.Config{
+				RequestPerTimeFrame: 2,
+				TimeFrame:           time.Minute,
+				Enabled:             true,
+				Policies: []ratelimiter.Policy{
+					{Name: "internal", Networks: []string{"10.0.0.0/8"}, Exempt: true},
+				},
+			},
+			trustedProxies: trustedProxies,
+		}
+		return TestMockApplication(t, cfg).mount()
+	}
+
+	// the status after the limiter does not matter, only whether it let
+	// the request through
+	send := func(mux http.Handler, remoteAddr, forwardedFor string) int {
+		req := httptest.NewRequest(http.MethodGet, "/v1/health", nil)
+		req.RemoteAddr = remoteAddr
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+		req.Header.Set("X-Real-IP", forwardedFor)
+		return executeRequest(req, mux).Code
+	}
+
+	t.Run("spoofed headers are ignored", func(t *testing.T) {
+		mux := newApp(t)
+
+		for i, spoofed := range []string{"10.0.0.1", "203.0.113.1", "203.0.113.2"} {
+			code := send(mux, "198.51.100.7:4000", spoofed)
+			if i < 2 && code == http.StatusTooManyRequests {
+				t.Fatalf("request %d: expected to be within the budget", i)
+			}
+			if i == 2 && code != http.StatusTooManyRequests {
+				t.Errorf("expected a spoofed header to neither exempt the client nor reset its budget; got %d", code)
+			}
+		}
+	})
+
+	t.Run("trusted proxies forward the client address", func(t *testing.T) {
+		mux := newApp(t, netip.MustParsePrefix("192.0.2.0/24"))
+
+		for range 3 {
+			if code := send(mux, "192.0.2.1:4000", "10.0.0.1"); code == http.StatusTooManyRequests {
+				t.Fatal("expected the forwarded internal address to be exempt")
+			}
+		}
+
+		// only the hop added by the proxy counts, not what the client sent
+		for i := range 3 {
+			code := send(mux, "192.0.2.1:4000", "10.0.0.1, 203.0.113.9")
+			if i == 2 && code != http.StatusTooManyRequests {
+				t.Errorf("expected the client address to be limited; got %d", code)
+			}
+		}
+	})
+}
+
+func TestRequestIDMiddleware(t *testing.T) {
+	app := TestMockApplication(t, config{})
+
+	var got string
+	mux := app.RequestIDMiddleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = middleware.GetReqID(r.Context())
+	}))
+
+	cases := []struct {
+		in   string
+		keep bool
+	}{
+		{"3f2c9a1e-7b4d-4e0a-9c57-2d1f0e6b8a90", true},
+		{"abc123", true},
+		{`=HYPERLINK("https://evil.example","open")`, false},
+		{"id with spaces", false},
+		{strings.Repeat("a", 65), false},
+	}
+
+	for _, c := range cases {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(middleware.RequestIDHeader, c.in)
+		executeRequest(req, mux)
+
+		if c.keep && got != c.in {
+			t.Errorf("%q: expected the id to be kept; got %q", c.in, got)
+		}
+		if !c.keep && (got == c.in || got == "") {
+			t.Errorf("%q: expected a generated id; got %q", c.in, got)
+		}
+	}
+}
+
+func TestLongLivedRequests(t *testing.T) {
+	app := TestMockApplication(t, config{})
+
+	// handlers without a deadline answer right away, the others wait for it
+	mux := app.TimeoutMiddleware(10 * time.Millisecond)(app.ContextMiddlware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := r.Context().Deadline(); !ok {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		<-r.Context().Done()
+	})))
+
+	cases := []struct {
+		path    string
+		upgrade bool
+		want    int
+	}{
+		{"/v1/users/feed", false, http.StatusGatewayTimeout},
+		{"/v1/users/feed", true, http.StatusGatewayTimeout},
+		{"/v1/posts/search", true, http.StatusGatewayTimeout},
+		{wsPath, false, http.StatusGatewayTimeout},
+		{wsPath, true, http.StatusOK},
+		{feedStreamPath, false, http.StatusOK},
+	}
+
+	for _, c := range cases {
+		req := httptest.NewRequest(http.MethodGet, c.path, nil)
+		if c.upgrade {
+			req.Header.Set("Connection", "Upgrade")
+			req.Header.Set("Upgrade", "websocket")
+		}
+
+		if rr := executeRequest(req, mux); rr.Code != c.want {
+			t.Errorf("%s (upgrade %v): expected %d; got %d", c.path, c.upgrade, c.want, rr.Code)
+		}
+	}
+}
+
+func TestRateLimiterResolvesTokenOnce(t *testing.T) {
+	db, sqlMock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	cfg := config{
+		ratelimiter: ratelimiter.Config{RequestPerTimeFrame: 10, TimeFrame: time.Minute, Enabled: true},
+		redisCfg:    redisConfig{enabled: true},
+	}
+	app := TestMockApplication(t, cfg)
+	app.store = store.New(db)
+
+	// the mock cache never hits, every resolution reaches the database
+	users := app.cacheStorage.Users.(*cache.MockUserCache)
+	users.On("Get", mock.Anything).Return(nil, nil)
+	users.On("Set", mock.Anything).Return(nil)
+
+	token, err := app.authenticator.GenerateToken(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	userID, err := app.tokenUserID(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sqlMock.ExpectQuery("GetUserByUserId").WithArgs(userID).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "email", "username", "password", "created_at", "verified", "role_id", "is_private",
+			"suspended_until", "suspension_reason", "banned_at", "ban_reason",
+		}).AddRow(userID, "a@example.com", "alice", []byte{}, time.Now(), true, 1, false, nil, "", nil, ""))
+
+	r := chi.NewRouter()
+	r.Use(app.RateLimiterMiddleware(r))
+	r.With(app.AuthTokenMiddleware()).Get("/v1/me", func(w http.ResponseWriter, r *http.Request) {
+		if app.GetUserFromCtx(r).ID != userID {
+			t.Error("expected the authenticated user in the context")
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/me", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	checkResponseCode(t, http.StatusNoContent, executeRequest(req, r).Code)
+
+	users.AssertNumberOfCalls(t, "Get", 1)
+	if err := sqlMock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
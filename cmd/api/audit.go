@@ -0,0 +1,231 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/JaskiratAnand/go-social/internal/audit"
+	"github.com/JaskiratAnand/go-social/internal/store"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/google/uuid"
+)
+
+// auditExportPageSize is the number of entries read per query by exports.
+const auditExportPageSize = 1000
+
+// auditEntry describes an action taken by the user of r, if any.
+func (app *application) auditEntry(r *http.Request, action, targetType, targetID string, before, after any) audit.Entry {
+	e := audit.Entry{
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		Before:     before,
+		After:      after,
+		RequestID:  middleware.GetReqID(r.Context()),
+	}
+
+	if ip := remoteIP(r); ip.IsValid() {
+		e.IP = ip.String()
+	}
+	if user, ok := r.Context().Value(userCtx).(store.Users); ok {
+		e.ActorID = user.ID
+	}
+
+	return e
+}
+
+// recordSecurityEvent records an event no other write depends on. A failure
+// is logged rather than failing the request.
+func (app *application) recordSecurityEvent(ctx context.Context, e audit.Entry) {
+	if err := audit.Record(ctx, app.store, e); err != nil {
+		app.logger.Errorw("error recording audit log", "action", e.Action, "error", err.Error())
+	}
+}
+
+// userSanctions is what the audit log keeps of a user for sanctions,
+// leaving out their credentials.
+type userSanctions struct {
+	SuspendedUntil   *time.Time `json:"suspended_until"`
+	SuspensionReason string     `json:"suspension_reason"`
+	BannedAt         *time.Time `json:"banned_at"`
+	BanReason        string     `json:"ban_reason"`
+}
+
+func sanctionsOf(user store.Users) userSanctions {
+	s := userSanctions{
+		SuspensionReason: user.SuspensionReason,
+		BanReason:        user.BanReason,
+	}
+	if user.SuspendedUntil.Valid {
+		s.SuspendedUntil = &user.SuspendedUntil.Time
+	}
+	if user.BannedAt.Valid {
+		s.BannedAt = &user.BannedAt.Time
+	}
+	return s
+}
+
+// parseAuditQuery reads the filters shared by the audit log endpoints.
+// cursor is the id of the last entry of the previous page.
+func parseAuditQuery(r *http.Request) (store.GetAuditLogsParams, error) {
+	qs := r.URL.Query()
+	params := store.GetAuditLogsParams{Limit: 50}
+
+	if v := qs.Get("actor_id"); v != "" {
+		actorID, err := uuid.Parse(v)
+		if err != nil {
+			return params, fmt.Errorf("invalid actor_id %q", v)
+		}
+		params.ActorID = uuid.NullUUID{UUID: actorID, Valid: true}
+	}
+
+	for name, dst := range map[string]*sql.NullString{
+		"action":      &params.Action,
+		"target_type": &params.TargetType,
+		"target_id":   &params.TargetID,
+	} {
+		if v := qs.Get(name); v != "" {
+			*dst = sql.NullString{String: v, Valid: true}
+		}
+	}
+
+	for name, dst := range map[string]*sql.NullTime{"since": &params.Since, "until": &params.Until} {
+		v := qs.Get(name)
+		if v == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return params, fmt.Errorf("invalid %s %q, expected RFC 3339", name, v)
+		}
+		*dst = nullTime(t)
+	}
+
+	if v := qs.Get("cursor"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || id <= 0 {
+			return params, fmt.Errorf("invalid cursor %q", v)
+		}
+		params.BeforeID = sql.NullInt64{Int64: id, Valid: true}
+	}
+
+	if v := qs.Get("limit"); v != "" {
+		limit, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || limit < 1 || limit > 200 {
+			return params, fmt.Errorf("invalid limit %q, expected 1 to 200", v)
+		}
+		params.Limit = limit
+	}
+
+	return params, nil
+}
+
+// GetAuditLog godoc
+//
+//	@Summary		Fetches the audit log
+//	@Description	Lists privileged actions and security events, newest first.
+//	@Tags			admin
+//	@Produce		json
+//	@Param			actor_id	query		string	false	"Actor ID"
+//	@Param			action		query		string	false	"Action, such as post.update"
+//	@Param			target_type	query		string	false	"Target type"
+//	@Param			target_id	query		string	false	"Target ID"
+//	@Param			since		query		string	false	"RFC 3339 time"
+//	@Param			until		query		string	false	"RFC 3339 time"
+//	@Param			cursor		query		string	false	"Cursor from next_cursor"
+//	@Param			limit		query		int		false	"Limit, 50 by default"
+//	@Success		200			{object}	[]store.AuditLog
+//	@Failure		400			{object}	error	"Bad Request"
+//	@Failure		403			{object}	error	"Forbidden"
+//	@Failure		500			{object}	error	"Server encountered a problem"
+//	@Security		ApiKeyAuth
+//	@Router			/admin/audit-log [get]
+func (app *application) getAuditLogHandler(w http.ResponseWriter, r *http.Request) {
+	params, err := parseAuditQuery(r)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	entries, err := app.store.GetAuditLogs(r.Context(), params)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	var nextCursor string
+	if int64(len(entries)) == params.Limit {
+		nextCursor = strconv.FormatInt(entries[len(entries)-1].ID, 10)
+	}
+
+	setLinkHeader(w, r, nextCursor, "")
+
+	if err := app.cursorJSONResponse(w, http.StatusOK, entries, nextCursor, ""); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+// ExportAuditLog godoc
+//
+//	@Summary		Exports the audit log
+//	@Description	Streams every entry matching the filters as CSV, newest first.
+//	@Tags			admin
+//	@Produce		text/csv
+//	@Param			actor_id	query		string	false	"Actor ID"
+//	@Param			action		query		string	false	"Action, such as post.update"
+//	@Param			target_type	query		string	false	"Target type"
+//	@Param			target_id	query		string	false	"Target ID"
+//	@Param			since		query		string	false	"RFC 3339 time"
+//	@Param			until		query		string	false	"RFC 3339 time"
+//	@Success		200			{string}	string	"CSV"
+//	@Failure		400			{object}	error	"Bad Request"
+//	@Failure		403			{object}	error	"Forbidden"
+//	@Failure		500			{object}	error	"Server encountered a problem"
+//	@Security		ApiKeyAuth
+//	@Router			/admin/audit-log/export [get]
+func (app *application) exportAuditLogHandler(w http.ResponseWriter, r *http.Request) {
+	params, err := parseAuditQuery(r)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+	params.Limit = auditExportPageSize
+
+	// exports are recorded before they start, a failed export was still an
+	// attempt to read the log
+	app.recordSecurityEvent(r.Context(), app.auditEntry(r, audit.LogExport, "audit_log", "", nil, r.URL.Query()))
+
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", `attachment; filename="audit-log.csv"`)
+
+	csv := audit.NewCSVWriter(w)
+
+	for {
+		// every page gets the query timeout, the export as a whole may take
+		// longer than one request
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(r.Context()), QueryTimeoutDuration)
+		entries, err := app.store.GetAuditLogs(ctx, params)
+		cancel()
+		if err != nil {
+			// the header may be out already, the truncated file is all
+			// the client gets
+			app.logger.Errorw("error exporting audit log", "error", err.Error())
+			return
+		}
+
+		if err := csv.Write(entries); err != nil {
+			app.logger.Warnw("audit log export aborted", "error", err.Error())
+			return
+		}
+
+		if len(entries) < auditExportPageSize {
+			return
+		}
+		params.BeforeID = sql.NullInt64{Int64: entries[len(entries)-1].ID, Valid: true}
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/JaskiratAnand/go-social/internal/audit"
 	"github.com/JaskiratAnand/go-social/internal/mailer"
 	"github.com/JaskiratAnand/go-social/internal/store"
 	"github.com/go-chi/chi/v5"
@@ -137,6 +138,7 @@ func (app *application) registerUserHandler(w http.ResponseWriter, r *http.Reque
 		if err := app.store.DeleteUser(ctx, userID); err != nil {
 			app.logger.Errorw("error deleting user", "error", err)
 		}
+		app.cacheStorage.Users.Delete(ctx, userID)
 		return
 	}
 
@@ -193,6 +195,8 @@ func (app *application) activateUserHandler(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
+	app.cacheStorage.Users.Delete(ctx, invite.UserID)
+
 	err = app.store.DeleteInvitationByUserId(ctx, invite.UserID)
 	if err != nil {
 		app.internalServerError(w, r, err)
@@ -221,6 +225,7 @@ type CreateUserTokenPayload struct {
 //	@Success		200		{string}	string					"Token"
 //	@Failure		400		{object}	error					"Bad Request"
 //	@Failure		401		{object}	error					"Unauthorized"
+//	@Failure		403		{object}	error					"Suspended or banned"
 //	@Failure		500		{object}	error					"Server encountered a problem"
 //	@Router			/auth/token [post]
 func (app *application) createTokenHandler(w http.ResponseWriter, r *http.Request) {
@@ -240,6 +245,7 @@ func (app *application) createTokenHandler(w http.ResponseWriter, r *http.Reques
 	user, err := app.store.GetUserByEmail(ctx, payload.Email)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
+			app.recordSecurityEvent(ctx, app.auditEntry(r, audit.LoginFailed, "email", payload.Email, nil, nil))
 			app.unauthorizedErrorResponse(w, r, err)
 		} else {
 			app.internalServerError(w, r, err)
@@ -253,10 +259,17 @@ func (app *application) createTokenHandler(w http.ResponseWriter, r *http.Reques
 	}
 	// verify user password
 	if err := bcrypt.CompareHashAndPassword(user.Password, []byte(payload.Password)); err != nil {
+		app.recordSecurityEvent(ctx, app.auditEntry(r, audit.LoginFailed, "user", user.ID.String(), nil, nil))
 		app.unauthorizedErrorResponse(w, r, err)
 		return
 	}
 
+	if restriction := accountRestriction(&user, time.Now()); restriction != "" {
+		app.recordSecurityEvent(ctx, app.auditEntry(r, audit.LoginBlocked, "user", user.ID.String(), nil, sanctionsOf(user)))
+		app.customErrorResponse(w, r, http.StatusForbidden, restriction)
+		return
+	}
+
 	// gen token => add claims
 	claims := jwt.MapClaims{
 		"sub": user.ID,
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/JaskiratAnand/go-social/internal/store"
+	"github.com/google/uuid"
+)
+
+const invalidateTimeout = 10 * time.Second
+
+// getUserFeed serves a feed page from the cache, querying it on a miss.
+// Ascending pages are rare and always queried, their params alone would
+// share cache keys with the descending ones.
+func (app *application) getUserFeed(ctx context.Context, params store.GetUserFeedParams, ascending bool) ([]store.GetUserFeedRow, error) {
+	if ascending {
+		rows, err := app.store.GetUserFeedAscending(ctx, store.GetUserFeedAscendingParams(params))
+		if err != nil {
+			return nil, err
+		}
+
+		feed := make([]store.GetUserFeedRow, len(rows))
+		for i, row := range rows {
+			feed[i] = store.GetUserFeedRow(row)
+		}
+		return feed, nil
+	}
+
+	feed, err := app.cacheStorage.Feeds.Get(ctx, params)
+	if err != nil {
+		app.logger.Warnw("error reading feed cache", "error", err.Error())
+	}
+	if feed != nil {
+		return feed, nil
+	}
+
+	feed, err = app.store.GetUserFeed(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := app.cacheStorage.Feeds.Set(ctx, params, feed); err != nil {
+		app.logger.Warnw("error writing feed cache", "error", err.Error())
+	}
+
+	return feed, nil
+}
+
+// getPost reads a post with its comments through the cache. Visibility is
+// checked by the caller on every request. Posts of banned authors are gone
+// as soon as the ban is, even while a cached copy is left.
+func (app *application) getPost(ctx context.Context, postID uuid.UUID) (store.GetPostWithCommentsByIdRow, error) {
+	post, err := app.cacheStorage.Posts.Load(ctx, postID, func(ctx context.Context) (*store.GetPostWithCommentsByIdRow, error) {
+		post, err := app.store.GetPostWithCommentsById(ctx, postID)
+		if err != nil {
+			return nil, err
+		}
+		return &post, nil
+	})
+	if err != nil {
+		return store.GetPostWithCommentsByIdRow{}, err
+	}
+
+	author, err := app.getUser(ctx, post.UserID)
+	if err != nil {
+		return store.GetPostWithCommentsByIdRow{}, err
+	}
+	if author.BannedAt.Valid {
+		return store.GetPostWithCommentsByIdRow{}, sql.ErrNoRows
+	}
+
+	return *post, nil
+}
+
+// invalidateFeeds drops the cached feed pages of users whose feed changed.
+func (app *application) invalidateFeeds(ctx context.Context, userIDs ...uuid.UUID) {
+	if err := app.cacheStorage.Feeds.Invalidate(ctx, userIDs...); err != nil {
+		app.logger.Warnw("error invalidating feed cache", "error", err.Error())
+	}
+}
+
+// invalidatePostFeeds drops the feed pages that may show a post: those of
+// its author, of their followers and of everyone following one of its tags.
+func (app *application) invalidatePostFeeds(authorID uuid.UUID, tags []string) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), invalidateTimeout)
+		defer cancel()
+
+		followers, err := app.store.GetFollowerIds(ctx, authorID)
+		if err != nil {
+			app.logger.Warnw("error fetching followers for feed invalidation", "error", err.Error())
+			return
+		}
+
+		var tagFollowers []uuid.UUID
+		if len(tags) > 0 {
+			tagFollowers, err = app.store.GetTagFollowerIds(ctx, tags)
+			if err != nil {
+				app.logger.Warnw("error fetching tag followers for feed invalidation", "error", err.Error())
+				return
+			}
+		}
+
+		recipients := append([]uuid.UUID{authorID}, followers...)
+		app.invalidateFeeds(ctx, append(recipients, tagFollowers...)...)
+	}()
+}
+
+func (app *application) invalidatePost(ctx context.Context, postID uuid.UUID) {
+	app.cacheStorage.Posts.Delete(ctx, postID)
+}
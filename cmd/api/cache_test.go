@@ -0,0 +1,192 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/JaskiratAnand/go-social/internal/filter"
+	"github.com/JaskiratAnand/go-social/internal/store"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+func TestFeedInvalidation(t *testing.T) {
+	author := store.Users{ID: uuid.New(), Username: "alice"}
+	follower, tagFollower, stranger := uuid.New(), uuid.New(), uuid.New()
+
+	newApp := func(t *testing.T) (*application, sqlmock.Sqlmock) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { db.Close() })
+		// invalidation and realtime events query followers concurrently
+		mock.MatchExpectationsInOrder(false)
+
+		app := TestMockApplication(t, config{})
+		app.store = store.New(db)
+		f, _ := filter.New(nil)
+		app.contentFilter.Store(f)
+
+		// a cached page per user, dropped once their generation is bumped
+		for _, id := range []uuid.UUID{author.ID, follower, tagFollower, stranger} {
+			if err := app.cacheStorage.Feeds.Set(context.Background(), store.GetUserFeedParams{UserID: id}, nil); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return app, mock
+	}
+
+	expectFollowers := func(mock sqlmock.Sqlmock, times int) {
+		for range times {
+			mock.ExpectQuery("GetFollowerIds").WithArgs(author.ID).
+				WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(follower.String()))
+		}
+	}
+	expectTagFollowers := func(mock sqlmock.Sqlmock, tags string) {
+		mock.ExpectQuery("GetTagFollowerIds").WithArgs(tags).
+			WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(tagFollower.String()))
+	}
+
+	request := func(method, body string, user store.Users, post *store.Posts, params map[string]string) *http.Request {
+		r := httptest.NewRequest(method, "/", strings.NewReader(body))
+
+		rctx := chi.NewRouteContext()
+		for k, v := range params {
+			rctx.URLParams.Add(k, v)
+		}
+		ctx := context.WithValue(r.Context(), chi.RouteCtxKey, rctx)
+		ctx = context.WithValue(ctx, userCtx, user)
+		if post != nil {
+			ctx = context.WithValue(ctx, postCtx, *post)
+		}
+		return r.WithContext(ctx)
+	}
+
+	// assertFeeds waits for the asynchronous fan-out, then checks that
+	// exactly the feeds of invalidated were dropped
+	assertFeeds := func(t *testing.T, app *application, mock sqlmock.Sqlmock, invalidated ...uuid.UUID) {
+		t.Helper()
+
+		cached := func(id uuid.UUID) bool {
+			feed, _ := app.cacheStorage.Feeds.Get(context.Background(), store.GetUserFeedParams{UserID: id})
+			return feed != nil
+		}
+
+		deadline := time.Now().Add(time.Second)
+		for _, id := range invalidated {
+			for cached(id) {
+				if time.Now().After(deadline) {
+					t.Fatalf("expected the feed of %v to be invalidated", id)
+				}
+				time.Sleep(5 * time.Millisecond)
+			}
+		}
+
+		for _, id := range []uuid.UUID{author.ID, follower, tagFollower, stranger} {
+			want := true
+			for _, inv := range invalidated {
+				if id == inv {
+					want = false
+				}
+			}
+			if cached(id) != want {
+				t.Errorf("%v: expected cached=%v", id, want)
+			}
+		}
+
+		// realtime events are published in the background as well
+		for {
+			err := mock.ExpectationsWereMet()
+			if err == nil {
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Error(err)
+				break
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+
+	post := store.Posts{ID: uuid.New(), Title: "hi", Content: "hello", Tags: []string{"news"}, UserID: author.ID, UpdatedAt: time.Now()}
+
+	t.Run("created posts reach the author, followers and tag followers", func(t *testing.T) {
+		app, mock := newApp(t)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery("CreatePost").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "updated_at"}).AddRow(post.ID.String(), time.Now(), time.Now()))
+		mock.ExpectCommit()
+		expectFollowers(mock, 2)
+		expectTagFollowers(mock, `{"news"}`)
+
+		rr := executeRequest(request(http.MethodPost, `{"title":"hi","content":"hello","tags":["news"]}`, author, nil, nil), http.HandlerFunc(app.createPostHandler))
+		checkResponseCode(t, http.StatusCreated, rr.Code)
+
+		assertFeeds(t, app, mock, author.ID, follower, tagFollower)
+	})
+
+	t.Run("updates reach readers of the old and new tags", func(t *testing.T) {
+		app, mock := newApp(t)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery("UpdatePostById").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "updated_at"}).AddRow(post.ID.String(), time.Now()))
+		mock.ExpectCommit()
+		expectFollowers(mock, 1)
+		expectTagFollowers(mock, `{"news","go"}`)
+
+		rr := executeRequest(request(http.MethodPatch, `{"tags":["go"]}`, author, &post, nil), http.HandlerFunc(app.updatePostHandler))
+		checkResponseCode(t, http.StatusOK, rr.Code)
+
+		assertFeeds(t, app, mock, author.ID, follower, tagFollower)
+	})
+
+	t.Run("deleted posts leave the same feeds", func(t *testing.T) {
+		app, mock := newApp(t)
+
+		mock.ExpectBegin()
+		mock.ExpectExec("DeletePostById").WithArgs(post.ID).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		expectFollowers(mock, 1)
+		expectTagFollowers(mock, `{"news"}`)
+
+		rr := executeRequest(request(http.MethodDelete, "", author, &post, nil), http.HandlerFunc(app.deletePostHandler))
+		checkResponseCode(t, http.StatusNoContent, rr.Code)
+
+		assertFeeds(t, app, mock, author.ID, follower, tagFollower)
+	})
+
+	t.Run("follows only change the follower's feed", func(t *testing.T) {
+		app, mock := newApp(t)
+
+		if err := app.cacheStorage.Users.Set(context.Background(), &author); err != nil {
+			t.Fatal(err)
+		}
+		mock.ExpectExec("FollowUser").WithArgs(stranger, author.ID).WillReturnResult(sqlmock.NewResult(0, 1))
+
+		req := request(http.MethodPut, "", store.Users{ID: stranger}, nil, map[string]string{"userID": author.ID.String()})
+		rr := executeRequest(req, http.HandlerFunc(app.followUserHandler))
+		checkResponseCode(t, http.StatusNoContent, rr.Code)
+
+		assertFeeds(t, app, mock, stranger)
+	})
+
+	t.Run("tag follows only change the user's feed", func(t *testing.T) {
+		app, mock := newApp(t)
+
+		mock.ExpectExec("FollowTag").WithArgs(tagFollower, "go").WillReturnResult(sqlmock.NewResult(0, 1))
+
+		req := request(http.MethodPut, "", store.Users{ID: tagFollower}, nil, map[string]string{"tag": "go"})
+		rr := executeRequest(req, http.HandlerFunc(app.followTagHandler))
+		checkResponseCode(t, http.StatusNoContent, rr.Code)
+
+		assertFeeds(t, app, mock, tagFollower)
+	})
+}
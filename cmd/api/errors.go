@@ -2,7 +2,10 @@ package main
 
 import (
 	"errors"
+	"math"
 	"net/http"
+	"strconv"
+	"time"
 )
 
 func (app *application) recordNotFoundResponse(w http.ResponseWriter, r *http.Request, err error) {
@@ -57,3 +60,11 @@ func (app *application) rateLimitExceededResponse(w http.ResponseWriter, r *http
 
 	writeJSONError(w, http.StatusTooManyRequests, "rate limit exceeded, retry after: "+retryAfter)
 }
+
+func (app *application) quotaExceededResponse(w http.ResponseWriter, r *http.Request, reason string, retryAfter time.Duration) {
+	app.logger.Warnw("posting quota exceeded", "method", r.Method, "path", r.URL.Path, "reason", reason)
+
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+
+	writeJSONError(w, http.StatusTooManyRequests, reason)
+}
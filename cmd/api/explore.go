@@ -0,0 +1,211 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/JaskiratAnand/go-social/internal/ranking"
+	"github.com/JaskiratAnand/go-social/internal/store"
+)
+
+const (
+	exploreWindow     = 3 * 24 * time.Hour
+	exploreCandidates = 500
+	maxExplorePosts   = 100
+
+	trendingRecentWindow   = 24 * time.Hour
+	trendingBaselineWindow = 7 * 24 * time.Hour
+	trendingMinPosts       = 2
+	maxTrendingTags        = 50
+)
+
+// GetExplore godoc
+//
+//	@Summary		Fetches the explore feed
+//	@Description	Fetches recent popular posts from public accounts, no authentication required
+//	@Tags			feed
+//	@Accept			json
+//	@Produce		json
+//	@Param			limit	query		int	false	"Limit"
+//	@Param			offset	query		int	false	"Offset"
+//	@Success		200		{object}	[]store.GetExplorePostsRow
+//	@Failure		400		{object}	error	"Bad Request"
+//	@Failure		500		{object}	error	"Server encountered a problem"
+//	@Router			/explore [get]
+func (app *application) getExploreHandler(w http.ResponseWriter, r *http.Request) {
+	limit, err := readIntParam(r, "limit", 20, 1, 20)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	offset, err := readIntParam(r, "offset", 0, 0, maxExplorePosts)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	posts, err := app.getExplorePosts(r.Context())
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	start := min(offset, len(posts))
+	end := min(start+limit, len(posts))
+
+	if err := app.jsonResponse(w, http.StatusOK, posts[start:end]); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+// GetTrendingTags godoc
+//
+//	@Summary		Fetches trending tags
+//	@Description	Fetches tags whose posting rate over the last day grew the most against the previous week
+//	@Tags			tags
+//	@Accept			json
+//	@Produce		json
+//	@Param			limit	query		int	false	"Limit"
+//	@Success		200		{object}	[]ranking.TrendingTag
+//	@Failure		400		{object}	error	"Bad Request"
+//	@Failure		500		{object}	error	"Server encountered a problem"
+//	@Router			/tags/trending [get]
+func (app *application) getTrendingTagsHandler(w http.ResponseWriter, r *http.Request) {
+	limit, err := readIntParam(r, "limit", 10, 1, maxTrendingTags)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	tags, err := app.getTrendingTags(r.Context())
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if len(tags) > limit {
+		tags = tags[:limit]
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, tags); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+// readIntParam reads an optional integer query param within [lo, hi].
+func readIntParam(r *http.Request, name string, def, lo, hi int) (int, error) {
+	s := r.URL.Query().Get(name)
+	if s == "" {
+		return def, nil
+	}
+
+	v, err := strconv.Atoi(s)
+	if err != nil || v < lo || v > hi {
+		return 0, fmt.Errorf("%s must be between %d and %d", name, lo, hi)
+	}
+
+	return v, nil
+}
+
+// getExplorePosts serves the explore feed from the cache, computing it on a
+// miss so the endpoint works before the first run of the background job.
+func (app *application) getExplorePosts(ctx context.Context) ([]store.GetExplorePostsRow, error) {
+	posts, err := app.cacheStorage.Explore.GetPosts(ctx)
+	if err != nil {
+		app.logger.Warnw("error reading explore cache", "error", err.Error())
+	}
+	if posts != nil {
+		return posts, nil
+	}
+
+	return app.computeExplorePosts(ctx)
+}
+
+func (app *application) computeExplorePosts(ctx context.Context) ([]store.GetExplorePostsRow, error) {
+	candidates, err := app.store.GetExplorePosts(ctx, store.GetExplorePostsParams{
+		Since: time.Now().Add(-exploreWindow),
+		Limit: exploreCandidates,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	posts := ranking.Rank(app.ranker, candidates, func(p store.GetExplorePostsRow) ranking.Signals {
+		return ranking.Signals{
+			CreatedAt: p.CreatedAt,
+			Comments:  p.CommentsCount,
+		}
+	})
+	if len(posts) > maxExplorePosts {
+		posts = posts[:maxExplorePosts]
+	}
+
+	if err := app.cacheStorage.Explore.SetPosts(ctx, posts); err != nil {
+		return nil, err
+	}
+
+	return posts, nil
+}
+
+func (app *application) getTrendingTags(ctx context.Context) ([]ranking.TrendingTag, error) {
+	tags, err := app.cacheStorage.Explore.GetTrendingTags(ctx)
+	if err != nil {
+		app.logger.Warnw("error reading trending tags cache", "error", err.Error())
+	}
+	if tags != nil {
+		return tags, nil
+	}
+
+	return app.computeTrendingTags(ctx)
+}
+
+func (app *application) computeTrendingTags(ctx context.Context) ([]ranking.TrendingTag, error) {
+	now := time.Now()
+
+	rows, err := app.store.GetTagActivity(ctx, store.GetTagActivityParams{
+		RecentSince:   now.Add(-trendingRecentWindow),
+		BaselineSince: now.Add(-trendingRecentWindow - trendingBaselineWindow),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	activity := make([]ranking.TagActivity, len(rows))
+	for i, row := range rows {
+		activity[i] = ranking.TagActivity{
+			Tag:      row.Tag,
+			Recent:   row.RecentCount,
+			Baseline: row.BaselineCount,
+		}
+	}
+
+	tags := ranking.Trending(activity, trendingRecentWindow, trendingBaselineWindow, trendingMinPosts)
+	if len(tags) > maxTrendingTags {
+		tags = tags[:maxTrendingTags]
+	}
+
+	if err := app.cacheStorage.Explore.SetTrendingTags(ctx, tags); err != nil {
+		return nil, err
+	}
+
+	return tags, nil
+}
+
+// refreshExplore recomputes the cached explore feed and trending tags.
+func (app *application) refreshExplore(ctx context.Context) error {
+	queryCtx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+
+	if _, err := app.computeExplorePosts(queryCtx); err != nil {
+		return err
+	}
+
+	_, err := app.computeTrendingTags(queryCtx)
+	return err
+}
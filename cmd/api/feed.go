@@ -1,60 +1,290 @@
 package main
 
 import (
+	"database/sql"
+	"errors"
+	"fmt"
 	"net/http"
+	"slices"
+	"strings"
+	"time"
 
+	"github.com/JaskiratAnand/go-social/internal/ranking"
 	"github.com/JaskiratAnand/go-social/internal/store"
+	"github.com/google/uuid"
 )
 
 // GetUserFeed godoc
 //
 //	@Summary		Fetches user feed
-//	@Description	Fetches user feed with following posts
+//	@Description	Fetches user feed with posts from followed users and tags, each with the reason it is included.
+//	@Description	Pages are linked with opaque cursors, offset is only kept for older clients.
 //	@Tags			feed
 //	@Accept			json
 //	@Produce		json
-//	@Param			userID	path		string	true	"User ID"
 //	@Param			limit	query		int		false	"Limit"
-//	@Param			offset	query		int		false	"Offset"
+//	@Param			sort	query		string	false	"latest (default) or top"
+//	@Param			order	query		string	false	"desc (default) or asc, ignored for top"
+//	@Param			since	query		string	false	"RFC 3339 time, date or relative duration like 24h or 7d"
+//	@Param			until	query		string	false	"RFC 3339 time, date or relative duration like 24h or 7d"
+//	@Param			tz		query		string	false	"IANA time zone for since and until without an offset"
+//	@Param			authors	query		string	false	"Comma separated usernames"
+//	@Param			cursor	query		string	false	"Cursor from next_cursor or prev_cursor"
+//	@Param			offset	query		int		false	"Offset (deprecated)"
 //	@Success		200		{object}	[]store.GetUserFeedRow
 //	@Failure		400		{object}	error	"Bad Request"
 //	@Failure		500		{object}	error	"Server encountered a problem"
 //	@Security		ApiKeyAuth
 //	@Router			/users/feed [get]
 func (app *application) getUserFeedHandler(w http.ResponseWriter, r *http.Request) {
-	fq := store.PaginatedFeedQuery{
-		Limit:  20,
-		Offset: 0,
-	}
-	fq, err := fq.Parse(r)
+	fq, err := parseFeedQuery(r)
 	if err != nil {
 		app.badRequestResponse(w, r, err)
 		return
 	}
 
-	if err := Validate.Struct(fq); err != nil {
-		app.badRequestResponse(w, r, err)
-		return
-	}
-
 	ctx := r.Context()
 
 	// get user id
 	user := app.GetUserFromCtx(r)
 
+	if fq.Sort == "top" {
+		app.getTopFeed(w, r, fq, user.ID)
+		return
+	}
+
+	ascending := fq.Order == "asc"
+
 	getUserFeedParams := &store.GetUserFeedParams{
 		UserID:  user.ID,
-		Column2: fq.Search, // Search
+		Search:  fq.Search,
 		Tags:    fq.Tags,
+		Authors: fq.Authors,
+		Since:   nullTime(fq.Since),
+		Until:   nullTime(fq.Until),
 		Limit:   int64(fq.Limit),
-		Offset:  int64(fq.Offset),
 	}
 
-	feed, err := app.store.GetUserFeed(ctx, *getUserFeedParams)
+	// offset mode is kept for backward compatibility only
+	cursorMode := fq.Offset == 0
+	if !cursorMode {
+		getUserFeedParams.Offset = sql.NullInt64{Int64: int64(fq.Offset), Valid: true}
+	}
+
+	queryAscending := ascending
+
+	var cursor store.FeedCursor
+	if fq.Cursor != "" {
+		if !cursorMode {
+			app.badRequestResponse(w, r, errors.New("cursor and offset cannot be combined"))
+			return
+		}
+
+		cursor, err = app.cursorCodec.Decode(fq.Cursor)
+		if err != nil {
+			app.badRequestResponse(w, r, err)
+			return
+		}
+
+		getUserFeedParams.CursorCreatedAt = sql.NullTime{Time: cursor.CreatedAt, Valid: true}
+		getUserFeedParams.CursorID = uuid.NullUUID{UUID: cursor.ID, Valid: true}
+		// prev cursors walk against the requested order
+		queryAscending = ascending != cursor.Prev
+	}
+
+	if cursorMode {
+		// fetch one extra row to find out if there is another page
+		getUserFeedParams.Limit++
+	}
+
+	var feed []store.GetUserFeedRow
+
+	// unfiltered pages are served from the precomputed home timeline
+	fromTimeline := false
+	if app.timeline != nil && cursorMode && !ascending && !fq.Filtered() {
+		var position *store.FeedCursor
+		if fq.Cursor != "" {
+			position = &cursor
+		}
+
+		feed, fromTimeline, err = app.readTimeline(ctx, user.ID, position, fq.Limit+1)
+		if err != nil {
+			app.logger.Warnw("error reading timeline, falling back to database", "error", err.Error())
+		}
+	}
+
+	if !fromTimeline {
+		feed, err = app.getUserFeed(ctx, *getUserFeedParams, queryAscending)
+		if err != nil {
+			app.internalServerError(w, r, err)
+			return
+		}
+	}
+
+	muter, err := app.userMuter(ctx, user.ID)
 	if err != nil {
 		app.internalServerError(w, r, err)
 		return
 	}
+	unmuted := func(feed []store.GetUserFeedRow) []store.GetUserFeedRow {
+		return dropMuted(muter, feed, func(p store.GetUserFeedRow) []string {
+			return postTexts(p.Title, p.Content, p.Tags)
+		})
+	}
+
+	if !cursorMode {
+		if err := app.jsonResponse(w, http.StatusOK, unmuted(feed)); err != nil {
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	hasMore := len(feed) > fq.Limit
+	if hasMore {
+		feed = feed[:fq.Limit]
+	}
+
+	if cursor.Prev {
+		slices.Reverse(feed)
+	}
+
+	var nextCursor, prevCursor string
+	if len(feed) > 0 {
+		first, last := feed[0], feed[len(feed)-1]
+
+		// paging back always leaves posts behind in the requested order
+		if hasMore || cursor.Prev {
+			nextCursor = app.cursorCodec.Encode(store.FeedCursor{CreatedAt: last.CreatedAt, ID: last.ID})
+		}
+		// a prev cursor is always returned so clients can poll for new posts
+		prevCursor = app.cursorCodec.Encode(store.FeedCursor{CreatedAt: first.CreatedAt, ID: first.ID, Prev: true})
+	}
+
+	setLinkHeader(w, r, nextCursor, prevCursor)
+
+	// muted posts are dropped after the cursors are set, so pages may come
+	// out short but never skip posts
+	if err := app.cursorJSONResponse(w, http.StatusOK, unmuted(feed), nextCursor, prevCursor); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+// parseFeedQuery reads the pagination and filter params shared by every feed.
+func parseFeedQuery(r *http.Request) (store.PaginatedFeedQuery, error) {
+	fq := store.PaginatedFeedQuery{
+		Limit:  20,
+		Offset: 0,
+		Sort:   "latest",
+		Order:  "desc",
+	}
+	fq, err := fq.Parse(r)
+	if err != nil {
+		return fq, err
+	}
+
+	if err := Validate.Struct(fq); err != nil {
+		return fq, err
+	}
+
+	return fq, nil
+}
+
+// nullTime maps an unset filter time to NULL.
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
+}
+
+// setLinkHeader advertises the next and prev pages in an RFC 8288 Link header.
+func setLinkHeader(w http.ResponseWriter, r *http.Request, nextCursor, prevCursor string) {
+	var links []string
+
+	for _, l := range []struct{ rel, cursor string }{{"next", nextCursor}, {"prev", prevCursor}} {
+		if l.cursor == "" {
+			continue
+		}
+
+		qs := r.URL.Query()
+		qs.Del("offset")
+		qs.Set("cursor", l.cursor)
+
+		links = append(links, fmt.Sprintf(`<%s?%s>; rel="%s"`, r.URL.Path, qs.Encode(), l.rel))
+	}
+
+	if len(links) > 0 {
+		w.Header().Set("Link", strings.Join(links, ", "))
+	}
+}
+
+const (
+	topFeedWindow     = 7 * 24 * time.Hour
+	topFeedCandidates = 500
+)
+
+// getTopFeed ranks recent feed posts by engagement and author affinity.
+// Ranked pages are not stable over time, so they use offset pagination.
+func (app *application) getTopFeed(w http.ResponseWriter, r *http.Request, fq store.PaginatedFeedQuery, userID uuid.UUID) {
+	if fq.Cursor != "" {
+		app.badRequestResponse(w, r, errors.New("cursor is not supported with sort=top"))
+		return
+	}
+
+	since := time.Now().Add(-topFeedWindow)
+	if fq.Since.After(since) {
+		since = fq.Since
+	}
+
+	candidates, err := app.store.GetUserFeedCandidates(r.Context(), store.GetUserFeedCandidatesParams{
+		UserID:  userID,
+		Search:  fq.Search,
+		Tags:    fq.Tags,
+		Authors: fq.Authors,
+		Since:   since,
+		Until:   nullTime(fq.Until),
+		Limit:   topFeedCandidates,
+	})
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	// candidates are muted before ranking so offsets stay consistent
+	muter, err := app.userMuter(r.Context(), userID)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+	candidates = dropMuted(muter, candidates, func(p store.GetUserFeedCandidatesRow) []string {
+		return postTexts(p.Title, p.Content, p.Tags)
+	})
+
+	// there is no reactions table yet, so only comments count as engagement
+	ranked := ranking.Rank(app.ranker, candidates, func(p store.GetUserFeedCandidatesRow) ranking.Signals {
+		return ranking.Signals{
+			CreatedAt: p.CreatedAt,
+			Comments:  p.CommentsCount,
+			Affinity:  p.AuthorAffinity,
+		}
+	})
+
+	start := min(fq.Offset, len(ranked))
+	end := min(start+fq.Limit, len(ranked))
+
+	feed := make([]store.GetUserFeedRow, 0, end-start)
+	for _, p := range ranked[start:end] {
+		feed = append(feed, store.GetUserFeedRow{
+			ID:            p.ID,
+			Title:         p.Title,
+			Content:       p.Content,
+			Tags:          p.Tags,
+			CreatedAt:     p.CreatedAt,
+			UpdatedAt:     p.UpdatedAt,
+			Username:      p.Username,
+			CommentsCount: p.CommentsCount,
+			Reason:        p.Reason,
+			FollowedTags:  p.FollowedTags,
+		})
+	}
 
 	if err := app.jsonResponse(w, http.StatusOK, feed); err != nil {
 		app.internalServerError(w, r, err)
@@ -0,0 +1,438 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/JaskiratAnand/go-social/internal/audit"
+	"github.com/JaskiratAnand/go-social/internal/filter"
+	"github.com/JaskiratAnand/go-social/internal/store"
+	"github.com/JaskiratAnand/go-social/internal/timeline"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+const (
+	// reportReasonFilter marks reports opened by a hold rule rather than a
+	// user. Dismissing one approves the held content.
+	reportReasonFilter = "filter"
+
+	maxMutedWords = 100
+)
+
+// reloadContentFilter compiles the stored rules, unless filtering is
+// disabled. Rules are validated on create, so a failure leaves the previous
+// filter in place.
+func (app *application) reloadContentFilter(ctx context.Context) error {
+	if !app.config.filters.enabled {
+		return nil
+	}
+
+	rows, err := app.store.GetFilterRules(ctx)
+	if err != nil {
+		return err
+	}
+
+	rules := make([]filter.Rule, 0, len(rows))
+	for _, row := range rows {
+		rules = append(rules, filter.Rule{
+			ID:      row.ID.String(),
+			Kind:    row.Kind,
+			Pattern: row.Pattern,
+			Action:  row.Action,
+		})
+	}
+
+	f, err := filter.New(rules)
+	if err != nil {
+		return err
+	}
+
+	app.contentFilter.Store(f)
+	return nil
+}
+
+// filterContent checks fields against the content filter, masking them in
+// place. It returns the strongest action taken and the ids of the matching
+// rules, writing the response when the content is rejected.
+func (app *application) filterContent(w http.ResponseWriter, r *http.Request, fields ...*string) (string, []string, bool) {
+	f := app.contentFilter.Load()
+
+	var action string
+	var matched []string
+	for _, field := range fields {
+		res := f.Check(*field)
+		*field = res.Text
+
+		action = filter.Stronger(action, res.Action)
+		for _, rule := range res.Matched {
+			matched = append(matched, rule.ID)
+		}
+	}
+
+	if action == filter.Reject {
+		app.logger.Infow("content rejected by filter", "user", app.GetUserFromCtx(r).ID, "rules", matched)
+		app.customErrorResponse(w, r, http.StatusUnprocessableEntity, "content violates the community rules")
+		return action, matched, false
+	}
+
+	return action, matched, true
+}
+
+// holdForReview hides freshly created content and queues it for the
+// moderators, who approve it by dismissing the report.
+func holdForReview(ctx context.Context, q *store.Queries, targetType string, targetID, authorID uuid.UUID, matched []string) error {
+	hide := q.HidePost
+	if targetType == reportTargetComment {
+		hide = q.HideComment
+	}
+	if err := hide(ctx, targetID); err != nil {
+		return err
+	}
+
+	_, err := q.CreateReport(ctx, store.CreateReportParams{
+		TargetType:   targetType,
+		TargetID:     targetID,
+		TargetUserID: authorID,
+		Reason:       reportReasonFilter,
+		Details:      "matched filter rules " + strings.Join(matched, ", "),
+	})
+	return err
+}
+
+// heldContent is content approved by a moderator, to be published once the
+// approval is committed.
+type heldContent struct {
+	// post is the approved post, or the post of the approved comment.
+	post    store.Posts
+	comment bool
+}
+
+// releaseHeld unhides the target of a filter report.
+func releaseHeld(ctx context.Context, q *store.Queries, report store.Reports) (*heldContent, error) {
+	if report.TargetType == reportTargetComment {
+		comment, err := q.GetCommentById(ctx, report.TargetID)
+		if err != nil {
+			return nil, err
+		}
+		if err := q.UnhideComment(ctx, comment.ID); err != nil {
+			return nil, err
+		}
+		return &heldContent{post: store.Posts{ID: comment.PostID}, comment: true}, nil
+	}
+
+	post, err := q.GetPostsById(ctx, report.TargetID)
+	if err != nil {
+		return nil, err
+	}
+	if err := q.UnhidePost(ctx, post.ID); err != nil {
+		return nil, err
+	}
+	return &heldContent{post: post}, nil
+}
+
+// publishHeld does what creating the content skipped while it was held.
+// Live streams are left out, the content is no longer new.
+func (app *application) publishHeld(ctx context.Context, held *heldContent) {
+	app.invalidatePost(ctx, held.post.ID)
+	if held.comment {
+		return
+	}
+
+	post := held.post
+	app.invalidatePostFeeds(post.UserID, post.Tags)
+	app.fanOutPost(post.UserID, timeline.Entry{PostID: post.ID, CreatedAt: post.CreatedAt})
+
+	author, err := app.getUser(ctx, post.UserID)
+	if err != nil {
+		app.logger.Errorw("error loading author of approved post", "post", post.ID, "error", err.Error())
+		return
+	}
+	post.HiddenAt = sql.NullTime{}
+	app.federatePost(author, post)
+}
+
+// GetFilterRules godoc
+//
+//	@Summary		Fetches the content filter rules
+//	@Description	Lists the rules applied to new posts and comments.
+//	@Tags			admin
+//	@Produce		json
+//	@Success		200	{object}	[]store.FilterRules
+//	@Failure		403	{object}	error	"Forbidden"
+//	@Failure		500	{object}	error	"Server encountered a problem"
+//	@Security		ApiKeyAuth
+//	@Router			/admin/filter-rules [get]
+func (app *application) getFilterRulesHandler(w http.ResponseWriter, r *http.Request) {
+	rules, err := app.store.GetFilterRules(r.Context())
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, rules); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+type CreateFilterRulePayload struct {
+	Kind    string `json:"kind" validate:"required,oneof=word regex domain"`
+	Pattern string `json:"pattern" validate:"required,max=500"`
+	Action  string `json:"action" validate:"required,oneof=reject hold mask"`
+}
+
+// CreateFilterRule godoc
+//
+//	@Summary		Creates a content filter rule
+//	@Description	Words match whole words ignoring case, regexes use Go syntax and domains match links to the domain and its subdomains.
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		CreateFilterRulePayload	true	"Rule"
+//	@Success		201		{object}	store.FilterRules
+//	@Failure		400		{object}	error	"Bad Request"
+//	@Failure		403		{object}	error	"Forbidden"
+//	@Failure		409		{object}	error	"Rule already exists"
+//	@Failure		500		{object}	error	"Server encountered a problem"
+//	@Security		ApiKeyAuth
+//	@Router			/admin/filter-rules [post]
+func (app *application) createFilterRuleHandler(w http.ResponseWriter, r *http.Request) {
+	var payload CreateFilterRulePayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	// domains and words are matched ignoring case
+	pattern := strings.TrimSpace(payload.Pattern)
+	if payload.Kind != filter.Regex {
+		pattern = strings.ToLower(pattern)
+	}
+	if err := filter.ValidPattern(payload.Kind, pattern); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	ctx := r.Context()
+	admin := app.GetUserFromCtx(r)
+
+	var rule store.FilterRules
+	err := app.store.ExecTx(ctx, func(q *store.Queries) error {
+		var err error
+		rule, err = q.CreateFilterRule(ctx, store.CreateFilterRuleParams{
+			Kind:      payload.Kind,
+			Pattern:   pattern,
+			Action:    payload.Action,
+			CreatedBy: uuid.NullUUID{UUID: admin.ID, Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+		return audit.Record(ctx, q, app.auditEntry(r, audit.FilterRuleCreate, "filter_rule", rule.ID.String(), nil, rule))
+	})
+	if err != nil {
+		if isUniqueViolation(err) {
+			app.customErrorResponse(w, r, http.StatusConflict, "a "+payload.Kind+" rule for this pattern already exists")
+			return
+		}
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	// other instances pick the rule up on their next refresh
+	if err := app.reloadContentFilter(ctx); err != nil {
+		app.logger.Errorw("error reloading content filter", "error", err.Error())
+	}
+
+	if err := app.jsonResponse(w, http.StatusCreated, rule); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+// DeleteFilterRule godoc
+//
+//	@Summary		Deletes a content filter rule
+//	@Tags			admin
+//	@Produce		json
+//	@Param			ruleID	path	string	true	"Rule ID"
+//	@Success		204
+//	@Failure		400	{object}	error	"Bad Request"
+//	@Failure		403	{object}	error	"Forbidden"
+//	@Failure		404	{object}	error	"Record Not Found"
+//	@Failure		500	{object}	error	"Server encountered a problem"
+//	@Security		ApiKeyAuth
+//	@Router			/admin/filter-rules/{ruleID} [delete]
+func (app *application) deleteFilterRuleHandler(w http.ResponseWriter, r *http.Request) {
+	ruleID, err := uuid.Parse(chi.URLParam(r, "ruleID"))
+	if err != nil {
+		app.badRequestResponse(w, r, errors.New("invalid rule-id"))
+		return
+	}
+
+	ctx := r.Context()
+
+	err = app.store.ExecTx(ctx, func(q *store.Queries) error {
+		rule, err := q.DeleteFilterRule(ctx, ruleID)
+		if err != nil {
+			return err
+		}
+		return audit.Record(ctx, q, app.auditEntry(r, audit.FilterRuleDelete, "filter_rule", rule.ID.String(), rule, nil))
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			app.recordNotFoundResponse(w, r, err)
+			return
+		}
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.reloadContentFilter(ctx); err != nil {
+		app.logger.Errorw("error reloading content filter", "error", err.Error())
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// userMuter loads the words muted by a user.
+func (app *application) userMuter(ctx context.Context, userID uuid.UUID) (*filter.Muter, error) {
+	words, err := app.store.GetMutedWords(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return filter.NewMuter(words), nil
+}
+
+// dropMuted returns the posts of feed without a muted word. feed may be
+// shared with the cache, so it is copied rather than filtered in place.
+func dropMuted[T any](m *filter.Muter, feed []T, texts func(T) []string) []T {
+	kept := make([]T, 0, len(feed))
+	for _, post := range feed {
+		if !m.Mutes(texts(post)...) {
+			kept = append(kept, post)
+		}
+	}
+	return kept
+}
+
+func postTexts(title, content string, tags []string) []string {
+	return append([]string{title, content}, tags...)
+}
+
+// GetMutedWords godoc
+//
+//	@Summary		Fetches muted words
+//	@Description	Lists the words hidden from the feeds of the user.
+//	@Tags			users
+//	@Produce		json
+//	@Success		200	{object}	[]string
+//	@Failure		500	{object}	error	"Server encountered a problem"
+//	@Security		ApiKeyAuth
+//	@Router			/users/me/muted-words [get]
+func (app *application) getMutedWordsHandler(w http.ResponseWriter, r *http.Request) {
+	user := app.GetUserFromCtx(r)
+
+	words, err := app.store.GetMutedWords(r.Context(), user.ID)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, words); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+type MuteWordPayload struct {
+	Word string `json:"word" validate:"required,max=100"`
+}
+
+// MuteWord godoc
+//
+//	@Summary		Mutes a word
+//	@Description	Hides posts containing the word or phrase from the feeds of the user.
+//	@Tags			users
+//	@Accept			json
+//	@Param			payload	body	MuteWordPayload	true	"Word"
+//	@Success		204
+//	@Failure		400	{object}	error	"Bad Request"
+//	@Failure		500	{object}	error	"Server encountered a problem"
+//	@Security		ApiKeyAuth
+//	@Router			/users/me/muted-words [put]
+func (app *application) muteWordHandler(w http.ResponseWriter, r *http.Request) {
+	var payload MuteWordPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	payload.Word = strings.TrimSpace(payload.Word)
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	ctx := r.Context()
+	user := app.GetUserFromCtx(r)
+
+	// every muted word is matched against every feed post
+	words, err := app.store.GetMutedWords(ctx, user.ID)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+	if len(words) >= maxMutedWords {
+		app.badRequestResponse(w, r, errors.New("too many muted words"))
+		return
+	}
+
+	if err := app.store.AddMutedWord(ctx, store.AddMutedWordParams{
+		UserID: user.ID,
+		Word:   payload.Word,
+	}); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// UnmuteWord godoc
+//
+//	@Summary		Unmutes a word
+//	@Tags			users
+//	@Param			word	path	string	true	"Word"
+//	@Success		204
+//	@Failure		404	{object}	error	"Record Not Found"
+//	@Failure		500	{object}	error	"Server encountered a problem"
+//	@Security		ApiKeyAuth
+//	@Router			/users/me/muted-words/{word} [delete]
+func (app *application) unmuteWordHandler(w http.ResponseWriter, r *http.Request) {
+	user := app.GetUserFromCtx(r)
+
+	removed, err := app.store.RemoveMutedWord(r.Context(), store.RemoveMutedWordParams{
+		UserID: user.ID,
+		Word:   strings.ToLower(chi.URLParam(r, "word")),
+	})
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+	if removed == 0 {
+		app.recordNotFoundResponse(w, r, errors.New("word is not muted"))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/JaskiratAnand/go-social/internal/store"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// GetFollowRequests godoc
+//
+//	@Summary		Fetches pending follow requests
+//	@Description	Fetches follow requests sent to the authenticated user
+//	@Tags			users
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	[]store.GetFollowRequestsByFollowIdRow
+//	@Failure		500	{object}	error	"Server encountered a problem"
+//	@Security		ApiKeyAuth
+//	@Router			/users/follow-requests [get]
+func (app *application) getFollowRequestsHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	user := app.GetUserFromCtx(r)
+
+	requests, err := app.store.GetFollowRequestsByFollowId(ctx, user.ID)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, requests); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+// ApproveFollowRequest godoc
+//
+//	@Summary		Approve follow request
+//	@Description	Approves a pending follow request from user
+//	@Tags			users
+//	@Accept			json
+//	@Produce		json
+//	@Param			userID	path	string	true	"Requesting User ID"
+//	@Success		204
+//	@Failure		400	{object}	error	"Bad Request"
+//	@Failure		404	{object}	error	"Record Not Found"
+//	@Failure		500	{object}	error	"Server encountered a problem"
+//	@Security		ApiKeyAuth
+//	@Router			/users/follow-requests/{userID}/approve [put]
+func (app *application) approveFollowRequestHandler(w http.ResponseWriter, r *http.Request) {
+	idParam := chi.URLParam(r, "userID")
+
+	ctx := r.Context()
+
+	requesterID, err := uuid.Parse(idParam)
+	if err != nil {
+		app.customErrorResponse(w, r, http.StatusBadRequest, "invalid user-id")
+		return
+	}
+
+	user := app.GetUserFromCtx(r)
+
+	approveRequest := &store.ApproveFollowRequestParams{
+		UserID:   requesterID,
+		FollowID: user.ID,
+	}
+
+	rows, err := app.store.ApproveFollowRequest(ctx, *approveRequest)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if rows == 0 {
+		app.recordNotFoundResponse(w, r, errors.New("follow request not found"))
+		return
+	}
+
+	app.cacheStorage.Suggestions.Delete(ctx, requesterID)
+	app.invalidateFeeds(ctx, requesterID)
+	app.rebuildTimeline(ctx, requesterID)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RejectFollowRequest godoc
+//
+//	@Summary		Reject follow request
+//	@Description	Rejects a pending follow request from user
+//	@Tags			users
+//	@Accept			json
+//	@Produce		json
+//	@Param			userID	path	string	true	"Requesting User ID"
+//	@Success		204
+//	@Failure		400	{object}	error	"Bad Request"
+//	@Failure		404	{object}	error	"Record Not Found"
+//	@Failure		500	{object}	error	"Server encountered a problem"
+//	@Security		ApiKeyAuth
+//	@Router			/users/follow-requests/{userID}/reject [put]
+func (app *application) rejectFollowRequestHandler(w http.ResponseWriter, r *http.Request) {
+	idParam := chi.URLParam(r, "userID")
+
+	ctx := r.Context()
+
+	requesterID, err := uuid.Parse(idParam)
+	if err != nil {
+		app.customErrorResponse(w, r, http.StatusBadRequest, "invalid user-id")
+		return
+	}
+
+	user := app.GetUserFromCtx(r)
+
+	rejectRequest := &store.DeleteFollowRequestParams{
+		UserID:   requesterID,
+		FollowID: user.ID,
+	}
+
+	rows, err := app.store.DeleteFollowRequest(ctx, *rejectRequest)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if rows == 0 {
+		app.recordNotFoundResponse(w, r, errors.New("follow request not found"))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"time"
+)
+
+// runPeriodic runs job once at startup and then on every tick of interval
+// until ctx is cancelled. Errors are logged and never stop the schedule.
+func (app *application) runPeriodic(ctx context.Context, name string, interval time.Duration, job func(context.Context) error) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			start := time.Now()
+			if err := job(ctx); err != nil {
+				app.logger.Errorw("background job failed", "job", name, "error", err.Error())
+			} else {
+				app.logger.Infow("background job completed", "job", name, "duration", time.Since(start).String())
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func (app *application) startBackgroundJobs(ctx context.Context) {
+	go func() {
+		if err := app.hub.Run(ctx); err != nil {
+			app.logger.Errorw("realtime hub stopped", "error", err.Error())
+		}
+	}()
+
+	if app.janitor != nil {
+		go app.janitor.Run(ctx)
+	}
+
+	if app.cacheBus != nil {
+		go app.cacheBus.Run(ctx, func(err error) {
+			app.logger.Warnw("cache invalidation bus unavailable, retrying", "error", err.Error())
+		})
+	}
+
+	// the batch queries every verified user. Without Redis each replica
+	// would repeat it for its own in-memory cache and push the cached users
+	// out of it, so suggestions are only computed on read there.
+	if app.config.suggestions.enabled && app.config.redisCfg.enabled {
+		app.runPeriodic(ctx, "follow-suggestions", app.config.suggestions.interval, app.refreshSuggestions)
+	}
+
+	// the explore feed is a single cache entry and cheap to keep warm on
+	// every replica
+	if app.config.explore.enabled {
+		app.runPeriodic(ctx, "explore", app.config.explore.interval, app.refreshExplore)
+	}
+
+	if app.config.filters.enabled {
+		app.runPeriodic(ctx, "content-filters", app.config.filters.refreshInterval, app.reloadContentFilter)
+	}
+
+	if app.config.activityPub.enabled {
+		app.runPeriodic(ctx, "activitypub-delivery", 15*time.Second, app.deliverActivities)
+	}
+}
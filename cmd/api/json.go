@@ -43,3 +43,13 @@ func (app *application) jsonResponse(w http.ResponseWriter, status int, data any
 
 	return writeJSON(w, status, &envelope{Data: data})
 }
+
+func (app *application) cursorJSONResponse(w http.ResponseWriter, status int, data any, nextCursor, prevCursor string) error {
+	type envelope struct {
+		Data       any    `json:"data"`
+		NextCursor string `json:"next_cursor,omitempty"`
+		PrevCursor string `json:"prev_cursor,omitempty"`
+	}
+
+	return writeJSON(w, status, &envelope{Data: data, NextCursor: nextCursor, PrevCursor: prevCursor})
+}
@@ -0,0 +1,393 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/JaskiratAnand/go-social/internal/store"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+type CreateListPayload struct {
+	Name      string `json:"name" validate:"required,max=100"`
+	IsPrivate bool   `json:"is_private"`
+}
+
+// CreateList godoc
+//
+//	@Summary		Create a List
+//	@Description	Creates a new list of users
+//	@Tags			lists
+//	@Accept			json
+//	@Produce		json
+//	@Param			CreateList	body		CreateListPayload	true	"Create List Payload"
+//	@Success		201			{object}	store.CreateListRow
+//	@Failure		400			{object}	error	"Bad Request"
+//	@Failure		409			{object}	error	"List name already used"
+//	@Failure		500			{object}	error	"Server encountered a problem"
+//	@Security		ApiKeyAuth
+//	@Router			/lists [post]
+func (app *application) createListHandler(w http.ResponseWriter, r *http.Request) {
+	var payload CreateListPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	ctx := r.Context()
+
+	user := app.GetUserFromCtx(r)
+
+	createList := &store.CreateListParams{
+		UserID:    user.ID,
+		Name:      payload.Name,
+		IsPrivate: payload.IsPrivate,
+	}
+
+	list, err := app.store.CreateList(ctx, *createList)
+	if err != nil {
+		if isUniqueViolation(err) {
+			app.customErrorResponse(w, r, http.StatusConflict, "you already have a list named "+payload.Name)
+			return
+		}
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusCreated, list); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+// GetLists godoc
+//
+//	@Summary		Fetches own lists
+//	@Description	Fetches lists owned by the authenticated user
+//	@Tags			lists
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	[]store.GetListsByUserIdRow
+//	@Failure		500	{object}	error	"Server encountered a problem"
+//	@Security		ApiKeyAuth
+//	@Router			/lists [get]
+func (app *application) getListsHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	user := app.GetUserFromCtx(r)
+
+	lists, err := app.store.GetListsByUserId(ctx, user.ID)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, lists); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+type ListResponseType struct {
+	store.Lists
+	Members []store.GetListMembersRow `json:"members"`
+}
+
+// GetList godoc
+//
+//	@Summary		Fetch List
+//	@Description	Fetch list and its members by id
+//	@Tags			lists
+//	@Accept			json
+//	@Produce		json
+//	@Param			listID	path		string	true	"List ID"
+//	@Success		200		{object}	ListResponseType
+//	@Failure		400		{object}	error	"Bad Request"
+//	@Failure		404		{object}	error	"Record Not Found"
+//	@Failure		500		{object}	error	"Server encountered a problem"
+//	@Security		ApiKeyAuth
+//	@Router			/lists/{listID} [get]
+func (app *application) getListHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	list := app.GetListFromCtx(r)
+
+	members, err := app.store.GetListMembers(ctx, list.ID)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, &ListResponseType{Lists: list, Members: members}); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+type UpdateListPayload struct {
+	Name      string `json:"name" validate:"omitempty,max=100"`
+	IsPrivate *bool  `json:"is_private"`
+}
+
+// UpdateList godoc
+//
+//	@Summary		Update List
+//	@Description	Rename a list or change its visibility
+//	@Tags			lists
+//	@Accept			json
+//	@Produce		json
+//	@Param			listID		path	string				true	"List ID"
+//	@Param			updateList	body	UpdateListPayload	true	"Update List Payload"
+//	@Success		204
+//	@Failure		400	{object}	error	"Bad Request"
+//	@Failure		403	{object}	error	"Forbidden"
+//	@Failure		404	{object}	error	"Record Not Found"
+//	@Failure		500	{object}	error	"Server encountered a problem"
+//	@Security		ApiKeyAuth
+//	@Router			/lists/{listID} [patch]
+func (app *application) updateListHandler(w http.ResponseWriter, r *http.Request) {
+	var payload UpdateListPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	ctx := r.Context()
+
+	list := app.GetListFromCtx(r)
+
+	updateList := &store.UpdateListByIdParams{
+		ID:        list.ID,
+		Name:      If(payload.Name != "", payload.Name, list.Name),
+		IsPrivate: If(payload.IsPrivate != nil, *payload.IsPrivate, list.IsPrivate),
+	}
+
+	if err := app.store.UpdateListById(ctx, *updateList); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// DeleteList godoc
+//
+//	@Summary		Delete List
+//	@Description	Deletes list by id
+//	@Tags			lists
+//	@Accept			json
+//	@Produce		json
+//	@Param			listID	path	string	true	"List ID"
+//	@Success		204
+//	@Failure		403	{object}	error	"Forbidden"
+//	@Failure		404	{object}	error	"Record Not Found"
+//	@Failure		500	{object}	error	"Server encountered a problem"
+//	@Security		ApiKeyAuth
+//	@Router			/lists/{listID} [delete]
+func (app *application) deleteListHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	list := app.GetListFromCtx(r)
+
+	if err := app.store.DeleteListById(ctx, list.ID); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// AddListMember godoc
+//
+//	@Summary		Add List member
+//	@Description	Adds a user to the list
+//	@Tags			lists
+//	@Accept			json
+//	@Produce		json
+//	@Param			listID	path	string	true	"List ID"
+//	@Param			userID	path	string	true	"User ID"
+//	@Success		204
+//	@Failure		400	{object}	error	"Bad Request"
+//	@Failure		403	{object}	error	"Forbidden"
+//	@Failure		404	{object}	error	"Record Not Found"
+//	@Failure		500	{object}	error	"Server encountered a problem"
+//	@Security		ApiKeyAuth
+//	@Router			/lists/{listID}/members/{userID} [put]
+func (app *application) addListMemberHandler(w http.ResponseWriter, r *http.Request) {
+	idParam := chi.URLParam(r, "userID")
+
+	ctx := r.Context()
+
+	memberID, err := uuid.Parse(idParam)
+	if err != nil {
+		app.customErrorResponse(w, r, http.StatusBadRequest, "invalid user-id")
+		return
+	}
+
+	if _, err := app.getUser(ctx, memberID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			app.recordNotFoundResponse(w, r, err)
+			return
+		}
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	list := app.GetListFromCtx(r)
+
+	addMember := &store.AddListMemberParams{
+		ListID:   list.ID,
+		MemberID: memberID,
+	}
+
+	if err := app.store.AddListMember(ctx, *addMember); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RemoveListMember godoc
+//
+//	@Summary		Remove List member
+//	@Description	Removes a user from the list
+//	@Tags			lists
+//	@Accept			json
+//	@Produce		json
+//	@Param			listID	path	string	true	"List ID"
+//	@Param			userID	path	string	true	"User ID"
+//	@Success		204
+//	@Failure		400	{object}	error	"Bad Request"
+//	@Failure		403	{object}	error	"Forbidden"
+//	@Failure		404	{object}	error	"Record Not Found"
+//	@Failure		500	{object}	error	"Server encountered a problem"
+//	@Security		ApiKeyAuth
+//	@Router			/lists/{listID}/members/{userID} [delete]
+func (app *application) removeListMemberHandler(w http.ResponseWriter, r *http.Request) {
+	idParam := chi.URLParam(r, "userID")
+
+	ctx := r.Context()
+
+	memberID, err := uuid.Parse(idParam)
+	if err != nil {
+		app.customErrorResponse(w, r, http.StatusBadRequest, "invalid user-id")
+		return
+	}
+
+	list := app.GetListFromCtx(r)
+
+	removeMember := &store.RemoveListMemberParams{
+		ListID:   list.ID,
+		MemberID: memberID,
+	}
+
+	rows, err := app.store.RemoveListMember(ctx, *removeMember)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if rows == 0 {
+		app.recordNotFoundResponse(w, r, errors.New("list member not found"))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetListFeed godoc
+//
+//	@Summary		Fetches list feed
+//	@Description	Fetches posts by the members of a list, newest first. Cursors, time bounds, authors and other orders are not supported.
+//	@Tags			lists
+//	@Accept			json
+//	@Produce		json
+//	@Param			listID	path		string	true	"List ID"
+//	@Param			limit	query		int		false	"Limit"
+//	@Param			offset	query		int		false	"Offset"
+//	@Param			tags	query		string	false	"Comma separated tags"
+//	@Param			search	query		string	false	"Search"
+//	@Success		200		{object}	[]store.GetListFeedRow
+//	@Failure		400		{object}	error	"Bad Request"
+//	@Failure		404		{object}	error	"Record Not Found"
+//	@Failure		500		{object}	error	"Server encountered a problem"
+//	@Security		ApiKeyAuth
+//	@Router			/lists/{listID}/feed [get]
+func (app *application) getListFeedHandler(w http.ResponseWriter, r *http.Request) {
+	fq, err := parseFeedQuery(r)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if param := listFeedUnsupported(fq); param != "" {
+		app.badRequestResponse(w, r, fmt.Errorf("%s is not supported by list feeds", param))
+		return
+	}
+
+	ctx := r.Context()
+
+	user := app.GetUserFromCtx(r)
+	list := app.GetListFromCtx(r)
+
+	getListFeedParams := &store.GetListFeedParams{
+		ListID:  list.ID,
+		ID:      user.ID,
+		Column3: fq.Search, // Search
+		Tags:    fq.Tags,
+		Limit:   int64(fq.Limit),
+		Offset:  int64(fq.Offset),
+	}
+
+	feed, err := app.store.GetListFeed(ctx, *getListFeedParams)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	muter, err := app.userMuter(ctx, user.ID)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+	feed = dropMuted(muter, feed, func(p store.GetListFeedRow) []string {
+		return postTexts(p.Title, p.Content, p.Tags)
+	})
+
+	if err := app.jsonResponse(w, http.StatusOK, feed); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+// listFeedUnsupported names a feed query parameter that GetListFeed cannot
+// honour, so clients are told instead of silently getting the first page.
+func listFeedUnsupported(fq store.PaginatedFeedQuery) string {
+	switch {
+	case fq.Cursor != "":
+		return "cursor"
+	case len(fq.Authors) > 0:
+		return "authors"
+	case !fq.Since.IsZero():
+		return "since"
+	case !fq.Until.IsZero():
+		return "until"
+	case fq.Sort != "latest":
+		return "sort"
+	case fq.Order != "desc":
+		return "order"
+	}
+	return ""
+}
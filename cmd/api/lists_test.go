@@ -0,0 +1,35 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/JaskiratAnand/go-social/internal/store"
+	"github.com/google/uuid"
+)
+
+func TestGetListFeedUnsupportedParams(t *testing.T) {
+	app := TestMockApplication(t, config{})
+	user := store.Users{ID: uuid.New()}
+	list := store.Lists{ID: uuid.New(), UserID: user.ID}
+
+	for _, query := range []string{
+		"cursor=abc",
+		"authors=alice",
+		"since=7d",
+		"until=2025-11-01",
+		"sort=top",
+		"order=asc",
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/v1/lists/"+list.ID.String()+"/feed?"+query, nil)
+		ctx := context.WithValue(req.Context(), userCtx, user)
+		ctx = context.WithValue(ctx, listCtx, list)
+
+		rr := executeRequest(req.WithContext(ctx), http.HandlerFunc(app.getListFeedHandler))
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected %d; got %d", query, http.StatusBadRequest, rr.Code)
+		}
+	}
+}
@@ -1,17 +1,23 @@
 package main
 
 import (
+	"context"
 	"expvar"
+	"net/url"
 	"runtime"
 	"time"
 
+	"github.com/JaskiratAnand/go-social/internal/activitypub"
 	"github.com/JaskiratAnand/go-social/internal/auth"
 	"github.com/JaskiratAnand/go-social/internal/db"
 	"github.com/JaskiratAnand/go-social/internal/env"
 	"github.com/JaskiratAnand/go-social/internal/mailer"
+	"github.com/JaskiratAnand/go-social/internal/ranking"
 	"github.com/JaskiratAnand/go-social/internal/ratelimiter"
+	"github.com/JaskiratAnand/go-social/internal/realtime"
 	"github.com/JaskiratAnand/go-social/internal/store"
 	"github.com/JaskiratAnand/go-social/internal/store/cache"
+	"github.com/JaskiratAnand/go-social/internal/timeline"
 	"github.com/redis/go-redis/v9"
 	"go.uber.org/zap"
 )
@@ -56,6 +62,14 @@ func main() {
 			db:      env.GetInt("Redis_DB", 0),
 			enabled: env.GetBool("REDIS_ENABLED", false),
 		},
+		cache: cacheConfig{
+			tiered: env.GetBool("CACHE_TIERED", false),
+			memory: cache.MemoryConfig{
+				MaxEntries: env.GetInt("CACHE_MAX_ENTRIES", 10000),
+				MaxBytes:   int64(env.GetInt("CACHE_MAX_MB", 64)) << 20,
+			},
+			localTTL: 30 * time.Second,
+		},
 		env: env.GetString("ENV", "development"),
 		mail: mailConfig{
 			exp:       (24 * time.Hour),
@@ -79,6 +93,59 @@ func main() {
 			RequestPerTimeFrame: env.GetInt("RATE_LIMITER_REQUESTS_COUNT", 20),
 			TimeFrame:           time.Second * 5,
 			Enabled:             env.GetBool("RATE_LIMITER_ENABLED", true),
+			Strategy:            env.GetString("RATE_LIMITER_STRATEGY", ratelimiter.SlidingWindow),
+			MaxKeys:             env.GetInt("RATE_LIMITER_MAX_KEYS", ratelimiter.DefaultMaxKeys),
+			Backend:             env.GetString("RATE_LIMITER_BACKEND", ratelimiter.MemoryBackend),
+			FailOpen:            env.GetBool("RATE_LIMITER_FAIL_OPEN", true),
+		},
+		feed: feedConfig{
+			cursorSecret: env.GetString("FEED_CURSOR_SECRET", ""),
+		},
+		timeline: timeline.Config{
+			Enabled:            env.GetBool("TIMELINE_ENABLED", false),
+			MaxLength:          int64(env.GetInt("TIMELINE_MAX_LENGTH", 800)),
+			CelebrityThreshold: int64(env.GetInt("TIMELINE_CELEBRITY_THRESHOLD", 10000)),
+		},
+		realtime: realtime.Config{
+			Redis:        env.GetBool("REALTIME_REDIS_ENABLED", false),
+			ReplaySize:   env.GetInt("REALTIME_REPLAY_SIZE", 100),
+			ReplayWindow: 10 * time.Minute,
+		},
+		suggestions: suggestionsConfig{
+			enabled:  env.GetBool("SUGGESTIONS_JOB_ENABLED", true),
+			interval: time.Hour,
+		},
+		explore: exploreConfig{
+			enabled:  env.GetBool("EXPLORE_JOB_ENABLED", true),
+			interval: 10 * time.Minute,
+		},
+		antiSpam: antiSpamConfig{
+			enabled:         env.GetBool("ANTISPAM_ENABLED", true),
+			duplicateWindow: 24 * time.Hour,
+			quotas: []postingQuota{
+				{roles: []string{"MODERATOR", "ADMIN"}},
+				{
+					maxAccountAge: time.Duration(env.GetInt("ANTISPAM_NEW_ACCOUNT_HOURS", 24)) * time.Hour,
+					posts:         env.GetInt("ANTISPAM_NEW_ACCOUNT_POSTS_PER_HOUR", 5),
+					comments:      env.GetInt("ANTISPAM_NEW_ACCOUNT_COMMENTS_PER_HOUR", 20),
+					window:        time.Hour,
+				},
+				{
+					posts:    env.GetInt("ANTISPAM_POSTS_PER_HOUR", 30),
+					comments: env.GetInt("ANTISPAM_COMMENTS_PER_HOUR", 120),
+					window:   time.Hour,
+				},
+			},
+		},
+		filters: filtersConfig{
+			enabled:         env.GetBool("CONTENT_FILTERS_ENABLED", true),
+			refreshInterval: time.Minute,
+		},
+		activityPub: activityPubConfig{
+			enabled: env.GetBool("ACTIVITYPUB_ENABLED", false),
+			baseURL: env.GetString("ACTIVITYPUB_BASE_URL", "http://localhost:8080"),
+
+			allowInsecure: env.GetBool("ACTIVITYPUB_ALLOW_INSECURE", false),
 		},
 	}
 
@@ -108,14 +175,95 @@ func main() {
 	}
 
 	// rate limiter
-	ratelimiter := ratelimiter.NewFixedWindowLimiter(
-		cfg.ratelimiter.RequestPerTimeFrame,
-		cfg.ratelimiter.TimeFrame,
+	rateLimitPolicies, err := rateLimitPolicies(
+		env.GetString("RATE_LIMITER_POLICIES", ""),
+		env.GetString("RATE_LIMITER_INTERNAL_NETWORKS", ""),
 	)
+	if err != nil {
+		logger.Fatal(err)
+	}
+	cfg.ratelimiter.Policies = rateLimitPolicies
+
+	cfg.trustedProxies, err = parseNetworks(env.GetString("TRUSTED_PROXIES", ""))
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	janitor := ratelimiter.NewJanitor(time.Minute)
+	rateLimiterOpts := ratelimiter.Options{
+		Janitor: janitor,
+		OnError: ratelimiter.ThrottleErrors(time.Minute, func(err error, dropped int) {
+			logger.Warnw("rate limiter backend error", "error", err.Error(), "fail_open", cfg.ratelimiter.FailOpen, "dropped", dropped)
+		}),
+	}
+	if cfg.redisCfg.enabled {
+		rateLimiterOpts.Redis = rdb
+	}
+	rateLimiter, err := ratelimiter.NewPolicies(cfg.ratelimiter, rateLimiterOpts)
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	// feed cursors, a known secret lets clients forge cursors
+	if cfg.feed.cursorSecret == "" {
+		if cfg.env == "production" {
+			logger.Fatal("FEED_CURSOR_SECRET must be set in production")
+		}
+		logger.Warnw("FEED_CURSOR_SECRET is not set, using an insecure development secret")
+		cfg.feed.cursorSecret = "secret"
+	}
+	cursorCodec := store.NewCursorCodec(cfg.feed.cursorSecret)
+
+	// home timelines
+	var redisTimeline *timeline.RedisTimeline
+	if cfg.timeline.Enabled && cfg.redisCfg.enabled {
+		redisTimeline = timeline.NewRedisTimeline(rdb, cfg.timeline.MaxLength)
+	}
+
+	// realtime events
+	var realtimeBackend realtime.Backend
+	if cfg.realtime.Redis && cfg.redisCfg.enabled {
+		realtimeBackend = realtime.NewRedisBackend(rdb, "realtime-events")
+	}
+	hub := realtime.NewHub(cfg.realtime, realtimeBackend)
+
+	// federation
+	if cfg.activityPub.enabled {
+		u, err := url.Parse(cfg.activityPub.baseURL)
+		if err != nil || u.Host == "" {
+			logger.Fatalw("invalid ACTIVITYPUB_BASE_URL", "url", cfg.activityPub.baseURL)
+		}
+		cfg.activityPub.domain = u.Host
+	}
+	if cfg.activityPub.allowInsecure && cfg.env == "production" {
+		logger.Fatal("ACTIVITYPUB_ALLOW_INSECURE must not be set in production")
+	}
+	apClient := activitypub.NewClient(activitypub.ClientConfig{
+		Timeout:       10 * time.Second,
+		UserAgent:     "GoSocial/" + version,
+		AllowInsecure: cfg.activityPub.allowInsecure,
+		KeyCacheTTL:   time.Hour,
+	})
 
 	// stores
 	store := store.New(db)
-	cacheStorage := cache.NewRedisStorage(rdb)
+
+	roleNames, err := loadRoleNames(store)
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	var cacheStorage cache.Storage
+	var cacheBus *cache.InvalidationBus
+	switch {
+	case !cfg.redisCfg.enabled:
+		cacheStorage = cache.NewMemoryStorage(cfg.cache.memory)
+	case cfg.cache.tiered:
+		cacheBus = cache.NewInvalidationBus(rdb, "cache-invalidations")
+		cacheStorage = cache.NewTieredStorage(rdb, cfg.cache.memory, cfg.cache.localTTL, cacheBus)
+	default:
+		cacheStorage = cache.NewRedisStorage(rdb)
+	}
 
 	// mailer
 	mailer := mailer.NewSendGrid(cfg.mail.sendGrid.apiKey, cfg.mail.emailAddr)
@@ -131,10 +279,18 @@ func main() {
 		config:        cfg,
 		store:         store,
 		cacheStorage:  cacheStorage,
+		cacheBus:      cacheBus,
 		logger:        logger,
 		mailer:        mailer,
 		authenticator: jwtAuthenticator,
-		rateLimiter:   ratelimiter,
+		rateLimiter:   rateLimiter,
+		janitor:       janitor,
+		cursorCodec:   cursorCodec,
+		timeline:      redisTimeline,
+		ranker:        ranking.New(ranking.DefaultWeights, time.Now),
+		hub:           hub,
+		apClient:      apClient,
+		roleNames:     roleNames,
 	}
 
 	expvar.NewString("version").Set(version)
@@ -150,6 +306,24 @@ func main() {
 	logger.Fatal(app.run(mux))
 }
 
+// loadRoleNames maps role ids to names. Roles are only changed by
+// migrations, so they are read once at startup.
+func loadRoleNames(q *store.Queries) (map[int32]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), QueryTimeoutDuration)
+	defer cancel()
+
+	roles, err := q.GetRoles(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make(map[int32]string, len(roles))
+	for _, role := range roles {
+		names[role.ID] = role.Name
+	}
+	return names, nil
+}
+
 func If[T any](cond bool, vtrue, vfalse T) T {
 	if cond {
 		return vtrue
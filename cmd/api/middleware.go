@@ -2,14 +2,19 @@ package main
 
 import (
 	"context"
+	"database/sql"
 	"encoding/base64"
 	"errors"
 	"fmt"
 	"net/http"
+	"regexp"
 	"strings"
+	"time"
 
 	"github.com/JaskiratAnand/go-social/internal/store"
+	"github.com/JaskiratAnand/go-social/internal/websocket"
 	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 )
@@ -19,6 +24,9 @@ type contextKey string
 const (
 	userCtx contextKey = "user"
 	postCtx contextKey = "post"
+	listCtx contextKey = "list"
+	// tokenCtx holds a bearerUser resolved by RateLimiterMiddleware
+	tokenCtx contextKey = "token"
 )
 
 func (app *application) GetUserFromCtx(r *http.Request) store.Users {
@@ -31,9 +39,20 @@ func (app *application) GetPostFromCtx(r *http.Request) store.Posts {
 	return post
 }
 
+func (app *application) GetListFromCtx(r *http.Request) store.Lists {
+	list := r.Context().Value(listCtx).(store.Lists)
+	return list
+}
+
 func (app *application) ContextMiddlware() func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// long lived connections time out their queries one by one
+			if isLongLived(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
 			ctx, cancel := context.WithTimeout(r.Context(), QueryTimeoutDuration)
 			defer cancel()
 			next.ServeHTTP(w, r.WithContext(ctx))
@@ -94,35 +113,64 @@ func (app *application) AuthTokenMiddleware() func(http.Handler) http.Handler {
 				return
 			}
 
-			token := parts[1]
-			jwtToken, err := app.authenticator.ValidateToken(token)
-			if err != nil {
-				app.unauthorizedErrorResponse(w, r, err)
-				return
-			}
-
-			claims, _ := jwtToken.Claims.(jwt.MapClaims)
+			ctx := r.Context()
 
-			userID, err := uuid.Parse(claims["sub"].(string))
-			if err != nil {
-				app.unauthorizedErrorResponse(w, r, err)
-				return
+			// the rate limiter may have resolved the token already
+			bearer, ok := ctx.Value(tokenCtx).(bearerUser)
+			if !ok || bearer.token != parts[1] {
+				user, err := app.tokenUser(ctx, parts[1])
+				if err != nil {
+					app.unauthorizedErrorResponse(w, r, err)
+					return
+				}
+				bearer = bearerUser{token: parts[1], user: user}
 			}
+			user := bearer.user
 
-			ctx := r.Context()
-
-			user, err := app.getUser(ctx, userID)
-			if err != nil {
-				app.unauthorizedErrorResponse(w, r, err)
+			// tokens issued before a suspension or ban stop working with it
+			if restriction := accountRestriction(user, time.Now()); restriction != "" {
+				app.customErrorResponse(w, r, http.StatusForbidden, restriction)
 				return
 			}
 
-			ctx = context.WithValue(ctx, userCtx, user)
+			ctx = context.WithValue(ctx, userCtx, *user)
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
 }
 
+// bearerUser is a bearer token and the user it was issued to.
+type bearerUser struct {
+	token string
+	user  *store.Users
+}
+
+// tokenUser validates a bearer token and loads its user. Streams and the
+// rate limiter run without a request deadline, so the lookup gets its own.
+func (app *application) tokenUser(ctx context.Context, token string) (*store.Users, error) {
+	userID, err := app.tokenUserID(token)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+	defer cancel()
+	return app.getUser(ctx, userID)
+}
+
+// tokenUserID validates a bearer token and returns the user it was issued to.
+func (app *application) tokenUserID(token string) (uuid.UUID, error) {
+	jwtToken, err := app.authenticator.ValidateToken(token)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	claims, _ := jwtToken.Claims.(jwt.MapClaims)
+
+	sub, _ := claims["sub"].(string)
+	return uuid.Parse(sub)
+}
+
 func (app *application) checkPostOwnership(requiredRole string, next http.HandlerFunc) http.HandlerFunc {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
@@ -164,6 +212,76 @@ func (app *application) checkPostOwnership(requiredRole string, next http.Handle
 	})
 }
 
+// listContextMiddleware loads the list into the request context. Private
+// lists are reported as missing to everyone but their owner.
+func (app *application) listContextMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		user := app.GetUserFromCtx(r)
+
+		idParam := chi.URLParam(r, "listID")
+		listID, err := uuid.Parse(idParam)
+		if err != nil {
+			app.badRequestResponse(w, r, errors.New("invalid list-id"))
+			return
+		}
+
+		list, err := app.store.GetListById(ctx, listID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				app.recordNotFoundResponse(w, r, err)
+				return
+			}
+			app.internalServerError(w, r, err)
+			return
+		}
+
+		if list.IsPrivate && list.UserID != user.ID {
+			app.recordNotFoundResponse(w, r, errors.New("list is private"))
+			return
+		}
+
+		ctx = context.WithValue(ctx, listCtx, list)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func (app *application) checkListOwnership(next http.HandlerFunc) http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user := app.GetUserFromCtx(r)
+		list := app.GetListFromCtx(r)
+
+		if list.UserID != user.ID {
+			app.forbiddenResponse(w, r)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// requireRoleMiddleware lets through users with roleName or a higher role.
+func (app *application) requireRoleMiddleware(roleName string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user := app.GetUserFromCtx(r)
+
+			allowed, err := app.checkRolePrecedence(r.Context(), &user, roleName)
+			if err != nil {
+				app.internalServerError(w, r, err)
+				return
+			}
+
+			if !allowed {
+				app.forbiddenResponse(w, r)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
 func (app *application) checkRolePrecedence(ctx context.Context, user *store.Users, roleName string) (bool, error) {
 	role, err := app.store.GetRoleByName(ctx, roleName)
 	if err != nil {
@@ -172,34 +290,116 @@ func (app *application) checkRolePrecedence(ctx context.Context, user *store.Use
 	return user.RoleID >= role.ID, nil
 }
 
+// getUser reads a user through the cache. Concurrent misses share one query
+// and unknown ids are remembered, both answering with sql.ErrNoRows.
 func (app *application) getUser(ctx context.Context, userID uuid.UUID) (*store.Users, error) {
-	user, _ := app.cacheStorage.Users.Get(ctx, userID)
-
-	if user == nil {
+	return app.cacheStorage.Users.Load(ctx, userID, func(ctx context.Context) (*store.Users, error) {
 		user, err := app.store.GetUserByUserId(ctx, userID)
 		if err != nil {
 			return nil, err
 		}
+		return &user, nil
+	})
+}
 
-		err = app.cacheStorage.Users.Set(ctx, &user)
-		if err != nil {
-			return nil, err
-		}
+// requestIDPattern matches request ids accepted from clients. They end up
+// in logs and audit exports, so anything else is replaced.
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9-]{1,64}$`)
 
-		return &user, nil
+// RequestIDMiddleware is middleware.RequestID, generating ids for requests
+// without a plain X-Request-Id of their own.
+func (app *application) RequestIDMiddleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		withID := middleware.RequestID(next)
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if id := r.Header.Get(middleware.RequestIDHeader); id != "" && !requestIDPattern.MatchString(id) {
+				r.Header.Del(middleware.RequestIDHeader)
+			}
+			withID.ServeHTTP(w, r)
+		})
 	}
+}
 
-	return user, nil
+// RealIPMiddleware sets r.RemoteAddr to the address forwarded by a trusted
+// proxy. Requests from anyone else keep the address of their connection, so
+// a made up X-Forwarded-For can neither match the internal networks nor
+// start a fresh rate limit budget.
+func (app *application) RealIPMiddleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if app.trustedProxy(remoteIP(r)) {
+				if ip, ok := app.forwardedIP(r); ok {
+					r.RemoteAddr = ip.String()
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
 }
 
-func (app *application) RateLimiterMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if app.config.ratelimiter.Enabled {
-			if allow, retryAfter := app.rateLimiter.Allow(r.RemoteAddr); !allow {
-				app.rateLimitExceededResponse(w, r, retryAfter.String())
+// RateLimiterMiddleware limits requests by the first policy matching their
+// route, user, role or address. It runs before routing and authentication,
+// so it looks both up on its own.
+func (app *application) RateLimiterMiddleware(routes chi.Routes) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !app.config.ratelimiter.Enabled {
+				next.ServeHTTP(w, r)
 				return
 			}
-		}
-		next.ServeHTTP(w, r)
+
+			client, bearer := app.rateLimitClient(r, routes)
+			res, limited := app.rateLimiter.Take(client)
+			if limited {
+				setRateLimitHeaders(w, res)
+			}
+
+			if !res.Allowed {
+				app.rateLimitExceededResponse(w, r, res.RetryAfter.String())
+				return
+			}
+
+			if bearer != nil {
+				r = r.WithContext(context.WithValue(r.Context(), tokenCtx, *bearer))
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func (app *application) canViewPosts(ctx context.Context, viewer *store.Users, authorID uuid.UUID) (bool, error) {
+	if viewer.ID == authorID {
+		return true, nil
+	}
+
+	return app.store.CanViewUserPosts(ctx, store.CanViewUserPostsParams{
+		UserID: viewer.ID,
+		ID:     authorID,
 	})
 }
+
+// TimeoutMiddleware applies middleware.Timeout to regular requests. Upgraded
+// websocket connections outlive any request timeout and skip it.
+func (app *application) TimeoutMiddleware(timeout time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		withTimeout := middleware.Timeout(timeout)(next)
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if isLongLived(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			withTimeout.ServeHTTP(w, r)
+		})
+	}
+}
+
+// isLongLived reports whether r opens a WebSocket or an event stream, which
+// stay open far longer than the timeouts of ordinary requests. Upgrade
+// headers count on the WebSocket route only, anywhere else they would let
+// any client lift the deadlines of its queries.
+func isLongLived(r *http.Request) bool {
+	return (r.URL.Path == wsPath && websocket.IsUpgrade(r)) || r.URL.Path == feedStreamPath
+}
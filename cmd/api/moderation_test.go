@@ -0,0 +1,507 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/JaskiratAnand/go-social/internal/mailer"
+	"github.com/JaskiratAnand/go-social/internal/realtime"
+	"github.com/JaskiratAnand/go-social/internal/store"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// testMailer records the templates of the emails sent.
+type testMailer struct {
+	mu   sync.Mutex
+	sent []string
+}
+
+func (m *testMailer) Send(templateFile, username, email string, data any, isSandbox bool) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.sent = append(m.sent, templateFile)
+	return http.StatusOK, nil
+}
+
+func (m *testMailer) templates() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]string(nil), m.sent...)
+}
+
+// role ids of TestMockApplication
+var testRoles = map[string]int32{"USER": 1, "MODERATOR": 2, "ADMIN": 3}
+
+func userRows(u store.Users) *sqlmock.Rows {
+	return sqlmock.NewRows([]string{
+		"id", "email", "username", "password", "created_at", "verified", "role_id", "is_private",
+		"suspended_until", "suspension_reason", "banned_at", "ban_reason",
+	}).AddRow(u.ID, u.Email, u.Username, []byte{}, time.Now(), true, u.RoleID, false,
+		u.SuspendedUntil, u.SuspensionReason, u.BannedAt, u.BanReason)
+}
+
+func reportRows(r store.Reports) *sqlmock.Rows {
+	return sqlmock.NewRows([]string{
+		"id", "reporter_id", "target_type", "target_id", "target_user_id", "reason", "details",
+		"status", "moderator_id", "actions", "note", "created_at", "claimed_at", "closed_at",
+	}).AddRow(r.ID, r.ReporterID, r.TargetType, r.TargetID, r.TargetUserID, r.Reason, r.Details,
+		r.Status, r.ModeratorID, "{}", r.Note, time.Now(), r.ClaimedAt, r.ClosedAt)
+}
+
+func expectRole(mock sqlmock.Sqlmock, name string) {
+	mock.ExpectQuery("GetRoleByName").WithArgs(name).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "description"}).AddRow(testRoles[name], name, ""))
+}
+
+// waitExpectations waits for queries run in the background after the
+// response.
+func waitExpectations(t *testing.T, mock sqlmock.Sqlmock) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		err := mock.ExpectationsWereMet()
+		if err == nil {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Error(err)
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// moderationCase is a request to a moderation or admin handler, made by
+// caller with the url params and body, against the queries set up by expect.
+type moderationCase struct {
+	name   string
+	caller store.Users
+	params map[string]string
+	body   string
+	expect func(mock sqlmock.Sqlmock)
+	want   int
+	// setup runs before the request, check after the response, with the
+	// target subscribed to its live events beforehand
+	setup func(t *testing.T, app *application)
+	check func(t *testing.T, app *application, sub *realtime.Subscription, mail *testMailer)
+}
+
+func runModerationCases(t *testing.T, handler func(*application) http.HandlerFunc, target uuid.UUID, cases []moderationCase) {
+	t.Helper()
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer db.Close()
+
+			app := TestMockApplication(t, config{})
+			app.store = store.New(db)
+			mail := &testMailer{}
+			app.mailer = mail
+
+			sub := app.hub.Subscribe()
+			defer sub.Close()
+			sub.Add(0, realtime.UserTopic(target))
+
+			if c.expect != nil {
+				c.expect(mock)
+			}
+			if c.setup != nil {
+				c.setup(t, app)
+			}
+
+			req := httptest.NewRequest(http.MethodPut, "/", strings.NewReader(c.body))
+			rctx := chi.NewRouteContext()
+			for k, v := range c.params {
+				rctx.URLParams.Add(k, v)
+			}
+			ctx := context.WithValue(req.Context(), chi.RouteCtxKey, rctx)
+			ctx = context.WithValue(ctx, userCtx, c.caller)
+
+			rr := executeRequest(req.WithContext(ctx), handler(app))
+			checkResponseCode(t, c.want, rr.Code)
+			waitExpectations(t, mock)
+
+			if c.check != nil {
+				c.check(t, app, sub, mail)
+			}
+		})
+	}
+}
+
+// assertSanctioned checks that a sanction took effect right away.
+func assertSanctioned(template string) func(*testing.T, *application, *realtime.Subscription, *testMailer) {
+	return func(t *testing.T, app *application, sub *realtime.Subscription, mail *testMailer) {
+		t.Helper()
+
+		if !sub.Disconnected() {
+			t.Error("expected the live connections of the user to be closed")
+		}
+
+		deadline := time.Now().Add(time.Second)
+		for len(mail.templates()) == 0 && time.Now().Before(deadline) {
+			time.Sleep(5 * time.Millisecond)
+		}
+		if sent := mail.templates(); len(sent) != 1 || sent[0] != template {
+			t.Errorf("expected a %s email; got %v", template, sent)
+		}
+	}
+}
+
+func assertNotSanctioned(t *testing.T, app *application, sub *realtime.Subscription, mail *testMailer) {
+	t.Helper()
+
+	if sub.Disconnected() {
+		t.Error("expected the user to stay connected")
+	}
+}
+
+func TestReportHandlers(t *testing.T) {
+	admin := store.Users{ID: uuid.New(), Username: "admin", RoleID: testRoles["ADMIN"]}
+	moderator := store.Users{ID: uuid.New(), Username: "mod", RoleID: testRoles["MODERATOR"]}
+	staff := store.Users{ID: uuid.New(), Username: "other-mod", RoleID: testRoles["MODERATOR"]}
+	user := store.Users{ID: uuid.New(), Username: "alice", RoleID: testRoles["USER"]}
+
+	reportOn := func(target store.Users, status string) store.Reports {
+		return store.Reports{
+			ID:           uuid.New(),
+			TargetType:   reportTargetPost,
+			TargetID:     uuid.New(),
+			TargetUserID: target.ID,
+			Reason:       "spam",
+			Status:       status,
+		}
+	}
+	params := func(r store.Reports) map[string]string {
+		return map[string]string{"reportID": r.ID.String()}
+	}
+	expectReport := func(mock sqlmock.Sqlmock, r store.Reports) {
+		mock.ExpectQuery("GetReportById").WithArgs(r.ID).WillReturnRows(reportRows(r))
+	}
+
+	t.Run("claim", func(t *testing.T) {
+		open := reportOn(user, reportOpen)
+		claimed := reportOn(user, reportClaimed)
+
+		runModerationCases(t, func(app *application) http.HandlerFunc { return app.claimReportHandler }, user.ID, []moderationCase{
+			{
+				name: "open reports are claimed", caller: moderator, params: params(open), want: http.StatusOK,
+				expect: func(mock sqlmock.Sqlmock) {
+					expectReport(mock, open)
+					mock.ExpectBegin()
+					claimed := open
+					claimed.Status = reportClaimed
+					mock.ExpectQuery("ClaimReport").WithArgs(moderator.ID, open.ID).WillReturnRows(reportRows(claimed))
+					mock.ExpectExec("CreateAuditLog").WillReturnResult(sqlmock.NewResult(0, 1))
+					mock.ExpectCommit()
+				},
+			},
+			{
+				name: "reports claimed by someone else conflict", caller: moderator, params: params(claimed), want: http.StatusConflict,
+				expect: func(mock sqlmock.Sqlmock) {
+					expectReport(mock, claimed)
+					mock.ExpectBegin()
+					mock.ExpectQuery("ClaimReport").WillReturnError(sql.ErrNoRows)
+					mock.ExpectRollback()
+				},
+			},
+		})
+	})
+
+	t.Run("resolve", func(t *testing.T) {
+		onUser := reportOn(user, reportOpen)
+		onStaff := reportOn(staff, reportOpen)
+
+		// resolving reads the moderator's and the target's roles first
+		expectTarget := func(mock sqlmock.Sqlmock, r store.Reports, target store.Users) {
+			expectReport(mock, r)
+			expectRole(mock, "ADMIN")
+			mock.ExpectQuery("GetUserByUserId").WithArgs(target.ID).WillReturnRows(userRows(target))
+			expectRole(mock, "MODERATOR")
+		}
+		expectClose := func(mock sqlmock.Sqlmock, r store.Reports) {
+			closed := r
+			closed.Status = reportResolved
+			mock.ExpectBegin()
+			mock.ExpectQuery("CloseReport").WillReturnRows(reportRows(closed))
+			mock.ExpectExec("CreateAuditLog").WillReturnResult(sqlmock.NewResult(0, 1))
+		}
+		expectFanOut := func(mock sqlmock.Sqlmock, r store.Reports, actions string, moderatorID uuid.UUID) {
+			mock.ExpectExec("CloseTargetReports").
+				WithArgs(actions, "resolved with report "+r.ID.String(), moderatorID, r.TargetType, r.TargetID, r.ID).
+				WillReturnResult(sqlmock.NewResult(0, 2))
+			mock.ExpectCommit()
+		}
+
+		runModerationCases(t, func(app *application) http.HandlerFunc { return app.resolveReportHandler }, user.ID, []moderationCase{
+			{
+				name: "users are warned", caller: moderator, params: params(onUser), body: `{"actions":["warn"]}`, want: http.StatusOK,
+				expect: func(mock sqlmock.Sqlmock) {
+					expectTarget(mock, onUser, user)
+					expectClose(mock, onUser)
+					mock.ExpectExec("CreateUserWarning").WithArgs(user.ID, sqlmock.AnyArg(), sqlmock.AnyArg(), "spam").
+						WillReturnResult(sqlmock.NewResult(0, 1))
+					expectFanOut(mock, onUser, `{"warn"}`, moderator.ID)
+				},
+				check: assertNotSanctioned,
+			},
+			{
+				name: "suspensions close live connections", caller: moderator, params: params(onUser),
+				body: `{"actions":["suspend"],"suspend_hours":24}`, want: http.StatusOK,
+				expect: func(mock sqlmock.Sqlmock) {
+					expectTarget(mock, onUser, user)
+					expectClose(mock, onUser)
+					mock.ExpectExec("SuspendUser").WithArgs(user.ID, sqlmock.AnyArg(), "spam").
+						WillReturnResult(sqlmock.NewResult(0, 1))
+					mock.ExpectExec("CreateAuditLog").WillReturnResult(sqlmock.NewResult(0, 1))
+					expectFanOut(mock, onUser, `{"suspend"}`, moderator.ID)
+				},
+				check: assertSanctioned(mailer.UserSuspendedTemplate),
+			},
+			{
+				name: "moderators cannot sanction staff", caller: moderator, params: params(onStaff), body: `{"actions":["warn"]}`, want: http.StatusForbidden,
+				expect: func(mock sqlmock.Sqlmock) {
+					expectTarget(mock, onStaff, staff)
+				},
+			},
+			{
+				name: "admins can sanction staff", caller: admin, params: params(onStaff), body: `{"actions":["warn"]}`, want: http.StatusOK,
+				expect: func(mock sqlmock.Sqlmock) {
+					expectTarget(mock, onStaff, staff)
+					expectClose(mock, onStaff)
+					mock.ExpectExec("CreateUserWarning").WillReturnResult(sqlmock.NewResult(0, 1))
+					expectFanOut(mock, onStaff, `{"warn"}`, admin.ID)
+				},
+			},
+			{
+				name: "closed reports conflict", caller: moderator, params: params(onUser), body: `{"actions":["warn"]}`, want: http.StatusConflict,
+				expect: func(mock sqlmock.Sqlmock) {
+					expectTarget(mock, onUser, user)
+					mock.ExpectBegin()
+					mock.ExpectQuery("CloseReport").WillReturnError(sql.ErrNoRows)
+					mock.ExpectRollback()
+				},
+				check: assertNotSanctioned,
+			},
+			{
+				name: "users cannot be hidden", caller: moderator, params: params(reportOn(user, reportOpen)),
+				body: `{"actions":["hide"]}`, want: http.StatusBadRequest,
+				expect: func(mock sqlmock.Sqlmock) {
+					r := onUser
+					r.TargetType = reportTargetUser
+					r.TargetID = user.ID
+					mock.ExpectQuery("GetReportById").WillReturnRows(reportRows(r))
+				},
+			},
+		})
+	})
+
+	t.Run("dismiss", func(t *testing.T) {
+		open := reportOn(user, reportOpen)
+
+		runModerationCases(t, func(app *application) http.HandlerFunc { return app.dismissReportHandler }, user.ID, []moderationCase{
+			{
+				name: "open reports are dismissed", caller: moderator, params: params(open), want: http.StatusOK,
+				expect: func(mock sqlmock.Sqlmock) {
+					expectReport(mock, open)
+					expectRole(mock, "ADMIN")
+					mock.ExpectBegin()
+					dismissed := open
+					dismissed.Status = reportDismissed
+					mock.ExpectQuery("CloseReport").
+						WithArgs(reportDismissed, "{}", "", moderator.ID, open.ID, false).
+						WillReturnRows(reportRows(dismissed))
+					mock.ExpectExec("CreateAuditLog").WillReturnResult(sqlmock.NewResult(0, 1))
+					mock.ExpectCommit()
+				},
+			},
+			{
+				name: "admins take over claimed reports", caller: admin, params: params(open), want: http.StatusOK,
+				expect: func(mock sqlmock.Sqlmock) {
+					expectReport(mock, open)
+					expectRole(mock, "ADMIN")
+					mock.ExpectBegin()
+					mock.ExpectQuery("CloseReport").
+						WithArgs(reportDismissed, "{}", "", admin.ID, open.ID, true).
+						WillReturnRows(reportRows(open))
+					mock.ExpectExec("CreateAuditLog").WillReturnResult(sqlmock.NewResult(0, 1))
+					mock.ExpectCommit()
+				},
+			},
+			{
+				name: "closed reports conflict", caller: moderator, params: params(open), want: http.StatusConflict,
+				expect: func(mock sqlmock.Sqlmock) {
+					expectReport(mock, open)
+					expectRole(mock, "ADMIN")
+					mock.ExpectBegin()
+					mock.ExpectQuery("CloseReport").WillReturnError(sql.ErrNoRows)
+					mock.ExpectRollback()
+				},
+			},
+		})
+	})
+}
+
+func TestSanctionHandlers(t *testing.T) {
+	admin := store.Users{ID: uuid.New(), Username: "admin", RoleID: testRoles["ADMIN"]}
+	otherAdmin := store.Users{ID: uuid.New(), Username: "root", RoleID: testRoles["ADMIN"]}
+	user := store.Users{ID: uuid.New(), Username: "alice", Email: "alice@example.com", RoleID: testRoles["USER"]}
+
+	suspended := user
+	suspended.SuspendedUntil = sql.NullTime{Time: time.Now().Add(time.Hour), Valid: true}
+	expired := user
+	expired.SuspendedUntil = sql.NullTime{Time: time.Now().Add(-time.Hour), Valid: true}
+
+	params := func(u store.Users) map[string]string {
+		return map[string]string{"userID": u.ID.String()}
+	}
+	expectTarget := func(mock sqlmock.Sqlmock, target store.Users) {
+		mock.ExpectQuery("GetUserByUserId").WithArgs(target.ID).WillReturnRows(userRows(target))
+		expectRole(mock, "ADMIN")
+	}
+
+	t.Run("suspend", func(t *testing.T) {
+		runModerationCases(t, func(app *application) http.HandlerFunc { return app.suspendUserHandler }, user.ID, []moderationCase{
+			{
+				name: "users are suspended", caller: admin, params: params(user), body: `{"hours":24,"reason":"spam"}`, want: http.StatusNoContent,
+				expect: func(mock sqlmock.Sqlmock) {
+					expectTarget(mock, user)
+					mock.ExpectBegin()
+					mock.ExpectExec("SuspendUser").WithArgs(user.ID, sqlmock.AnyArg(), "spam").WillReturnResult(sqlmock.NewResult(0, 1))
+					mock.ExpectExec("CreateAuditLog").WillReturnResult(sqlmock.NewResult(0, 1))
+					mock.ExpectCommit()
+				},
+				check: assertSanctioned(mailer.UserSuspendedTemplate),
+			},
+			{
+				name: "admins cannot suspend themselves", caller: admin, params: params(admin),
+				body: `{"hours":24,"reason":"spam"}`, want: http.StatusBadRequest,
+			},
+			{
+				name: "admins cannot suspend each other", caller: admin, params: params(otherAdmin),
+				body: `{"hours":24,"reason":"spam"}`, want: http.StatusForbidden,
+				expect: func(mock sqlmock.Sqlmock) {
+					expectTarget(mock, otherAdmin)
+				},
+			},
+			{
+				name: "a reason is required", caller: admin, params: params(user), body: `{"hours":24}`, want: http.StatusBadRequest,
+			},
+		})
+	})
+
+	t.Run("ban", func(t *testing.T) {
+		runModerationCases(t, func(app *application) http.HandlerFunc { return app.banUserHandler }, user.ID, []moderationCase{
+			{
+				name: "users are banned", caller: admin, params: params(user), body: `{"reason":"spam"}`, want: http.StatusNoContent,
+				expect: func(mock sqlmock.Sqlmock) {
+					expectTarget(mock, user)
+					mock.ExpectBegin()
+					mock.ExpectExec("BanUser").WithArgs(user.ID, "spam").WillReturnResult(sqlmock.NewResult(0, 1))
+					mock.ExpectExec("CreateAuditLog").WillReturnResult(sqlmock.NewResult(0, 1))
+					mock.ExpectCommit()
+					// their posts leave the feeds of their followers
+					mock.ExpectQuery("GetFollowerIds").WithArgs(user.ID).WillReturnRows(sqlmock.NewRows([]string{"user_id"}))
+				},
+				setup: func(t *testing.T, app *application) {
+					app.cacheStorage.Explore.SetPosts(context.Background(), []store.GetExplorePostsRow{{Username: user.Username}})
+				},
+				check: func(t *testing.T, app *application, sub *realtime.Subscription, mail *testMailer) {
+					assertSanctioned(mailer.UserBannedTemplate)(t, app, sub, mail)
+
+					if posts, _ := app.cacheStorage.Explore.GetPosts(context.Background()); posts != nil {
+						t.Error("expected the cached explore feed to be dropped")
+					}
+				},
+			},
+		})
+	})
+
+	t.Run("reinstate", func(t *testing.T) {
+		runModerationCases(t, func(app *application) http.HandlerFunc { return app.reinstateUserHandler }, user.ID, []moderationCase{
+			{
+				name: "suspended users are reinstated", caller: admin, params: params(suspended), want: http.StatusNoContent,
+				expect: func(mock sqlmock.Sqlmock) {
+					expectTarget(mock, suspended)
+					mock.ExpectBegin()
+					mock.ExpectExec("ReinstateUser").WithArgs(user.ID).WillReturnResult(sqlmock.NewResult(0, 1))
+					mock.ExpectExec("CreateAuditLog").WillReturnResult(sqlmock.NewResult(0, 1))
+					mock.ExpectCommit()
+				},
+				check: assertNotSanctioned,
+			},
+			{
+				name: "expired suspensions need no reinstating", caller: admin, params: params(expired), want: http.StatusBadRequest,
+				expect: func(mock sqlmock.Sqlmock) {
+					expectTarget(mock, expired)
+				},
+			},
+		})
+	})
+}
+
+func TestAuthTokenMiddlewareRestrictions(t *testing.T) {
+	now := time.Now()
+
+	cases := []struct {
+		name string
+		user store.Users
+		want int
+	}{
+		{"active users pass", store.Users{}, http.StatusNoContent},
+		{"suspended users are rejected", store.Users{SuspendedUntil: sql.NullTime{Time: now.Add(time.Hour), Valid: true}}, http.StatusForbidden},
+		{"expired suspensions end on their own", store.Users{SuspendedUntil: sql.NullTime{Time: now.Add(-time.Minute), Valid: true}}, http.StatusNoContent},
+		{"banned users are rejected", store.Users{BannedAt: sql.NullTime{Time: now, Valid: true}, BanReason: "spam"}, http.StatusForbidden},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer db.Close()
+
+			app := TestMockApplication(t, config{})
+			app.store = store.New(db)
+
+			token, _ := app.authenticator.GenerateToken(nil)
+			userID, err := app.tokenUserID(token)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			u := c.user
+			u.ID = userID
+			u.RoleID = testRoles["USER"]
+			mock.ExpectQuery("GetUserByUserId").WithArgs(userID).WillReturnRows(userRows(u))
+
+			handler := app.AuthTokenMiddleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNoContent)
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/v1/users/feed", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			rr := executeRequest(req, handler)
+			checkResponseCode(t, c.want, rr.Code)
+
+			if c.want == http.StatusForbidden && !strings.Contains(rr.Body.String(), "account") {
+				t.Errorf("expected the restriction in the response; got %s", rr.Body.String())
+			}
+		})
+	}
+}
@@ -4,8 +4,13 @@ import (
 	"database/sql"
 	"errors"
 	"net/http"
+	"slices"
+	"time"
 
+	"github.com/JaskiratAnand/go-social/internal/audit"
+	"github.com/JaskiratAnand/go-social/internal/filter"
 	"github.com/JaskiratAnand/go-social/internal/store"
+	"github.com/JaskiratAnand/go-social/internal/timeline"
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 )
@@ -25,7 +30,11 @@ type CreatePostPayload struct {
 //	@Produce		json
 //	@Param			CreatePost	body		CreatePostPayload	true	"Create Post Payload"
 //	@Success		201			{object}	store.CreatePostRow
+//	@Success		202			{object}	store.CreatePostRow	"Held for review"
 //	@Failure		400			{object}	error	"Bad Request"
+//	@Failure		409			{object}	error	"Duplicate content"
+//	@Failure		422			{object}	error	"Rejected by a content filter"
+//	@Failure		429			{object}	error	"Posting quota exceeded"
 //	@Failure		500			{object}	error	"Server encountered a problem"
 //	@Security		ApiKeyAuth
 //	@Router			/posts [post]
@@ -41,6 +50,16 @@ func (app *application) createPostHandler(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	// masked content is what gets compared for duplicates and stored
+	fields := []*string{&payload.Title, &payload.Content}
+	for i := range payload.Tags {
+		fields = append(fields, &payload.Tags[i])
+	}
+	action, matched, ok := app.filterContent(w, r, fields...)
+	if !ok {
+		return
+	}
+
 	ctx := r.Context()
 
 	// get user id
@@ -53,13 +72,68 @@ func (app *application) createPostHandler(w http.ResponseWriter, r *http.Request
 		Tags:    payload.Tags,
 	}
 
+	held := action == filter.Hold
+
 	var post store.CreatePostRow
-	var err error
-	if post, err = app.store.CreatePost(ctx, *createPost); err != nil {
+	err := app.store.ExecTx(ctx, func(q *store.Queries) error {
+		// titles like "Good morning" are common, so only the author's own
+		// posts count as duplicates
+		err := app.checkPosting(ctx, q, &user, postingPosts, func(since time.Time) (bool, error) {
+			return q.RecentPostWithContentExists(ctx, store.RecentPostWithContentExistsParams{
+				UserID:  user.ID,
+				Title:   payload.Title,
+				Content: payload.Content,
+				Since:   since,
+			})
+		})
+		if err != nil {
+			return err
+		}
+
+		if post, err = q.CreatePost(ctx, *createPost); err != nil {
+			return err
+		}
+		if !held {
+			return nil
+		}
+		return holdForReview(ctx, q, reportTargetPost, post.ID, user.ID, matched)
+	})
+	if app.postingRefused(w, r, err) {
+		return
+	}
+	if err != nil {
 		app.internalServerError(w, r, err)
 		return
 	}
 
+	// held posts reach feeds and followers once a moderator approves them
+	if held {
+		if err := app.jsonResponse(w, http.StatusAccepted, post); err != nil {
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	app.invalidatePostFeeds(user.ID, payload.Tags)
+	app.fanOutPost(user.ID, timeline.Entry{PostID: post.ID, CreatedAt: post.CreatedAt})
+	app.publishPost(&user, PostEvent{
+		ID:        post.ID,
+		Title:     payload.Title,
+		Tags:      payload.Tags,
+		UserID:    user.ID,
+		Username:  user.Username,
+		CreatedAt: post.CreatedAt,
+	})
+	app.federatePost(&user, store.Posts{
+		ID:        post.ID,
+		Title:     payload.Title,
+		Content:   payload.Content,
+		Tags:      payload.Tags,
+		UserID:    user.ID,
+		CreatedAt: post.CreatedAt,
+		UpdatedAt: post.UpdatedAt,
+	})
+
 	if err := app.jsonResponse(w, http.StatusCreated, post); err != nil {
 		app.internalServerError(w, r, err)
 		return
@@ -93,7 +167,7 @@ func (app *application) getPostHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var post store.GetPostWithCommentsByIdRow
-	if post, err = app.store.GetPostWithCommentsById(ctx, postID); err != nil {
+	if post, err = app.getPost(ctx, postID); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			app.recordNotFoundResponse(w, r, err)
 			return
@@ -102,6 +176,18 @@ func (app *application) getPostHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// posts by private accounts are only visible to approved followers
+	user := app.GetUserFromCtx(r)
+	canView, err := app.canViewPosts(ctx, &user, post.UserID)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+	if !canView {
+		app.recordNotFoundResponse(w, r, errors.New("post author is private"))
+		return
+	}
+
 	if err := app.jsonResponse(w, http.StatusOK, post); err != nil {
 		app.internalServerError(w, r, err)
 		return
@@ -125,8 +211,20 @@ func (app *application) getPostHandler(w http.ResponseWriter, r *http.Request) {
 func (app *application) deletePostHandler(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	post := app.GetPostFromCtx(r)
+	user := app.GetUserFromCtx(r)
+
+	err := app.store.ExecTx(ctx, func(q *store.Queries) error {
+		if err := q.DeletePostById(ctx, post.ID); err != nil {
+			return err
+		}
 
-	if err := app.store.DeletePostById(ctx, post.ID); err != nil {
+		// staff acting on posts of others are held to account
+		if post.UserID == user.ID {
+			return nil
+		}
+		return audit.Record(ctx, q, app.auditEntry(r, audit.PostDelete, "post", post.ID.String(), post, nil))
+	})
+	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			app.recordNotFoundResponse(w, r, err)
 			return
@@ -134,6 +232,10 @@ func (app *application) deletePostHandler(w http.ResponseWriter, r *http.Request
 		app.internalServerError(w, r, err)
 		return
 	}
+
+	app.invalidatePost(ctx, post.ID)
+	app.invalidatePostFeeds(post.UserID, post.Tags)
+
 	w.WriteHeader(http.StatusNoContent)
 }
 
@@ -153,8 +255,10 @@ type UpdatePostPayload struct {
 //	@Param			postID		path		string				true	"Post ID"
 //	@Param			updatePost	body		UpdatePostPayload	true	"Update Post Payload"
 //	@Success		200			{object}	store.UpdatePostByIdRow
+//	@Success		202			{object}	store.UpdatePostByIdRow	"Held for review"
 //	@Failure		400			{object}	error	"Bad Request"
 //	@Failure		401			{object}	error	"Unauthorized"
+//	@Failure		422			{object}	error	"Rejected by a content filter"
 //	@Failure		500			{object}	error	"Server encountered a problem"
 //	@Security		ApiKeyAuth
 //	@Router			/posts/{postID} [patch]
@@ -177,18 +281,59 @@ func (app *application) updatePostHandler(w http.ResponseWriter, r *http.Request
 	updatePost := &store.UpdatePostByIdParams{
 		Title:     If(payload.Title != "", payload.Title, post.Title),
 		Content:   If(payload.Content != "", payload.Content, post.Content),
-		Tags:      If(payload.Tags != nil, payload.Tags, post.Tags),
+		Tags:      slices.Clone(If(payload.Tags != nil, payload.Tags, post.Tags)),
 		ID:        post.ID,
 		UpdatedAt: post.UpdatedAt,
 	}
 
-	updatedPost, err := app.store.UpdatePostById(ctx, *updatePost)
+	// the merged post is checked, so an edit cannot sneak in what a new
+	// post would have been held for
+	fields := []*string{&updatePost.Title, &updatePost.Content}
+	for i := range updatePost.Tags {
+		fields = append(fields, &updatePost.Tags[i])
+	}
+	action, matched, ok := app.filterContent(w, r, fields...)
+	if !ok {
+		return
+	}
+
+	user := app.GetUserFromCtx(r)
+	held := action == filter.Hold
+
+	var updatedPost store.UpdatePostByIdRow
+	err := app.store.ExecTx(ctx, func(q *store.Queries) error {
+		var err error
+		updatedPost, err = q.UpdatePostById(ctx, *updatePost)
+		if err != nil {
+			return err
+		}
+
+		if held {
+			if err := holdForReview(ctx, q, reportTargetPost, post.ID, post.UserID, matched); err != nil {
+				return err
+			}
+		}
+
+		// staff acting on posts of others are held to account
+		if post.UserID == user.ID {
+			return nil
+		}
+
+		after := post
+		after.Title, after.Content, after.Tags = updatePost.Title, updatePost.Content, updatePost.Tags
+		after.UpdatedAt = updatedPost.UpdatedAt
+		return audit.Record(ctx, q, app.auditEntry(r, audit.PostUpdate, "post", post.ID.String(), post, after))
+	})
 	if err != nil {
 		app.internalServerError(w, r, err)
 		return
 	}
 
-	if err := app.jsonResponse(w, http.StatusOK, updatedPost); err != nil {
+	// readers of the old tags must lose the post as well
+	app.invalidatePost(ctx, post.ID)
+	app.invalidatePostFeeds(post.UserID, append(slices.Clone(post.Tags), updatePost.Tags...))
+
+	if err := app.jsonResponse(w, If(held, http.StatusAccepted, http.StatusOK), updatedPost); err != nil {
 		app.internalServerError(w, r, err)
 		return
 	}
@@ -208,7 +353,11 @@ type CreateCommentPayload struct {
 //	@Param			postID	path		string	true	"Post ID"
 //	@Param			content	body		string	true	"Content Payload"
 //	@Success		200		{object}	store.CreateCommentRow
+//	@Success		202		{object}	store.CreateCommentRow	"Held for review"
 //	@Failure		400		{object}	error	"Bad Request"
+//	@Failure		409		{object}	error	"Duplicate content"
+//	@Failure		422		{object}	error	"Rejected by a content filter"
+//	@Failure		429		{object}	error	"Posting quota exceeded"
 //	@Failure		500		{object}	error	"Server encountered a problem"
 //	@Security		ApiKeyAuth
 //	@Router			/posts/{postID}/comments [post]
@@ -232,23 +381,95 @@ func (app *application) createCommentHandler(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
+	action, matched, ok := app.filterContent(w, r, &payload.Content)
+	if !ok {
+		return
+	}
+
 	ctx := r.Context()
 
 	user := app.GetUserFromCtx(r)
 
+	post, err := app.store.GetPostsById(ctx, postID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			app.recordNotFoundResponse(w, r, err)
+			return
+		}
+		app.internalServerError(w, r, err)
+		return
+	}
+	if post.HiddenAt.Valid {
+		app.recordNotFoundResponse(w, r, errors.New("post was hidden by a moderator"))
+		return
+	}
+
+	canView, err := app.canViewPosts(ctx, &user, post.UserID)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+	if !canView {
+		app.recordNotFoundResponse(w, r, errors.New("post author is private"))
+		return
+	}
+
 	createComment := &store.CreateCommentParams{
 		PostID:  postID,
 		UserID:  user.ID,
 		Content: payload.Content,
 	}
 
+	held := action == filter.Hold
+
 	var comment store.CreateCommentRow
-	comment, err = app.store.CreateComment(ctx, *createComment)
+	err = app.store.ExecTx(ctx, func(q *store.Queries) error {
+		// short replies are often the same between users, so comments are
+		// only compared with the user's own
+		err := app.checkPosting(ctx, q, &user, postingComments, func(since time.Time) (bool, error) {
+			return q.RecentCommentWithContentExists(ctx, store.RecentCommentWithContentExistsParams{
+				UserID:  user.ID,
+				Content: payload.Content,
+				Since:   since,
+			})
+		})
+		if err != nil {
+			return err
+		}
+
+		if comment, err = q.CreateComment(ctx, *createComment); err != nil {
+			return err
+		}
+		if !held {
+			return nil
+		}
+		return holdForReview(ctx, q, reportTargetComment, comment.ID, user.ID, matched)
+	})
+	if app.postingRefused(w, r, err) {
+		return
+	}
 	if err != nil {
 		app.internalServerError(w, r, err)
 		return
 	}
 
+	if held {
+		if err := app.jsonResponse(w, http.StatusAccepted, comment); err != nil {
+			app.internalServerError(w, r, err)
+		}
+		return
+	}
+
+	app.invalidatePost(ctx, postID)
+	app.publishComment(post.UserID, CommentEvent{
+		ID:        comment.ID,
+		PostID:    postID,
+		Content:   payload.Content,
+		UserID:    user.ID,
+		Username:  user.Username,
+		CreatedAt: comment.CreatedAt,
+	})
+
 	if err := app.jsonResponse(w, http.StatusCreated, comment); err != nil {
 		app.internalServerError(w, r, err)
 		return
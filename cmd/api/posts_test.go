@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/JaskiratAnand/go-social/internal/filter"
+	"github.com/JaskiratAnand/go-social/internal/store"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+func TestUpdatePostFilter(t *testing.T) {
+	f, err := filter.New([]filter.Rule{
+		{ID: "r1", Kind: filter.Word, Pattern: "casino", Action: filter.Hold},
+		{ID: "r2", Kind: filter.Word, Pattern: "scam", Action: filter.Reject},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	author := store.Users{ID: uuid.New(), Username: "alice"}
+	post := store.Posts{
+		ID:        uuid.New(),
+		Title:     "hello",
+		Content:   "best casino in town",
+		Tags:      []string{"news"},
+		UserID:    author.ID,
+		UpdatedAt: time.Now(),
+	}
+
+	update := func(app *application, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPatch, "/v1/posts/"+post.ID.String(), strings.NewReader(body))
+		ctx := context.WithValue(req.Context(), userCtx, author)
+		ctx = context.WithValue(ctx, postCtx, post)
+		return executeRequest(req.WithContext(ctx), http.HandlerFunc(app.updatePostHandler))
+	}
+
+	t.Run("rejected edits are not stored", func(t *testing.T) {
+		app := TestMockApplication(t, config{})
+		app.contentFilter.Store(f)
+
+		rr := update(app, `{"tags":["scam"]}`)
+		checkResponseCode(t, http.StatusUnprocessableEntity, rr.Code)
+	})
+
+	t.Run("held content in the merged post is held", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer db.Close()
+
+		app := TestMockApplication(t, config{})
+		app.store = store.New(db)
+		app.contentFilter.Store(f)
+
+		// only the title changes, the held word is in the old content
+		mock.ExpectBegin()
+		mock.ExpectQuery("UPDATE posts").
+			WithArgs("hi there", post.Content, sqlmock.AnyArg(), post.ID, post.UpdatedAt).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "updated_at"}).AddRow(post.ID, time.Now()))
+		mock.ExpectExec("UPDATE posts SET hidden_at").
+			WithArgs(post.ID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery("INSERT INTO reports").
+			WithArgs(sqlmock.AnyArg(), reportTargetPost, post.ID, author.ID, reportReasonFilter, "matched filter rules r1").
+			WillReturnRows(sqlmock.NewRows([]string{
+				"id", "reporter_id", "target_type", "target_id", "target_user_id", "reason", "details",
+				"status", "moderator_id", "actions", "note", "created_at", "claimed_at", "closed_at",
+			}).AddRow(uuid.New(), nil, reportTargetPost, post.ID, author.ID, reportReasonFilter, "", "open", nil, "{}", "", time.Now(), nil, nil))
+		mock.ExpectCommit()
+
+		rr := update(app, `{"title":"hi there"}`)
+		checkResponseCode(t, http.StatusAccepted, rr.Code)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+}
+
+func TestGetPostBannedAuthor(t *testing.T) {
+	for _, c := range []struct {
+		name   string
+		banned bool
+		want   int
+	}{
+		{"posts are served from the cache", false, http.StatusOK},
+		{"cached posts of banned authors are gone", true, http.StatusNotFound},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			app := TestMockApplication(t, config{})
+			ctx := context.Background()
+
+			author := store.Users{ID: uuid.New(), Username: "alice"}
+			if c.banned {
+				author.BannedAt = sql.NullTime{Time: time.Now(), Valid: true}
+			}
+			postID := uuid.New()
+
+			// the post was cached before the ban, the author is read again
+			// after it
+			app.cacheStorage.Posts.Set(ctx, postID, &store.GetPostWithCommentsByIdRow{Title: "hi", UserID: author.ID})
+			app.cacheStorage.Users.Set(ctx, &author)
+
+			req := httptest.NewRequest(http.MethodGet, "/v1/posts/"+postID.String(), nil)
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("postID", postID.String())
+			reqCtx := context.WithValue(req.Context(), chi.RouteCtxKey, rctx)
+			reqCtx = context.WithValue(reqCtx, userCtx, author)
+
+			rr := executeRequest(req.WithContext(reqCtx), http.HandlerFunc(app.getPostHandler))
+			checkResponseCode(t, c.want, rr.Code)
+		})
+	}
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/JaskiratAnand/go-social/internal/ratelimiter"
+	"github.com/go-chi/chi/v5"
+)
+
+// defaultRateLimitPolicies keep logins and sign ups from sharing the
+// budget of regular reads. RATE_LIMITER_POLICIES replaces them.
+var defaultRateLimitPolicies = []ratelimiter.Policy{
+	{Name: "admins", Roles: []string{"ADMIN"}, Exempt: true},
+	{Name: "login", Routes: []string{"POST /v1/auth/token"}, Limit: 10, Window: time.Minute},
+	{Name: "register", Routes: []string{"POST /v1/auth/user"}, Limit: 5, Window: time.Hour},
+	{Name: "create-post", Routes: []string{"POST /v1/posts/"}, Limit: 30, Window: time.Minute},
+}
+
+// rateLimitPolicies reads policies from a JSON array, exempting the
+// internal networks ahead of them.
+func rateLimitPolicies(policiesJSON, internalNetworks string) ([]ratelimiter.Policy, error) {
+	policies := defaultRateLimitPolicies
+	if policiesJSON != "" {
+		policies = nil
+		if err := json.Unmarshal([]byte(policiesJSON), &policies); err != nil {
+			return nil, fmt.Errorf("invalid RATE_LIMITER_POLICIES: %w", err)
+		}
+	}
+
+	if internalNetworks != "" {
+		internal := ratelimiter.Policy{
+			Name:     "internal",
+			Networks: strings.Split(internalNetworks, ","),
+			Exempt:   true,
+		}
+		policies = append([]ratelimiter.Policy{internal}, policies...)
+	}
+
+	return policies, nil
+}
+
+// rateLimitClient describes r for policy matching, along with the user of
+// a valid bearer token so AuthTokenMiddleware does not resolve it again.
+// An invalid token is not an error here; the request is limited by address
+// and rejected later by AuthTokenMiddleware.
+func (app *application) rateLimitClient(r *http.Request, routes chi.Routes) (ratelimiter.Client, *bearerUser) {
+	c := ratelimiter.Client{IP: remoteIP(r)}
+
+	if pattern := routes.Find(chi.NewRouteContext(), r.Method, r.URL.Path); pattern != "" {
+		c.Route = r.Method + " " + pattern
+	}
+
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return c, nil
+	}
+
+	user, err := app.tokenUser(r.Context(), token)
+	if err != nil {
+		return c, nil
+	}
+
+	c.UserID = user.ID.String()
+	c.Role = app.roleNames[user.RoleID]
+	return c, &bearerUser{token: token, user: user}
+}
+
+// parseNetworks reads a comma separated list of CIDRs. Single addresses
+// are taken as networks of one.
+func parseNetworks(s string) ([]netip.Prefix, error) {
+	var networks []netip.Prefix
+	for _, cidr := range strings.Split(s, ",") {
+		cidr = strings.TrimSpace(cidr)
+		if cidr == "" {
+			continue
+		}
+
+		n, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			ip, ipErr := netip.ParseAddr(cidr)
+			if ipErr != nil {
+				return nil, fmt.Errorf("invalid network %q: %w", cidr, err)
+			}
+			n = netip.PrefixFrom(ip.Unmap(), ip.Unmap().BitLen())
+		}
+		networks = append(networks, n.Masked())
+	}
+	return networks, nil
+}
+
+func (app *application) trustedProxy(ip netip.Addr) bool {
+	for _, n := range app.config.trustedProxies {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// forwardedIP returns the client address of a request from a trusted
+// proxy. X-Forwarded-For is read from the right, skipping our own proxies,
+// since anything left of them was written by the client.
+func (app *application) forwardedIP(r *http.Request) (netip.Addr, bool) {
+	var client netip.Addr
+
+	hops := strings.Split(r.Header.Get("X-Forwarded-For"), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		ip, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			break
+		}
+		client = ip.Unmap()
+		if !app.trustedProxy(client) {
+			return client, true
+		}
+	}
+	if client.IsValid() {
+		return client, true
+	}
+
+	if ip, err := netip.ParseAddr(strings.TrimSpace(r.Header.Get("X-Real-IP"))); err == nil {
+		return ip.Unmap(), true
+	}
+	return netip.Addr{}, false
+}
+
+// remoteIP reads the address set by RealIPMiddleware, which only has a
+// port when it is the address of the connection.
+func remoteIP(r *http.Request) netip.Addr {
+	host := r.RemoteAddr
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+
+	ip, _ := netip.ParseAddr(host)
+	return ip.Unmap()
+}
+
+// setRateLimitHeaders writes the RateLimit headers of the IETF draft, with
+// the reset in whole seconds.
+func setRateLimitHeaders(w http.ResponseWriter, res ratelimiter.Result) {
+	w.Header().Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+	w.Header().Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+	w.Header().Set("RateLimit-Reset", strconv.Itoa(int(math.Ceil(res.Reset.Seconds()))))
+}
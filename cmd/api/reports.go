@@ -0,0 +1,608 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/JaskiratAnand/go-social/internal/audit"
+	"github.com/JaskiratAnand/go-social/internal/store"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const (
+	reportTargetPost    = "post"
+	reportTargetComment = "comment"
+	reportTargetUser    = "user"
+
+	reportOpen      = "open"
+	reportClaimed   = "claimed"
+	reportResolved  = "resolved"
+	reportDismissed = "dismissed"
+
+	moderationHide    = "hide"
+	moderationWarn    = "warn"
+	moderationSuspend = "suspend"
+
+	defaultSuspension = 72 * time.Hour
+)
+
+var errReportTargetNotFound = errors.New("report target not found")
+
+type CreateReportPayload struct {
+	TargetType string    `json:"target_type" validate:"required,oneof=post comment user"`
+	TargetID   uuid.UUID `json:"target_id" validate:"required"`
+	Reason     string    `json:"reason" validate:"required,oneof=spam harassment hate violence sexual misinformation other"`
+	Details    string    `json:"details" validate:"max=1000"`
+}
+
+// CreateReport godoc
+//
+//	@Summary		Report content
+//	@Description	Flags a post, comment or user for the moderators.
+//	@Tags			reports
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		CreateReportPayload	true	"Report"
+//	@Success		201		{object}	store.Reports
+//	@Failure		400		{object}	error	"Bad Request"
+//	@Failure		404		{object}	error	"Record Not Found"
+//	@Failure		409		{object}	error	"Already reported"
+//	@Failure		500		{object}	error	"Server encountered a problem"
+//	@Security		ApiKeyAuth
+//	@Router			/reports [post]
+func (app *application) createReportHandler(w http.ResponseWriter, r *http.Request) {
+	var payload CreateReportPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	ctx := r.Context()
+	user := app.GetUserFromCtx(r)
+
+	targetUserID, err := app.reportTargetOwner(ctx, &user, payload.TargetType, payload.TargetID)
+	if err != nil {
+		if errors.Is(err, errReportTargetNotFound) {
+			app.recordNotFoundResponse(w, r, err)
+			return
+		}
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if targetUserID == user.ID {
+		app.badRequestResponse(w, r, errors.New("you cannot report yourself"))
+		return
+	}
+
+	report, err := app.store.CreateReport(ctx, store.CreateReportParams{
+		ReporterID:   uuid.NullUUID{UUID: user.ID, Valid: true},
+		TargetType:   payload.TargetType,
+		TargetID:     payload.TargetID,
+		TargetUserID: targetUserID,
+		Reason:       payload.Reason,
+		Details:      payload.Details,
+	})
+	if err != nil {
+		if isUniqueViolation(err) {
+			app.customErrorResponse(w, r, http.StatusConflict, "you already reported this "+payload.TargetType)
+			return
+		}
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusCreated, report); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+// reportTargetOwner returns the user behind a report target. Content the
+// reporter cannot see is reported as missing, like everywhere else.
+func (app *application) reportTargetOwner(ctx context.Context, reporter *store.Users, targetType string, targetID uuid.UUID) (uuid.UUID, error) {
+	switch targetType {
+	case reportTargetUser:
+		target, err := app.getUser(ctx, targetID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return uuid.Nil, errReportTargetNotFound
+			}
+			return uuid.Nil, err
+		}
+		return target.ID, nil
+
+	case reportTargetComment:
+		comment, err := app.store.GetCommentById(ctx, targetID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return uuid.Nil, errReportTargetNotFound
+			}
+			return uuid.Nil, err
+		}
+		if comment.HiddenAt.Valid {
+			return uuid.Nil, errReportTargetNotFound
+		}
+
+		if _, err := app.visiblePostOwner(ctx, reporter, comment.PostID); err != nil {
+			return uuid.Nil, err
+		}
+		return comment.UserID, nil
+	}
+
+	return app.visiblePostOwner(ctx, reporter, targetID)
+}
+
+// visiblePostOwner returns the author of a post the viewer can see.
+func (app *application) visiblePostOwner(ctx context.Context, viewer *store.Users, postID uuid.UUID) (uuid.UUID, error) {
+	post, err := app.store.GetPostsById(ctx, postID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return uuid.Nil, errReportTargetNotFound
+		}
+		return uuid.Nil, err
+	}
+	if post.HiddenAt.Valid {
+		return uuid.Nil, errReportTargetNotFound
+	}
+
+	canView, err := app.canViewPosts(ctx, viewer, post.UserID)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if !canView {
+		return uuid.Nil, errReportTargetNotFound
+	}
+
+	return post.UserID, nil
+}
+
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
+
+// GetReports godoc
+//
+//	@Summary		Fetches the moderation queue
+//	@Description	Lists reports oldest first, with the number of pending reports on the same target.
+//	@Tags			moderation
+//	@Produce		json
+//	@Param			status		query		string	false	"Comma separated statuses, open,claimed by default"
+//	@Param			target_type	query		string	false	"post, comment or user"
+//	@Param			mine		query		bool	false	"Only reports handled by the caller"
+//	@Param			limit		query		int		false	"Limit"
+//	@Param			offset		query		int		false	"Offset"
+//	@Success		200			{object}	[]store.GetReportsRow
+//	@Failure		400			{object}	error	"Bad Request"
+//	@Failure		403			{object}	error	"Forbidden"
+//	@Failure		500			{object}	error	"Server encountered a problem"
+//	@Security		ApiKeyAuth
+//	@Router			/moderation/reports [get]
+func (app *application) getReportsHandler(w http.ResponseWriter, r *http.Request) {
+	qs := r.URL.Query()
+
+	params := store.GetReportsParams{
+		Statuses: []string{reportOpen, reportClaimed},
+		Limit:    20,
+	}
+
+	if status := qs.Get("status"); status != "" {
+		params.Statuses = strings.Split(status, ",")
+		for _, s := range params.Statuses {
+			if !slices.Contains([]string{reportOpen, reportClaimed, reportResolved, reportDismissed}, s) {
+				app.badRequestResponse(w, r, fmt.Errorf("invalid status %q", s))
+				return
+			}
+		}
+	}
+
+	if targetType := qs.Get("target_type"); targetType != "" {
+		if !slices.Contains([]string{reportTargetPost, reportTargetComment, reportTargetUser}, targetType) {
+			app.badRequestResponse(w, r, fmt.Errorf("invalid target_type %q", targetType))
+			return
+		}
+		params.TargetType = sql.NullString{String: targetType, Valid: true}
+	}
+
+	if qs.Get("mine") == "true" {
+		user := app.GetUserFromCtx(r)
+		params.ModeratorID = uuid.NullUUID{UUID: user.ID, Valid: true}
+	}
+
+	for name, dst := range map[string]*int64{"limit": &params.Limit, "offset": &params.Offset} {
+		v := qs.Get(name)
+		if v == "" {
+			continue
+		}
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n < 0 {
+			app.badRequestResponse(w, r, fmt.Errorf("invalid %s %q", name, v))
+			return
+		}
+		*dst = n
+	}
+	params.Limit = min(max(params.Limit, 1), 100)
+
+	reports, err := app.store.GetReports(r.Context(), params)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, reports); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+// ClaimReport godoc
+//
+//	@Summary		Claims a report
+//	@Description	Assigns an open report to the caller so other moderators skip it.
+//	@Tags			moderation
+//	@Produce		json
+//	@Param			reportID	path		string	true	"Report ID"
+//	@Success		200			{object}	store.Reports
+//	@Failure		403			{object}	error	"Forbidden"
+//	@Failure		404			{object}	error	"Record Not Found"
+//	@Failure		409			{object}	error	"Closed or claimed by another moderator"
+//	@Failure		500			{object}	error	"Server encountered a problem"
+//	@Security		ApiKeyAuth
+//	@Router			/moderation/reports/{reportID}/claim [put]
+func (app *application) claimReportHandler(w http.ResponseWriter, r *http.Request) {
+	report, ok := app.reportFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	ctx := r.Context()
+	user := app.GetUserFromCtx(r)
+
+	var claimed store.Reports
+	err := app.store.ExecTx(ctx, func(q *store.Queries) error {
+		var err error
+		claimed, err = q.ClaimReport(ctx, store.ClaimReportParams{
+			ModeratorID: user.ID,
+			ID:          report.ID,
+		})
+		if err != nil {
+			return err
+		}
+		return audit.Record(ctx, q, app.auditEntry(r, audit.ReportClaim, "report", report.ID.String(), report, claimed))
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			app.customErrorResponse(w, r, http.StatusConflict, "report is closed or claimed by another moderator")
+			return
+		}
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, claimed); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+type ResolveReportPayload struct {
+	Actions      []string `json:"actions" validate:"required,min=1,unique,dive,oneof=hide warn suspend"`
+	Note         string   `json:"note" validate:"max=1000"`
+	SuspendHours int      `json:"suspend_hours" validate:"omitempty,gte=1,lte=8760"`
+}
+
+// ResolveReport godoc
+//
+//	@Summary		Resolves a report
+//	@Description	Applies the actions and closes the report, together with the other pending reports on its target.
+//	@Description	hide applies to posts and comments, warn and suspend to the user behind the target.
+//	@Tags			moderation
+//	@Accept			json
+//	@Produce		json
+//	@Param			reportID	path		string					true	"Report ID"
+//	@Param			payload		body		ResolveReportPayload	true	"Actions"
+//	@Success		200			{object}	store.Reports
+//	@Failure		400			{object}	error	"Bad Request"
+//	@Failure		403			{object}	error	"Forbidden"
+//	@Failure		404			{object}	error	"Record Not Found"
+//	@Failure		409			{object}	error	"Closed or claimed by another moderator"
+//	@Failure		500			{object}	error	"Server encountered a problem"
+//	@Security		ApiKeyAuth
+//	@Router			/moderation/reports/{reportID}/resolve [put]
+func (app *application) resolveReportHandler(w http.ResponseWriter, r *http.Request) {
+	var payload ResolveReportPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	report, ok := app.reportFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	if report.TargetType == reportTargetUser && slices.Contains(payload.Actions, moderationHide) {
+		app.badRequestResponse(w, r, errors.New("users cannot be hidden, suspend them instead"))
+		return
+	}
+
+	ctx := r.Context()
+	moderator := app.GetUserFromCtx(r)
+
+	isAdmin, err := app.checkRolePrecedence(ctx, &moderator, "ADMIN")
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	// only admins sanction other staff
+	var target *store.Users
+	if slices.Contains(payload.Actions, moderationWarn) || slices.Contains(payload.Actions, moderationSuspend) {
+		target, err = app.getUser(ctx, report.TargetUserID)
+		if err != nil {
+			app.internalServerError(w, r, err)
+			return
+		}
+
+		isStaff, err := app.checkRolePrecedence(ctx, target, "MODERATOR")
+		if err != nil {
+			app.internalServerError(w, r, err)
+			return
+		}
+		if isStaff && !isAdmin {
+			app.forbiddenResponse(w, r)
+			return
+		}
+	}
+
+	suspension := defaultSuspension
+	if payload.SuspendHours > 0 {
+		suspension = time.Duration(payload.SuspendHours) * time.Hour
+	}
+	suspendedUntil := time.Now().Add(suspension)
+	reason := If(payload.Note != "", payload.Note, report.Reason)
+
+	// the hidden post is needed afterwards to drop it from cached feeds
+	var hiddenPost *store.Posts
+	var hiddenCommentPost uuid.UUID
+
+	err = app.store.ExecTx(ctx, func(q *store.Queries) error {
+		before := report
+		closed, err := q.CloseReport(ctx, store.CloseReportParams{
+			Status:      reportResolved,
+			Actions:     payload.Actions,
+			Note:        payload.Note,
+			ModeratorID: moderator.ID,
+			ID:          report.ID,
+			Force:       isAdmin,
+		})
+		if err != nil {
+			return err
+		}
+		report = closed
+
+		if err := audit.Record(ctx, q, app.auditEntry(r, audit.ReportResolve, "report", report.ID.String(), before, report)); err != nil {
+			return err
+		}
+
+		for _, action := range payload.Actions {
+			switch action {
+			case moderationHide:
+				if report.TargetType == reportTargetComment {
+					comment, err := q.GetCommentById(ctx, report.TargetID)
+					if err != nil {
+						return err
+					}
+					hiddenCommentPost = comment.PostID
+
+					if err := q.HideComment(ctx, report.TargetID); err != nil {
+						return err
+					}
+					continue
+				}
+
+				post, err := q.GetPostsById(ctx, report.TargetID)
+				if err != nil {
+					return err
+				}
+				hiddenPost = &post
+
+				if err := q.HidePost(ctx, report.TargetID); err != nil {
+					return err
+				}
+
+			case moderationWarn:
+				if err := q.CreateUserWarning(ctx, store.CreateUserWarningParams{
+					UserID:      report.TargetUserID,
+					ReportID:    uuid.NullUUID{UUID: report.ID, Valid: true},
+					ModeratorID: uuid.NullUUID{UUID: moderator.ID, Valid: true},
+					Reason:      reason,
+				}); err != nil {
+					return err
+				}
+
+			case moderationSuspend:
+				if err := q.SuspendUser(ctx, store.SuspendUserParams{
+					ID:               report.TargetUserID,
+					SuspendedUntil:   sql.NullTime{Time: suspendedUntil, Valid: true},
+					SuspensionReason: reason,
+				}); err != nil {
+					return err
+				}
+
+				after := *target
+				after.SuspendedUntil = sql.NullTime{Time: suspendedUntil, Valid: true}
+				after.SuspensionReason = reason
+				if err := audit.Record(ctx, q, app.auditEntry(r, audit.UserSuspend, "user", target.ID.String(), sanctionsOf(*target), sanctionsOf(after))); err != nil {
+					return err
+				}
+			}
+		}
+
+		return q.CloseTargetReports(ctx, store.CloseTargetReportsParams{
+			Actions:     payload.Actions,
+			Note:        "resolved with report " + report.ID.String(),
+			ModeratorID: moderator.ID,
+			TargetType:  report.TargetType,
+			TargetID:    report.TargetID,
+			ReportID:    report.ID,
+		})
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			app.customErrorResponse(w, r, http.StatusConflict, "report is closed or claimed by another moderator")
+			return
+		}
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if hiddenPost != nil {
+		app.invalidatePost(ctx, hiddenPost.ID)
+		app.invalidatePostFeeds(hiddenPost.UserID, hiddenPost.Tags)
+	}
+	if hiddenCommentPost != uuid.Nil {
+		app.invalidatePost(ctx, hiddenCommentPost)
+	}
+	if slices.Contains(payload.Actions, moderationSuspend) {
+		app.applySuspension(ctx, *target, reason, suspendedUntil)
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, report); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+type DismissReportPayload struct {
+	Note string `json:"note" validate:"max=1000"`
+}
+
+// DismissReport godoc
+//
+//	@Summary		Dismisses a report
+//	@Description	Closes a report without taking action. Dismissing a report of a content filter approves the held content.
+//	@Tags			moderation
+//	@Accept			json
+//	@Produce		json
+//	@Param			reportID	path		string					true	"Report ID"
+//	@Param			payload		body		DismissReportPayload	false	"Note"
+//	@Success		200			{object}	store.Reports
+//	@Failure		400			{object}	error	"Bad Request"
+//	@Failure		403			{object}	error	"Forbidden"
+//	@Failure		404			{object}	error	"Record Not Found"
+//	@Failure		409			{object}	error	"Closed or claimed by another moderator"
+//	@Failure		500			{object}	error	"Server encountered a problem"
+//	@Security		ApiKeyAuth
+//	@Router			/moderation/reports/{reportID}/dismiss [put]
+func (app *application) dismissReportHandler(w http.ResponseWriter, r *http.Request) {
+	var payload DismissReportPayload
+	if r.ContentLength != 0 {
+		if err := readJSON(w, r, &payload); err != nil {
+			app.badRequestResponse(w, r, err)
+			return
+		}
+		if err := Validate.Struct(payload); err != nil {
+			app.badRequestResponse(w, r, err)
+			return
+		}
+	}
+
+	report, ok := app.reportFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	ctx := r.Context()
+	moderator := app.GetUserFromCtx(r)
+
+	isAdmin, err := app.checkRolePrecedence(ctx, &moderator, "ADMIN")
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	var dismissed store.Reports
+	var approved *heldContent
+	err = app.store.ExecTx(ctx, func(q *store.Queries) error {
+		var err error
+		dismissed, err = q.CloseReport(ctx, store.CloseReportParams{
+			Status:      reportDismissed,
+			Actions:     []string{},
+			Note:        payload.Note,
+			ModeratorID: moderator.ID,
+			ID:          report.ID,
+			Force:       isAdmin,
+		})
+		if err != nil {
+			return err
+		}
+		if err := audit.Record(ctx, q, app.auditEntry(r, audit.ReportDismiss, "report", report.ID.String(), report, dismissed)); err != nil {
+			return err
+		}
+
+		if report.Reason == reportReasonFilter {
+			approved, err = releaseHeld(ctx, q, report)
+		}
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			app.customErrorResponse(w, r, http.StatusConflict, "report is closed or claimed by another moderator")
+			return
+		}
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if approved != nil {
+		app.publishHeld(ctx, approved)
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, dismissed); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+// reportFromURL loads the report named by the reportID param, writing the
+// response when it cannot.
+func (app *application) reportFromURL(w http.ResponseWriter, r *http.Request) (store.Reports, bool) {
+	reportID, err := uuid.Parse(chi.URLParam(r, "reportID"))
+	if err != nil {
+		app.badRequestResponse(w, r, errors.New("invalid report-id"))
+		return store.Reports{}, false
+	}
+
+	report, err := app.store.GetReportById(r.Context(), reportID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			app.recordNotFoundResponse(w, r, err)
+			return report, false
+		}
+		app.internalServerError(w, r, err)
+		return report, false
+	}
+
+	return report, true
+}
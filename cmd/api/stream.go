@@ -0,0 +1,206 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/JaskiratAnand/go-social/internal/realtime"
+	"github.com/JaskiratAnand/go-social/internal/store"
+	"github.com/google/uuid"
+)
+
+// feedStreamPath is exempt from the request timeouts, see isLongLived.
+const feedStreamPath = "/v1/users/feed/stream"
+
+// streamHeartbeat keeps idle streams from being closed by proxies.
+var streamHeartbeat = 15 * time.Second
+
+const (
+	streamRetry = 3 * time.Second
+	// streamDeadlineMargin ends a stream ahead of any deadline on its
+	// context so it closes cleanly and the client reconnects with
+	// Last-Event-ID.
+	streamDeadlineMargin = 2 * time.Second
+	publishTimeout       = 10 * time.Second
+)
+
+type PostEvent struct {
+	ID        uuid.UUID `json:"id"`
+	Title     string    `json:"title"`
+	Tags      []string  `json:"tags"`
+	UserID    uuid.UUID `json:"user_id"`
+	Username  string    `json:"username"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type CommentEvent struct {
+	ID        uuid.UUID `json:"id"`
+	PostID    uuid.UUID `json:"post_id"`
+	Content   string    `json:"content"`
+	UserID    uuid.UUID `json:"user_id"`
+	Username  string    `json:"username"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type FollowEvent struct {
+	UserID   uuid.UUID `json:"user_id"`
+	Username string    `json:"username"`
+}
+
+// StreamFeed godoc
+//
+//	@Summary		Streams feed updates
+//	@Description	Streams new posts from followed users, new comments on the caller's posts and new followers as Server-Sent Events.
+//	@Description	Reconnecting clients send Last-Event-ID (or last_event_id) to receive the events they missed.
+//	@Tags			feed
+//	@Produce		text/event-stream
+//	@Param			Last-Event-ID	header		string	false	"Id of the last event received"
+//	@Param			last_event_id	query		string	false	"Id of the last event received"
+//	@Success		200				{string}	string	"Event stream"
+//	@Failure		400				{object}	error	"Bad Request"
+//	@Security		ApiKeyAuth
+//	@Router			/users/feed/stream [get]
+func (app *application) streamFeedHandler(w http.ResponseWriter, r *http.Request) {
+	lastEventID, err := readLastEventID(r)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	user := app.GetUserFromCtx(r)
+
+	rc := http.NewResponseController(w)
+
+	// the stream outlives the server write timeout
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	sub := app.hub.Subscribe()
+	defer sub.Close()
+
+	missed := sub.Add(lastEventID, realtime.FeedTopic(user.ID), realtime.NotificationsTopic(user.ID), realtime.UserTopic(user.ID))
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	fmt.Fprintf(w, "retry: %d\n\n", streamRetry.Milliseconds())
+	for _, e := range missed {
+		writeEvent(w, e)
+	}
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	ctx := r.Context()
+
+	var deadline <-chan time.Time
+	if d, ok := ctx.Deadline(); ok {
+		timer := time.NewTimer(time.Until(d) - streamDeadlineMargin)
+		defer timer.Stop()
+		deadline = timer.C
+	}
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-deadline:
+			return
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": ping\n\n")
+		case e, ok := <-sub.C:
+			if !ok {
+				return
+			}
+			writeEvent(w, e)
+		}
+
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+func readLastEventID(r *http.Request) (int64, error) {
+	s := r.Header.Get("Last-Event-ID")
+	if s == "" {
+		s = r.URL.Query().Get("last_event_id")
+	}
+	if s == "" {
+		return 0, nil
+	}
+
+	id, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || id < 0 {
+		return 0, errors.New("invalid last event id")
+	}
+
+	return id, nil
+}
+
+func writeEvent(w http.ResponseWriter, e realtime.Event) {
+	fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.ID, e.Type, e.Data)
+}
+
+// publish sends an event in the background so slow backends never delay
+// the response. Failures only cost connected clients a live update.
+func (app *application) publish(typ string, data any, topics func(context.Context) ([]string, error)) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), publishTimeout)
+		defer cancel()
+
+		t, err := topics(ctx)
+		if err == nil {
+			err = app.hub.Publish(ctx, t, typ, data)
+		}
+		if err != nil {
+			app.logger.Warnw("error publishing event", "type", typ, "error", err.Error())
+		}
+	}()
+}
+
+func (app *application) publishPost(author *store.Users, event PostEvent) {
+	app.publish(realtime.EventPost, event, func(ctx context.Context) ([]string, error) {
+		followers, err := app.store.GetFollowerIds(ctx, author.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		topics := make([]string, len(followers))
+		for i, id := range followers {
+			topics[i] = realtime.FeedTopic(id)
+		}
+		return topics, nil
+	})
+}
+
+// publishComment notifies readers of the post and its author, unless the
+// author commented on their own post.
+func (app *application) publishComment(postOwnerID uuid.UUID, event CommentEvent) {
+	topics := []string{realtime.PostTopic(event.PostID)}
+	if postOwnerID != event.UserID {
+		topics = append(topics, realtime.NotificationsTopic(postOwnerID))
+	}
+
+	app.publish(realtime.EventComment, event, func(context.Context) ([]string, error) {
+		return topics, nil
+	})
+}
+
+func (app *application) publishFollow(followID uuid.UUID, event FollowEvent) {
+	app.publish(realtime.EventFollow, event, func(context.Context) ([]string, error) {
+		return []string{realtime.NotificationsTopic(followID)}, nil
+	})
+}
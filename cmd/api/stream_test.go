@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/JaskiratAnand/go-social/internal/store"
+	"github.com/google/uuid"
+)
+
+func TestStreamFeedOutlivesRequestTimeouts(t *testing.T) {
+	app := TestMockApplication(t, config{})
+
+	heartbeat := streamHeartbeat
+	streamHeartbeat = 50 * time.Millisecond
+	t.Cleanup(func() { streamHeartbeat = heartbeat })
+
+	deadlines := make(chan bool, 1)
+	stream := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, ok := r.Context().Deadline()
+		deadlines <- ok
+
+		ctx := context.WithValue(r.Context(), userCtx, store.Users{ID: uuid.New()})
+		app.streamFeedHandler(w, r.WithContext(ctx))
+	})
+
+	// the request timeout is shorter than the heartbeat interval
+	handler := app.ContextMiddlware()(app.TimeoutMiddleware(20 * time.Millisecond)(stream))
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+feedStreamPath, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	if <-deadlines {
+		t.Error("expected the stream context to have no deadline")
+	}
+
+	start := time.Now()
+	pings := 0
+	scanner := bufio.NewScanner(res.Body)
+	for pings < 3 && scanner.Scan() {
+		if strings.HasPrefix(scanner.Text(), ": ping") {
+			pings++
+		}
+	}
+	if pings < 3 {
+		t.Fatalf("expected the stream to stay open for 3 heartbeats; closed after %d (%v)", pings, scanner.Err())
+	}
+	if elapsed := time.Since(start); elapsed < 3*streamHeartbeat/2 {
+		t.Errorf("expected heartbeats to be spaced out; got 3 in %v", elapsed)
+	}
+}
+
+func TestDisconnectUserClosesStream(t *testing.T) {
+	app := TestMockApplication(t, config{})
+	user := store.Users{ID: uuid.New()}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), userCtx, user)
+		app.streamFeedHandler(w, r.WithContext(ctx))
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+feedStreamPath, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	// the retry line is written once the stream is subscribed
+	r := bufio.NewReader(res.Body)
+	if line, err := r.ReadString('\n'); err != nil || !strings.HasPrefix(line, "retry:") {
+		t.Fatalf("expected the stream to start; got %q, %v", line, err)
+	}
+
+	app.disconnectUser(ctx, user.ID)
+
+	if _, err := io.ReadAll(r); err != nil {
+		t.Fatalf("expected the stream to end; got %v", err)
+	}
+}
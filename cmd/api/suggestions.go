@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/JaskiratAnand/go-social/internal/store"
+	"github.com/google/uuid"
+)
+
+const (
+	maxSuggestions       = 50
+	suggestionsBatchSize = 100
+)
+
+// GetSuggestions godoc
+//
+//	@Summary		Fetches follow suggestions
+//	@Description	Fetches users to follow ranked by mutual follows, shared tags and popularity
+//	@Tags			users
+//	@Accept			json
+//	@Produce		json
+//	@Param			limit	query		int	false	"Limit"
+//	@Success		200		{object}	[]store.GetFollowSuggestionsRow
+//	@Failure		400		{object}	error	"Bad Request"
+//	@Failure		500		{object}	error	"Server encountered a problem"
+//	@Security		ApiKeyAuth
+//	@Router			/users/me/suggestions [get]
+func (app *application) getSuggestionsHandler(w http.ResponseWriter, r *http.Request) {
+	limit := 10
+	if l := r.URL.Query().Get("limit"); l != "" {
+		var err error
+		limit, err = strconv.Atoi(l)
+		if err != nil || limit < 1 || limit > maxSuggestions {
+			app.customErrorResponse(w, r, http.StatusBadRequest, "limit must be between 1 and 50")
+			return
+		}
+	}
+
+	ctx := r.Context()
+
+	user := app.GetUserFromCtx(r)
+
+	suggestions, err := app.getSuggestions(ctx, user.ID)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if len(suggestions) > limit {
+		suggestions = suggestions[:limit]
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, suggestions); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
+
+// getSuggestions serves suggestions from the cache, computing them on a miss
+// so users do not have to wait for the next run of the background job.
+func (app *application) getSuggestions(ctx context.Context, userID uuid.UUID) ([]store.GetFollowSuggestionsRow, error) {
+	suggestions, err := app.cacheStorage.Suggestions.Get(ctx, userID)
+	if err != nil {
+		app.logger.Warnw("error reading suggestions cache", "error", err.Error())
+	}
+	if suggestions != nil {
+		return suggestions, nil
+	}
+
+	return app.computeSuggestions(ctx, userID)
+}
+
+func (app *application) computeSuggestions(ctx context.Context, userID uuid.UUID) ([]store.GetFollowSuggestionsRow, error) {
+	suggestions, err := app.store.GetFollowSuggestions(ctx, store.GetFollowSuggestionsParams{
+		UserID: userID,
+		Limit:  maxSuggestions,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := app.cacheStorage.Suggestions.Set(ctx, userID, suggestions); err != nil {
+		return nil, err
+	}
+
+	return suggestions, nil
+}
+
+// refreshSuggestions recomputes the cached suggestions of every verified user.
+// Only listing the users fails the run, errors for single users are logged.
+func (app *application) refreshSuggestions(ctx context.Context) error {
+	for offset := int64(0); ; offset += suggestionsBatchSize {
+		userIDs, err := app.store.GetVerifiedUserIds(ctx, store.GetVerifiedUserIdsParams{
+			Limit:  suggestionsBatchSize,
+			Offset: offset,
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, userID := range userIDs {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+
+			queryCtx, cancel := context.WithTimeout(ctx, QueryTimeoutDuration)
+			_, err := app.computeSuggestions(queryCtx, userID)
+			cancel()
+			if err != nil {
+				// one user's failure should not leave everyone else stale,
+				// their cached suggestions are kept until the next run
+				app.logger.Warnw("error refreshing suggestions", "user", userID, "error", err.Error())
+				continue
+			}
+		}
+
+		if len(userIDs) < suggestionsBatchSize {
+			return nil
+		}
+	}
+}
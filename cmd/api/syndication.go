@@ -0,0 +1,200 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/JaskiratAnand/go-social/internal/store"
+	"github.com/JaskiratAnand/go-social/internal/syndication"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// syndicationItems is how many recent posts a feed document lists.
+const syndicationItems = 50
+
+// GetUserSyndicationFeed godoc
+//
+//	@Summary		Fetches a user's posts for feed readers
+//	@Description	Renders the latest public posts of a user as RSS, Atom or JSON Feed. Supports conditional GET with ETag and Last-Modified.
+//	@Tags			feed
+//	@Produce		xml
+//	@Produce		json
+//	@Param			username	path		string	true	"Username"
+//	@Param			format		path		string	true	"rss, atom or json"
+//	@Param			tag			query		string	false	"Only posts with this tag"
+//	@Success		200			{string}	string	"Feed document"
+//	@Success		304
+//	@Failure		404	{object}	error	"Record Not Found"
+//	@Failure		500	{object}	error	"Server encountered a problem"
+//	@Router			/users/{username}/feed.{format} [get]
+func (app *application) getUserSyndicationFeedHandler(w http.ResponseWriter, r *http.Request) {
+	format, ok := app.syndicationFormat(w, r)
+	if !ok {
+		return
+	}
+
+	ctx := r.Context()
+
+	user, err := app.store.GetUserByUsername(ctx, chi.URLParam(r, "username"))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			app.recordNotFoundResponse(w, r, err)
+			return
+		}
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	// private accounts are only readable by approved followers
+	if user.IsPrivate {
+		app.recordNotFoundResponse(w, r, errors.New("user is private"))
+		return
+	}
+	if user.BannedAt.Valid {
+		app.recordNotFoundResponse(w, r, errors.New("user is banned"))
+		return
+	}
+
+	tag := normalizeTag(r.URL.Query().Get("tag"))
+
+	posts, err := app.store.GetPostsByUserId(ctx, store.GetPostsByUserIdParams{
+		UserID: user.ID,
+		Tag:    sql.NullString{String: tag, Valid: tag != ""},
+		Limit:  syndicationItems,
+	})
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	feed := syndication.Feed{
+		Title:       user.Username,
+		Description: fmt.Sprintf("Posts by %s", user.Username),
+		Link:        fmt.Sprintf("%s/users/%s", app.config.frontendURL, user.Username),
+		FeedURL:     requestURL(r),
+		Author:      user.Username,
+		Updated:     user.CreatedAt,
+	}
+	if tag != "" {
+		feed.Title = fmt.Sprintf("%s #%s", user.Username, tag)
+		feed.Description = fmt.Sprintf("Posts by %s tagged #%s", user.Username, tag)
+	}
+
+	for _, post := range posts {
+		feed.Items = append(feed.Items, app.syndicationItem(post.ID, post.Title, post.Content, user.Username, post.Tags, post.CreatedAt, post.UpdatedAt))
+	}
+
+	app.serveSyndicationFeed(w, r, feed, format)
+}
+
+// GetTagSyndicationFeed godoc
+//
+//	@Summary		Fetches a tag's posts for feed readers
+//	@Description	Renders the latest public posts with a tag as RSS, Atom or JSON Feed. Supports conditional GET with ETag and Last-Modified.
+//	@Tags			tags
+//	@Produce		xml
+//	@Produce		json
+//	@Param			tag		path		string	true	"Tag"
+//	@Param			format	path		string	true	"rss, atom or json"
+//	@Success		200		{string}	string	"Feed document"
+//	@Success		304
+//	@Failure		400	{object}	error	"Bad Request"
+//	@Failure		404	{object}	error	"Record Not Found"
+//	@Failure		500	{object}	error	"Server encountered a problem"
+//	@Router			/tags/{tag}/feed.{format} [get]
+func (app *application) getTagSyndicationFeedHandler(w http.ResponseWriter, r *http.Request) {
+	format, ok := app.syndicationFormat(w, r)
+	if !ok {
+		return
+	}
+
+	tag, ok := app.tagFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	posts, err := app.store.GetPublicPostsByTag(r.Context(), store.GetPublicPostsByTagParams{
+		Tag:   tag,
+		Limit: syndicationItems,
+	})
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	feed := syndication.Feed{
+		Title:       "#" + tag,
+		Description: fmt.Sprintf("Public posts tagged #%s", tag),
+		Link:        fmt.Sprintf("%s/tags/%s", app.config.frontendURL, tag),
+		FeedURL:     requestURL(r),
+	}
+
+	for _, post := range posts {
+		feed.Items = append(feed.Items, app.syndicationItem(post.ID, post.Title, post.Content, post.Username, post.Tags, post.CreatedAt, post.UpdatedAt))
+	}
+
+	app.serveSyndicationFeed(w, r, feed, format)
+}
+
+func (app *application) syndicationFormat(w http.ResponseWriter, r *http.Request) (string, bool) {
+	format := chi.URLParam(r, "format")
+	if _, ok := syndication.ContentTypes[format]; !ok {
+		app.recordNotFoundResponse(w, r, fmt.Errorf("unknown feed format %q", format))
+		return "", false
+	}
+	return format, true
+}
+
+func (app *application) syndicationItem(id uuid.UUID, title, content, author string, tags []string, createdAt, updatedAt time.Time) syndication.Item {
+	return syndication.Item{
+		ID:        "urn:uuid:" + id.String(),
+		Title:     title,
+		Content:   content,
+		Link:      fmt.Sprintf("%s/posts/%s", app.config.frontendURL, id),
+		Author:    author,
+		Tags:      tags,
+		Published: createdAt,
+		Updated:   updatedAt,
+	}
+}
+
+// serveSyndicationFeed renders feed and lets http.ServeContent answer
+// conditional requests. The ETag is a hash of the document, Last-Modified
+// the latest change to any of its posts.
+func (app *application) serveSyndicationFeed(w http.ResponseWriter, r *http.Request, feed syndication.Feed, format string) {
+	for _, item := range feed.Items {
+		if item.Updated.After(feed.Updated) {
+			feed.Updated = item.Updated
+		}
+	}
+
+	body, err := syndication.Render(feed, format)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	sum := sha256.Sum256(body)
+
+	w.Header().Set("Content-Type", syndication.ContentTypes[format])
+	w.Header().Set("ETag", `"`+hex.EncodeToString(sum[:16])+`"`)
+	w.Header().Set("Cache-Control", "public, max-age=300")
+
+	http.ServeContent(w, r, "", feed.Updated, bytes.NewReader(body))
+}
+
+// requestURL rebuilds the absolute URL of r for self links.
+func requestURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://%s%s", scheme, r.Host, r.URL.RequestURI())
+}
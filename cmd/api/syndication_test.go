@@ -0,0 +1,71 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/JaskiratAnand/go-social/internal/syndication"
+)
+
+func TestServeSyndicationFeed(t *testing.T) {
+	app := TestMockApplication(t, config{})
+
+	updated := time.Date(2025, 11, 16, 12, 0, 0, 0, time.UTC)
+	feed := syndication.Feed{
+		Title:   "alice",
+		Updated: updated.Add(-time.Hour),
+		Items: []syndication.Item{
+			{ID: "urn:uuid:1", Title: "hello", Published: updated, Updated: updated},
+		},
+	}
+
+	serve := func(feed syndication.Feed, header http.Header) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/v1/users/alice/feed.rss", nil)
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		rr := httptest.NewRecorder()
+		app.serveSyndicationFeed(rr, req, feed, syndication.FormatRSS)
+		return rr
+	}
+
+	first := serve(feed, nil)
+	checkResponseCode(t, http.StatusOK, first.Code)
+
+	etag := first.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("expected an ETag")
+	}
+	if got := first.Header().Get("Content-Type"); got != syndication.ContentTypes[syndication.FormatRSS] {
+		t.Errorf("unexpected content type %q", got)
+	}
+	if got := first.Header().Get("Last-Modified"); got != updated.Format(http.TimeFormat) {
+		t.Errorf("expected Last-Modified of the newest post; got %q", got)
+	}
+
+	t.Run("matching etag is not modified", func(t *testing.T) {
+		rr := serve(feed, http.Header{"If-None-Match": {etag}})
+		checkResponseCode(t, http.StatusNotModified, rr.Code)
+		if rr.Body.Len() != 0 {
+			t.Error("expected no body")
+		}
+	})
+
+	t.Run("unchanged since is not modified", func(t *testing.T) {
+		rr := serve(feed, http.Header{"If-Modified-Since": {updated.Format(http.TimeFormat)}})
+		checkResponseCode(t, http.StatusNotModified, rr.Code)
+	})
+
+	t.Run("changed feeds get a new etag", func(t *testing.T) {
+		changed := feed
+		changed.Items = append([]syndication.Item{{ID: "urn:uuid:2", Title: "newer", Published: updated, Updated: updated}}, feed.Items...)
+
+		rr := serve(changed, http.Header{"If-None-Match": {etag}})
+		checkResponseCode(t, http.StatusOK, rr.Code)
+		if rr.Header().Get("ETag") == etag {
+			t.Error("expected the etag to change with the document")
+		}
+	})
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/JaskiratAnand/go-social/internal/store"
+	"github.com/go-chi/chi/v5"
+)
+
+const maxTagLength = 50
+
+// normalizeTag lowercases the tag and strips a leading '#', so "#Go" and
+// "go" refer to the same topic.
+func normalizeTag(tag string) string {
+	tag = strings.TrimSpace(tag)
+	tag = strings.TrimPrefix(tag, "#")
+	return strings.ToLower(tag)
+}
+
+func (app *application) tagFromURL(w http.ResponseWriter, r *http.Request) (string, bool) {
+	tag := normalizeTag(chi.URLParam(r, "tag"))
+	if tag == "" || len(tag) > maxTagLength {
+		app.customErrorResponse(w, r, http.StatusBadRequest, "invalid tag")
+		return "", false
+	}
+	return tag, true
+}
+
+// FollowTag godoc
+//
+//	@Summary		Follow tag
+//	@Description	Follow tag, its posts will show up in the home feed
+//	@Tags			tags
+//	@Accept			json
+//	@Produce		json
+//	@Param			tag	path	string	true	"Tag"
+//	@Success		204
+//	@Failure		400	{object}	error	"Bad Request"
+//	@Failure		500	{object}	error	"Server encountered a problem"
+//	@Security		ApiKeyAuth
+//	@Router			/tags/{tag}/follow [put]
+func (app *application) followTagHandler(w http.ResponseWriter, r *http.Request) {
+	tag, ok := app.tagFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	ctx := r.Context()
+
+	user := app.GetUserFromCtx(r)
+
+	followTag := &store.FollowTagParams{
+		UserID: user.ID,
+		Tag:    tag,
+	}
+
+	if err := app.store.FollowTag(ctx, *followTag); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	app.invalidateFeeds(ctx, user.ID)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// UnfollowTag godoc
+//
+//	@Summary		Unfollow tag
+//	@Description	Unfollow tag
+//	@Tags			tags
+//	@Accept			json
+//	@Produce		json
+//	@Param			tag	path	string	true	"Tag"
+//	@Success		204
+//	@Failure		400	{object}	error	"Bad Request"
+//	@Failure		500	{object}	error	"Server encountered a problem"
+//	@Security		ApiKeyAuth
+//	@Router			/tags/{tag}/unfollow [put]
+func (app *application) unfollowTagHandler(w http.ResponseWriter, r *http.Request) {
+	tag, ok := app.tagFromURL(w, r)
+	if !ok {
+		return
+	}
+
+	ctx := r.Context()
+
+	user := app.GetUserFromCtx(r)
+
+	unfollowTag := &store.UnfollowTagParams{
+		UserID: user.ID,
+		Tag:    tag,
+	}
+
+	if err := app.store.UnfollowTag(ctx, *unfollowTag); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	app.invalidateFeeds(ctx, user.ID)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetFollowedTags godoc
+//
+//	@Summary		Fetches followed tags
+//	@Description	Fetches tags followed by the authenticated user
+//	@Tags			tags
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	[]string
+//	@Failure		500	{object}	error	"Server encountered a problem"
+//	@Security		ApiKeyAuth
+//	@Router			/tags/followed [get]
+func (app *application) getFollowedTagsHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	user := app.GetUserFromCtx(r)
+
+	tags, err := app.store.GetFollowedTags(ctx, user.ID)
+	if err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if tags == nil {
+		tags = []string{}
+	}
+
+	if err := app.jsonResponse(w, http.StatusOK, tags); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+}
@@ -4,8 +4,12 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/JaskiratAnand/go-social/internal/auth"
+	"github.com/JaskiratAnand/go-social/internal/ranking"
+	"github.com/JaskiratAnand/go-social/internal/ratelimiter"
+	"github.com/JaskiratAnand/go-social/internal/realtime"
 	"github.com/JaskiratAnand/go-social/internal/store"
 	"github.com/JaskiratAnand/go-social/internal/store/cache"
 	"go.uber.org/zap"
@@ -18,16 +22,31 @@ func TestMockApplication(t *testing.T, withRedis config) *application {
 	mockStore := store.NewMockStore()
 	testAuth := &auth.TestAuthenticator{}
 
-	var mockCache cache.Storage
+	mockCache := cache.NewMemoryStorage(cache.MemoryConfig{MaxEntries: 100})
 	if withRedis.redisCfg.enabled {
 		mockCache = cache.NewMockCache()
 	}
 
+	var rateLimiter *ratelimiter.Policies
+	if withRedis.ratelimiter.Enabled {
+		var err error
+		rateLimiter, err = ratelimiter.NewPolicies(withRedis.ratelimiter, ratelimiter.Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
 	return &application{
+		config:        withRedis,
 		logger:        logger,
 		store:         mockStore,
 		cacheStorage:  mockCache,
 		authenticator: testAuth,
+		rateLimiter:   rateLimiter,
+		roleNames:     map[int32]string{1: "USER", 2: "MODERATOR", 3: "ADMIN"},
+		cursorCodec:   store.NewCursorCodec("test"),
+		ranker:        ranking.New(ranking.DefaultWeights, time.Now),
+		hub:           realtime.NewHub(realtime.Config{ReplaySize: 10, ReplayWindow: time.Minute}, nil),
 	}
 }
 
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"slices"
+	"time"
+
+	"github.com/JaskiratAnand/go-social/internal/store"
+	"github.com/JaskiratAnand/go-social/internal/timeline"
+	"github.com/google/uuid"
+)
+
+const fanOutTimeout = 30 * time.Second
+
+// fanOutPost pushes a new post into the timelines of the author and their
+// followers. Posts by authors above the celebrity threshold only go to the
+// author and are merged into follower feeds when they are read.
+func (app *application) fanOutPost(authorID uuid.UUID, entry timeline.Entry) {
+	if app.timeline == nil {
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), fanOutTimeout)
+		defer cancel()
+
+		recipients := []uuid.UUID{authorID}
+
+		count, err := app.store.CountFollowers(ctx, authorID)
+		if err != nil {
+			app.logger.Errorw("error counting followers for fan-out", "error", err.Error())
+			return
+		}
+
+		if count < app.config.timeline.CelebrityThreshold {
+			followers, err := app.store.GetFollowerIds(ctx, authorID)
+			if err != nil {
+				app.logger.Errorw("error fetching followers for fan-out", "error", err.Error())
+				return
+			}
+			recipients = append(recipients, followers...)
+		}
+
+		if err := app.timeline.Push(ctx, entry, recipients); err != nil {
+			app.logger.Errorw("error pushing post to timelines", "post", entry.PostID, "error", err.Error())
+		}
+	}()
+}
+
+// rebuildTimeline reseeds the timeline of user from the database, e.g. after
+// the set of followed users changed. Failures are logged and left for the
+// next rebuild, the follow itself already succeeded.
+func (app *application) rebuildTimeline(ctx context.Context, userID uuid.UUID) {
+	if app.timeline == nil {
+		return
+	}
+
+	if err := app.seedTimeline(ctx, userID); err != nil {
+		app.logger.Warnw("error rebuilding timeline", "user", userID, "error", err.Error())
+	}
+}
+
+func (app *application) seedTimeline(ctx context.Context, userID uuid.UUID) error {
+	seed, err := app.store.GetTimelineSeed(ctx, store.GetTimelineSeedParams{
+		UserID:             userID,
+		CelebrityThreshold: app.config.timeline.CelebrityThreshold,
+		Limit:              app.timeline.MaxLength(),
+	})
+	if err != nil {
+		return err
+	}
+
+	entries := make([]timeline.Entry, len(seed))
+	for i, row := range seed {
+		entries[i] = timeline.Entry{PostID: row.ID, CreatedAt: row.CreatedAt}
+	}
+
+	return app.timeline.Rebuild(ctx, userID, entries)
+}
+
+// readTimeline serves a feed page from the Redis timeline merged with posts
+// fanned out on read. It reports false when the page lies beyond the trimmed
+// end of the timeline and has to be read from the database instead.
+func (app *application) readTimeline(ctx context.Context, userID uuid.UUID, cursor *store.FeedCursor, limit int) ([]store.GetUserFeedRow, bool, error) {
+	length, err := app.timeline.Len(ctx, userID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if length == 0 {
+		// users who follow nobody keep an empty timeline, which is only
+		// reseeded once the seeded marker expires
+		seeded, err := app.timeline.Seeded(ctx, userID)
+		if err != nil {
+			return nil, false, err
+		}
+		if !seeded {
+			if err := app.seedTimeline(ctx, userID); err != nil {
+				return nil, false, err
+			}
+			if length, err = app.timeline.Len(ctx, userID); err != nil {
+				return nil, false, err
+			}
+		}
+	}
+
+	var (
+		position  *timeline.Entry
+		ascending bool
+		fanOut    = store.GetFanOutOnReadPostsParams{
+			UserID:             userID,
+			CelebrityThreshold: app.config.timeline.CelebrityThreshold,
+			Limit:              int64(limit),
+		}
+	)
+	if cursor != nil {
+		position = &timeline.Entry{PostID: cursor.ID, CreatedAt: cursor.CreatedAt}
+		ascending = cursor.Prev
+
+		fanOut.CursorCreatedAt = sql.NullTime{Time: cursor.CreatedAt, Valid: true}
+		fanOut.CursorID = uuid.NullUUID{UUID: cursor.ID, Valid: true}
+		fanOut.Ascending = cursor.Prev
+	}
+
+	entries, err := app.timeline.Range(ctx, userID, position, ascending, int64(limit))
+	if err != nil {
+		return nil, false, err
+	}
+
+	if !ascending && len(entries) < limit && length >= app.timeline.MaxLength() {
+		return nil, false, nil
+	}
+
+	extra, err := app.store.GetFanOutOnReadPosts(ctx, fanOut)
+	if err != nil {
+		return nil, false, err
+	}
+	for _, row := range extra {
+		entries = append(entries, timeline.Entry{PostID: row.ID, CreatedAt: row.CreatedAt})
+	}
+
+	entries = mergeEntries(entries, ascending, limit)
+
+	ids := make([]uuid.UUID, len(entries))
+	for i, entry := range entries {
+		ids[i] = entry.PostID
+	}
+
+	posts, err := app.store.GetFeedPostsByIds(ctx, store.GetFeedPostsByIdsParams{
+		UserID: userID,
+		Ids:    ids,
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	byID := make(map[uuid.UUID]store.GetUserFeedRow, len(posts))
+	for _, post := range posts {
+		byID[post.ID] = store.GetUserFeedRow(post)
+	}
+
+	feed := make([]store.GetUserFeedRow, 0, len(ids))
+	var missing []uuid.UUID
+	for _, id := range ids {
+		post, ok := byID[id]
+		if !ok {
+			missing = append(missing, id)
+			continue
+		}
+		feed = append(feed, post)
+	}
+
+	// deleted or no longer visible posts are dropped from the timeline
+	if err := app.timeline.Remove(ctx, userID, missing...); err != nil {
+		app.logger.Warnw("error removing stale timeline entries", "user", userID, "error", err.Error())
+	}
+
+	return feed, true, nil
+}
+
+// mergeEntries sorts entries in feed order, drops duplicates and keeps limit.
+func mergeEntries(entries []timeline.Entry, ascending bool, limit int) []timeline.Entry {
+	slices.SortFunc(entries, func(a, b timeline.Entry) int {
+		cmp := a.CreatedAt.Compare(b.CreatedAt)
+		if cmp == 0 {
+			cmp = bytes.Compare(a.PostID[:], b.PostID[:])
+		}
+		if ascending {
+			return cmp
+		}
+		return -cmp
+	})
+
+	entries = slices.CompactFunc(entries, func(a, b timeline.Entry) bool {
+		return a.PostID == b.PostID
+	})
+
+	if len(entries) > limit {
+		entries = entries[:limit]
+	}
+
+	return entries
+}
@@ -17,6 +17,7 @@ type UserResponseType struct {
 	Username  string    `json:"username"`
 	CreatedAt time.Time `json:"created_at"`
 	Verified  bool      `json:"verified"`
+	IsPrivate bool      `json:"is_private"`
 }
 
 // GetUserById godoc
@@ -60,6 +61,7 @@ func (app *application) getUserByIdHandler(w http.ResponseWriter, r *http.Reques
 		Username:  user.Username,
 		CreatedAt: user.CreatedAt,
 		Verified:  user.Verified,
+		IsPrivate: user.IsPrivate,
 	}
 
 	if err := app.jsonResponse(w, http.StatusCreated, userResponse); err != nil {
@@ -103,6 +105,7 @@ func (app *application) getUserByUsernameHandler(w http.ResponseWriter, r *http.
 		Username:  user.Username,
 		CreatedAt: user.CreatedAt,
 		Verified:  user.Verified,
+		IsPrivate: user.IsPrivate,
 	}
 
 	if err := app.jsonResponse(w, http.StatusOK, userResponse); err != nil {
@@ -115,15 +118,22 @@ type FollowUnfollowUserPayload struct {
 	FollowID uuid.UUID `json:"followID" validate:"required"`
 }
 
+type FollowStatusResponse struct {
+	Status string `json:"status"`
+}
+
 // FollowUser godoc
 //
 //	@Summary		Follow user
-//	@Description	Follow user
+//	@Description	Follow user, or send a follow request if the account is private
 //	@Tags			users
 //	@Accept			json
 //	@Produce		json
 //	@Param			userID	path		string	true	"Follow ID"
 //	@Success		200		{object}	nil
+//	@Success		202		{object}	FollowStatusResponse
+//	@Failure		400		{object}	error	"Bad Request"
+//	@Failure		404		{object}	error	"Record Not Found"
 //	@Failure		500		{object}	error	"Server encountered a problem"
 //	@Security		ApiKeyAuth
 //	@Router			/users/{userID}/follow [put]
@@ -140,6 +150,52 @@ func (app *application) followUserHandler(w http.ResponseWriter, r *http.Request
 
 	user := app.GetUserFromCtx(r)
 
+	if followID == user.ID {
+		app.customErrorResponse(w, r, http.StatusBadRequest, "cannot follow yourself")
+		return
+	}
+
+	followUser, err := app.getUser(ctx, followID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			app.recordNotFoundResponse(w, r, err)
+			return
+		}
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if followUser.IsPrivate {
+		isFollowing, err := app.store.IsFollowing(ctx, store.IsFollowingParams{
+			UserID:   user.ID,
+			FollowID: followID,
+		})
+		if err != nil {
+			app.internalServerError(w, r, err)
+			return
+		}
+
+		if !isFollowing {
+			followRequest := &store.CreateFollowRequestParams{
+				UserID:   user.ID,
+				FollowID: followID,
+			}
+
+			if err := app.store.CreateFollowRequest(ctx, *followRequest); err != nil {
+				app.internalServerError(w, r, err)
+				return
+			}
+
+			app.cacheStorage.Suggestions.Delete(ctx, user.ID)
+
+			if err := app.jsonResponse(w, http.StatusAccepted, &FollowStatusResponse{Status: "pending"}); err != nil {
+				app.internalServerError(w, r, err)
+				return
+			}
+			return
+		}
+	}
+
 	userFollowData := &store.FollowUserParams{
 		UserID:   user.ID,
 		FollowID: followID,
@@ -151,6 +207,12 @@ func (app *application) followUserHandler(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	app.cacheStorage.Suggestions.Delete(ctx, user.ID)
+
+	app.invalidateFeeds(ctx, user.ID)
+	app.rebuildTimeline(ctx, user.ID)
+	app.publishFollow(followID, FollowEvent{UserID: user.ID, Username: user.Username})
+
 	if err := app.jsonResponse(w, http.StatusNoContent, nil); err != nil {
 		app.internalServerError(w, r, err)
 		return
@@ -193,8 +255,77 @@ func (app *application) unfollowUserHandler(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
+	// also cancel a pending request to a private account
+	cancelRequest := &store.DeleteFollowRequestParams{
+		UserID:   user.ID,
+		FollowID: unfollowID,
+	}
+
+	if _, err := app.store.DeleteFollowRequest(ctx, *cancelRequest); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	app.cacheStorage.Suggestions.Delete(ctx, user.ID)
+	app.invalidateFeeds(ctx, user.ID)
+	app.rebuildTimeline(ctx, user.ID)
+
 	if err := app.jsonResponse(w, http.StatusNoContent, nil); err != nil {
 		app.internalServerError(w, r, err)
 		return
 	}
 }
+
+type UpdatePrivacyPayload struct {
+	IsPrivate *bool `json:"is_private" validate:"required"`
+}
+
+// UpdatePrivacy godoc
+//
+//	@Summary		Update account privacy
+//	@Description	Make the account private or public. Going public approves all pending follow requests
+//	@Tags			users
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body	UpdatePrivacyPayload	true	"Privacy Payload"
+//	@Success		204
+//	@Failure		400	{object}	error	"Bad Request"
+//	@Failure		500	{object}	error	"Server encountered a problem"
+//	@Security		ApiKeyAuth
+//	@Router			/users/me/privacy [put]
+func (app *application) updatePrivacyHandler(w http.ResponseWriter, r *http.Request) {
+	var payload UpdatePrivacyPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+	if err := Validate.Struct(payload); err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	ctx := r.Context()
+
+	user := app.GetUserFromCtx(r)
+
+	updatePrivacy := &store.UpdateUserPrivacyParams{
+		ID:        user.ID,
+		IsPrivate: *payload.IsPrivate,
+	}
+
+	if err := app.store.UpdateUserPrivacy(ctx, *updatePrivacy); err != nil {
+		app.internalServerError(w, r, err)
+		return
+	}
+
+	if !*payload.IsPrivate {
+		if err := app.store.ApproveAllFollowRequests(ctx, user.ID); err != nil {
+			app.internalServerError(w, r, err)
+			return
+		}
+	}
+
+	app.cacheStorage.Users.Delete(ctx, user.ID)
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,371 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/JaskiratAnand/go-social/internal/realtime"
+	"github.com/JaskiratAnand/go-social/internal/store"
+	"github.com/JaskiratAnand/go-social/internal/websocket"
+	"github.com/google/uuid"
+)
+
+const (
+	// wsPongWait is how long a client may stay silent, pings included.
+	wsPongWait     = 60 * time.Second
+	wsPingInterval = 25 * time.Second
+	wsWriteTimeout = 10 * time.Second
+	wsReadLimit    = 4096
+	// wsMaxChannels bounds the subscriptions of one connection.
+	wsMaxChannels = 50
+	// wsTypingInterval throttles typing indicators per channel.
+	wsTypingInterval = 2 * time.Second
+)
+
+// wsPath is exempt from the request timeouts when upgrading, see isLongLived.
+const wsPath = "/v1/ws"
+
+var wsUpgrader = &websocket.Upgrader{ReadLimit: wsReadLimit}
+
+// Channels clients can subscribe to. Post channels are named
+// "post:<post id>".
+const (
+	wsChannelFeed          = "feed"
+	wsChannelNotifications = "notifications"
+	wsChannelPostPrefix    = "post:"
+)
+
+// wsClientMessage is a request sent by the client.
+type wsClientMessage struct {
+	// Op is one of subscribe, unsubscribe, typing or ping.
+	Op          string `json:"op"`
+	Channel     string `json:"channel"`
+	LastEventID int64  `json:"last_event_id"`
+}
+
+// wsServerMessage is sent to the client, either as a reply to a request or
+// as an event of a subscribed channel.
+type wsServerMessage struct {
+	// Type is event, subscribed, unsubscribed, pong or error.
+	Type    string          `json:"type"`
+	Channel string          `json:"channel,omitempty"`
+	ID      int64           `json:"id,omitempty"`
+	Event   string          `json:"event,omitempty"`
+	Data    json.RawMessage `json:"data,omitempty"`
+	Error   string          `json:"error,omitempty"`
+}
+
+// TypingEvent tells readers of a post channel that someone is writing a
+// comment. Typing events are not replayed.
+type TypingEvent struct {
+	UserID   uuid.UUID `json:"user_id"`
+	Username string    `json:"username"`
+}
+
+// wsChange asks the writer to change the subscription, so replayed events
+// are always written before live events of the same channel.
+type wsChange struct {
+	subscribe   bool
+	channel     string
+	topic       string
+	lastEventID int64
+}
+
+type wsClient struct {
+	app  *application
+	conn *websocket.Conn
+	user store.Users
+	sub  *realtime.Subscription
+
+	replies chan wsServerMessage
+	changes chan wsChange
+	// done is closed when the reader returns, stopped when the writer does.
+	done    chan struct{}
+	stopped chan struct{}
+
+	// channels maps subscribed hub topics to channel names. It is only
+	// touched by the writer.
+	channels map[string]string
+	// lastTyping is only touched by the reader.
+	lastTyping map[string]time.Time
+}
+
+// Websocket godoc
+//
+//	@Summary		Opens the realtime gateway
+//	@Description	Upgrades to a WebSocket that multiplexes subscriptions to the feed, notifications and post channels.
+//	@Description	Clients send {"op": "subscribe"|"unsubscribe"|"typing"|"ping", "channel": "feed"|"notifications"|"post:<id>", "last_event_id": 0}.
+//	@Tags			feed
+//	@Success		101
+//	@Failure		400	{object}	error	"Bad Request"
+//	@Failure		401	{object}	error	"Unauthorized"
+//	@Security		ApiKeyAuth
+//	@Router			/ws [get]
+func (app *application) websocketHandler(w http.ResponseWriter, r *http.Request) {
+	user := app.GetUserFromCtx(r)
+
+	conn, err := wsUpgrader.Upgrade(w, r)
+	if err != nil {
+		app.logger.Warnw("websocket upgrade failed", "path", r.URL.Path, "error", err.Error())
+		return
+	}
+
+	c := &wsClient{
+		app:        app,
+		conn:       conn,
+		user:       user,
+		sub:        app.hub.Subscribe(),
+		replies:    make(chan wsServerMessage, 16),
+		changes:    make(chan wsChange, 4),
+		done:       make(chan struct{}),
+		stopped:    make(chan struct{}),
+		channels:   make(map[string]string),
+		lastTyping: make(map[string]time.Time),
+	}
+	c.sub.Add(0, realtime.UserTopic(user.ID))
+
+	go c.writeLoop()
+	c.readLoop()
+}
+
+func (c *wsClient) readLoop() {
+	defer close(c.done)
+
+	c.conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	})
+
+	for {
+		_, data, err := c.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		c.conn.SetReadDeadline(time.Now().Add(wsPongWait))
+
+		var msg wsClientMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			c.reply(wsServerMessage{Type: "error", Error: "invalid message"})
+			continue
+		}
+
+		if err := c.handle(msg); err != nil {
+			c.reply(wsServerMessage{Type: "error", Channel: msg.Channel, Error: err.Error()})
+		}
+	}
+}
+
+func (c *wsClient) handle(msg wsClientMessage) error {
+	switch msg.Op {
+	case "ping":
+		c.reply(wsServerMessage{Type: "pong"})
+		return nil
+	case "subscribe", "unsubscribe", "typing":
+	default:
+		return errors.New("unknown op")
+	}
+
+	topic, err := c.topic(msg.Channel)
+	if err != nil {
+		return err
+	}
+
+	switch msg.Op {
+	case "subscribe":
+		c.change(wsChange{subscribe: true, channel: msg.Channel, topic: topic, lastEventID: msg.LastEventID})
+	case "unsubscribe":
+		c.change(wsChange{channel: msg.Channel, topic: topic})
+	case "typing":
+		if !strings.HasPrefix(msg.Channel, wsChannelPostPrefix) {
+			return errors.New("typing is only supported on post channels")
+		}
+
+		// drop indicators sent faster than others need them
+		if time.Since(c.lastTyping[topic]) < wsTypingInterval {
+			return nil
+		}
+		c.lastTyping[topic] = time.Now()
+
+		ctx, cancel := context.WithTimeout(context.Background(), publishTimeout)
+		defer cancel()
+
+		event := TypingEvent{UserID: c.user.ID, Username: c.user.Username}
+		if err := c.app.hub.Signal(ctx, []string{topic}, realtime.EventTyping, event); err != nil {
+			c.app.logger.Warnw("error publishing typing indicator", "error", err.Error())
+		}
+	}
+
+	return nil
+}
+
+// topic maps a channel to its hub topic, checking that the user may read it.
+func (c *wsClient) topic(channel string) (string, error) {
+	switch channel {
+	case wsChannelFeed:
+		return realtime.FeedTopic(c.user.ID), nil
+	case wsChannelNotifications:
+		return realtime.NotificationsTopic(c.user.ID), nil
+	}
+
+	id, ok := strings.CutPrefix(channel, wsChannelPostPrefix)
+	if !ok {
+		return "", errors.New("unknown channel")
+	}
+
+	postID, err := uuid.Parse(id)
+	if err != nil {
+		return "", errors.New("invalid post-id")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), QueryTimeoutDuration)
+	defer cancel()
+
+	post, err := c.app.store.GetPostsById(ctx, postID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", errors.New("post not found")
+		}
+		c.app.logger.Errorw("error fetching post for websocket channel", "error", err.Error())
+		return "", errors.New("the server encountered a problem")
+	}
+	if post.HiddenAt.Valid {
+		return "", errors.New("post not found")
+	}
+
+	canView, err := c.app.canViewPosts(ctx, &c.user, post.UserID)
+	if err != nil {
+		c.app.logger.Errorw("error checking post visibility", "error", err.Error())
+		return "", errors.New("the server encountered a problem")
+	}
+	if !canView {
+		// same answer as a missing post, like the HTTP handlers
+		return "", errors.New("post not found")
+	}
+
+	return realtime.PostTopic(postID), nil
+}
+
+// reply and change block while the writer is busy, so a client that
+// floods requests without reading replies stops being read.
+func (c *wsClient) reply(msg wsServerMessage) {
+	select {
+	case c.replies <- msg:
+	case <-c.stopped:
+	}
+}
+
+func (c *wsClient) change(ch wsChange) {
+	select {
+	case c.changes <- ch:
+	case <-c.stopped:
+	}
+}
+
+func (c *wsClient) writeLoop() {
+	defer close(c.stopped)
+	defer c.conn.Close()
+	defer c.sub.Close()
+
+	c.conn.SetWriteTimeout(wsWriteTimeout)
+
+	ping := time.NewTicker(wsPingInterval)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-c.done:
+			c.conn.CloseWithCode(websocket.CloseNormalClosure, "")
+			return
+
+		case <-ping.C:
+			if err := c.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteTimeout)); err != nil {
+				return
+			}
+
+		case msg := <-c.replies:
+			if err := c.write(msg); err != nil {
+				return
+			}
+
+		case ch := <-c.changes:
+			if err := c.apply(ch); err != nil {
+				return
+			}
+
+		case e, ok := <-c.sub.C:
+			if !ok {
+				if c.sub.Disconnected() {
+					c.conn.CloseWithCode(websocket.ClosePolicyViolation, "account restricted")
+					return
+				}
+				// the hub dropped a client that fell behind, or is shutting
+				// down; either way the client reconnects and resumes
+				c.conn.CloseWithCode(websocket.CloseTryAgainLater, "resume with last_event_id")
+				return
+			}
+
+			if err := c.writeEvent(e); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (c *wsClient) apply(ch wsChange) error {
+	if !ch.subscribe {
+		if _, ok := c.channels[ch.topic]; ok {
+			c.sub.Remove(ch.topic)
+			delete(c.channels, ch.topic)
+		}
+		return c.write(wsServerMessage{Type: "unsubscribed", Channel: ch.channel})
+	}
+
+	if _, ok := c.channels[ch.topic]; !ok && len(c.channels) >= wsMaxChannels {
+		return c.write(wsServerMessage{Type: "error", Channel: ch.channel, Error: "too many subscriptions"})
+	}
+
+	c.channels[ch.topic] = ch.channel
+	missed := c.sub.Add(ch.lastEventID, ch.topic)
+
+	if err := c.write(wsServerMessage{Type: "subscribed", Channel: ch.channel}); err != nil {
+		return err
+	}
+
+	for _, e := range missed {
+		if err := c.writeEvent(e); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *wsClient) writeEvent(e realtime.Event) error {
+	channel, ok := c.channels[e.Topic]
+	if !ok {
+		// published before the unsubscribe reached the hub
+		return nil
+	}
+
+	return c.write(wsServerMessage{
+		Type:    "event",
+		Channel: channel,
+		ID:      e.ID,
+		Event:   e.Type,
+		Data:    e.Data,
+	})
+}
+
+func (c *wsClient) write(msg wsServerMessage) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	return c.conn.WriteMessage(websocket.TextMessage, data)
+}
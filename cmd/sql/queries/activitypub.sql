@@ -0,0 +1,84 @@
+-- name: GetActorKey :one
+SELECT user_id, public_key_pem, private_key_pem, created_at
+FROM actor_keys
+WHERE user_id = $1 LIMIT 1;
+
+-- name: CreateActorKey :exec
+INSERT 
+INTO actor_keys (user_id, public_key_pem, private_key_pem) 
+VALUES ($1, $2, $3)
+ON CONFLICT DO NOTHING;
+
+-- name: AddRemoteFollower :exec
+INSERT 
+INTO remote_followers (user_id, actor_id, inbox, shared_inbox) 
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (user_id, actor_id) DO UPDATE 
+SET inbox = EXCLUDED.inbox, shared_inbox = EXCLUDED.shared_inbox;
+
+-- name: RemoveRemoteFollower :execrows
+DELETE FROM remote_followers 
+WHERE user_id = $1 AND actor_id = $2;
+
+-- name: GetRemoteFollowerInboxes :many
+SELECT DISTINCT COALESCE(shared_inbox, inbox)::TEXT AS inbox
+FROM remote_followers 
+WHERE user_id = $1;
+
+-- name: CountRemoteFollowers :one
+SELECT COUNT(*) 
+FROM remote_followers 
+WHERE user_id = $1;
+
+-- name: AddRemoteLike :exec
+INSERT 
+INTO remote_likes (post_id, actor_id) 
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING;
+
+-- name: RemoveRemoteLike :exec
+DELETE FROM remote_likes 
+WHERE post_id = $1 AND actor_id = $2;
+
+-- name: CreateRemoteReply :exec
+INSERT 
+INTO remote_replies (id, post_id, actor_id, content, published_at) 
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT DO NOTHING;
+
+-- name: EnqueueDelivery :exec
+INSERT 
+INTO delivery_jobs (sender_id, inbox, activity) 
+VALUES ($1, $2, $3);
+
+-- name: ClaimDeliveries :many
+UPDATE delivery_jobs
+SET 
+    attempts = attempts + 1,
+    next_attempt_at = sqlc.arg('lease_until')::TIMESTAMPTZ
+WHERE id IN (
+    SELECT dj.id FROM delivery_jobs dj
+    WHERE dj.failed_at IS NULL AND dj.next_attempt_at <= NOW()
+    ORDER BY dj.next_attempt_at
+    LIMIT sqlc.arg('limit')
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING id, sender_id, inbox, activity, attempts;
+
+-- name: CompleteDelivery :exec
+DELETE FROM delivery_jobs 
+WHERE id = $1;
+
+-- name: RetryDelivery :exec
+UPDATE delivery_jobs
+SET 
+    next_attempt_at = $2,
+    last_error = $3
+WHERE id = $1;
+
+-- name: FailDelivery :exec
+UPDATE delivery_jobs
+SET 
+    failed_at = NOW(),
+    last_error = $2
+WHERE id = $1;
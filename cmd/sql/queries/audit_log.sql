@@ -0,0 +1,17 @@
+-- name: CreateAuditLog :exec
+INSERT INTO audit_log (actor_id, action, target_type, target_id, before, after, request_id, ip)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8);
+
+-- name: GetAuditLogs :many
+SELECT id, actor_id, action, target_type, target_id, before, after, request_id, ip, created_at
+FROM audit_log
+WHERE 
+    (sqlc.narg('actor_id')::UUID IS NULL OR actor_id = sqlc.narg('actor_id')::UUID) AND
+    (sqlc.narg('action')::TEXT IS NULL OR action = sqlc.narg('action')::TEXT) AND
+    (sqlc.narg('target_type')::TEXT IS NULL OR target_type = sqlc.narg('target_type')::TEXT) AND
+    (sqlc.narg('target_id')::TEXT IS NULL OR target_id = sqlc.narg('target_id')::TEXT) AND
+    (sqlc.narg('since')::TIMESTAMPTZ IS NULL OR created_at >= sqlc.narg('since')::TIMESTAMPTZ) AND
+    (sqlc.narg('until')::TIMESTAMPTZ IS NULL OR created_at < sqlc.narg('until')::TIMESTAMPTZ) AND
+    (sqlc.narg('before_id')::BIGINT IS NULL OR id < sqlc.narg('before_id')::BIGINT)
+ORDER BY id DESC
+LIMIT sqlc.arg('limit');
@@ -8,5 +8,34 @@ RETURNING id, created_at;
 SELECT c.id, c.content, c.created_at, c.user_id, u.username 
 FROM comments c
 JOIN users u ON u.id = c.user_id
-WHERE c.post_id = $1
-ORDER BY c.created_at DESC;
\ No newline at end of file
+WHERE c.post_id = $1 AND c.hidden_at IS NULL
+ORDER BY c.created_at DESC;
+
+-- name: CountUserCommentsSince :one
+SELECT COUNT(*) AS count, COALESCE(MIN(created_at), NOW())::TIMESTAMPTZ AS oldest 
+FROM comments 
+WHERE user_id = $1 AND created_at > $2;
+
+-- name: RecentCommentWithContentExists :one
+SELECT EXISTS (
+    SELECT 1 
+    FROM comments 
+    WHERE user_id = sqlc.arg('user_id') 
+    AND content_hash(content) = content_hash(sqlc.arg('content')::TEXT) 
+    AND created_at > sqlc.arg('since')
+);
+
+-- name: GetCommentById :one
+SELECT * 
+FROM comments 
+WHERE id = $1 LIMIT 1;
+
+-- name: HideComment :exec
+UPDATE comments
+SET hidden_at = NOW()
+WHERE id = $1 AND hidden_at IS NULL;
+
+-- name: UnhideComment :exec
+UPDATE comments
+SET hidden_at = NULL
+WHERE id = $1;
@@ -0,0 +1,16 @@
+-- name: CreateFilterRule :one
+INSERT 
+INTO filter_rules (kind, pattern, action, created_by) 
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: GetFilterRules :many
+SELECT * 
+FROM filter_rules 
+ORDER BY created_at, id;
+
+-- name: DeleteFilterRule :one
+DELETE 
+FROM filter_rules 
+WHERE id = $1
+RETURNING *;
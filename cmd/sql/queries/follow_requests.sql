@@ -0,0 +1,38 @@
+-- name: CreateFollowRequest :exec
+INSERT 
+INTO follow_requests (user_id, follow_id) 
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING;
+
+-- name: GetFollowRequestsByFollowId :many
+SELECT fr.user_id, u.username, fr.created_at
+FROM follow_requests fr
+JOIN users u ON u.id = fr.user_id
+WHERE fr.follow_id = $1
+ORDER BY fr.created_at DESC;
+
+-- name: ApproveFollowRequest :execrows
+WITH req AS (
+    DELETE FROM follow_requests
+    WHERE user_id = $1 AND follow_id = $2
+    RETURNING user_id, follow_id
+)
+INSERT 
+INTO follows (user_id, follow_id)
+SELECT user_id, follow_id FROM req
+ON CONFLICT DO NOTHING;
+
+-- name: ApproveAllFollowRequests :exec
+WITH req AS (
+    DELETE FROM follow_requests
+    WHERE follow_id = $1
+    RETURNING user_id, follow_id
+)
+INSERT 
+INTO follows (user_id, follow_id)
+SELECT user_id, follow_id FROM req
+ON CONFLICT DO NOTHING;
+
+-- name: DeleteFollowRequest :execrows
+DELETE FROM follow_requests 
+WHERE user_id = $1 AND follow_id = $2;
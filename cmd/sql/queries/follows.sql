@@ -5,4 +5,28 @@ VALUES ($1, $2);
 
 -- name: UnfollowUser :exec
 DELETE FROM follows 
-WHERE user_id = $1 AND follow_id = $2;
\ No newline at end of file
+WHERE user_id = $1 AND follow_id = $2;
+
+-- name: IsFollowing :one
+SELECT EXISTS (
+    SELECT 1 FROM follows 
+    WHERE user_id = $1 AND follow_id = $2
+);
+
+-- name: CanViewUserPosts :one
+SELECT (NOT u.is_private OR u.id = $1 OR EXISTS (
+    SELECT 1 FROM follows f 
+    WHERE f.user_id = $1 AND f.follow_id = u.id
+))::BOOLEAN AS can_view
+FROM users u
+WHERE u.id = $2;
+
+-- name: GetFollowerIds :many
+SELECT user_id 
+FROM follows 
+WHERE follow_id = $1;
+
+-- name: CountFollowers :one
+SELECT COUNT(*) 
+FROM follows 
+WHERE follow_id = $1;
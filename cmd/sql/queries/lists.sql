@@ -0,0 +1,68 @@
+-- name: CreateList :one
+INSERT 
+INTO lists (user_id, name, is_private) 
+VALUES ($1, $2, $3)
+RETURNING id, created_at;
+
+-- name: GetListById :one
+SELECT * 
+FROM lists 
+WHERE id = $1 LIMIT 1;
+
+-- name: GetListsByUserId :many
+SELECT l.id, l.name, l.is_private, l.created_at, 
+    COUNT(lm.member_id) AS members_count
+FROM lists l
+LEFT JOIN list_members lm ON lm.list_id = l.id
+WHERE l.user_id = $1
+GROUP BY l.id
+ORDER BY l.created_at DESC;
+
+-- name: UpdateListById :exec
+UPDATE lists
+SET 
+    name = $2,
+    is_private = $3
+WHERE id = $1;
+
+-- name: DeleteListById :exec
+DELETE FROM lists WHERE id = $1;
+
+-- name: AddListMember :exec
+INSERT 
+INTO list_members (list_id, member_id) 
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING;
+
+-- name: RemoveListMember :execrows
+DELETE FROM list_members 
+WHERE list_id = $1 AND member_id = $2;
+
+-- name: GetListMembers :many
+SELECT u.id, u.username, lm.created_at
+FROM list_members lm
+JOIN users u ON u.id = lm.member_id
+WHERE lm.list_id = $1
+ORDER BY lm.created_at DESC;
+
+-- name: GetListFeed :many
+SELECT p.id, p.title, p.content, p.tags, p.created_at, p.updated_at, 
+    u.username,
+    COUNT(c.id) AS comments_count
+FROM posts p
+JOIN users u ON p.user_id = u.id
+JOIN list_members lm ON lm.member_id = p.user_id
+LEFT JOIN comments c ON p.id = c.post_id AND c.hidden_at IS NULL
+WHERE 
+    p.hidden_at IS NULL AND
+    u.banned_at IS NULL AND
+    lm.list_id = $1 AND
+    (NOT u.is_private OR u.id = $2 OR EXISTS (
+        SELECT 1 FROM follows f 
+        WHERE f.user_id = $2 AND f.follow_id = u.id
+    )) AND
+    (p.title ILIKE '%' || $3::TEXT || '%' OR p.content ILIKE '%' || $3::TEXT || '%') AND 
+    (p.tags @> $4 OR $4 = '{}')
+GROUP BY p.id, u.username
+ORDER BY p.created_at DESC
+LIMIT $5 OFFSET $6;
@@ -0,0 +1,16 @@
+-- name: AddMutedWord :exec
+INSERT 
+INTO muted_words (user_id, word) 
+VALUES (sqlc.arg('user_id'), LOWER(sqlc.arg('word')))
+ON CONFLICT DO NOTHING;
+
+-- name: RemoveMutedWord :execrows
+DELETE 
+FROM muted_words 
+WHERE user_id = sqlc.arg('user_id') AND word = LOWER(sqlc.arg('word'));
+
+-- name: GetMutedWords :many
+SELECT word 
+FROM muted_words 
+WHERE user_id = $1 
+ORDER BY word;
@@ -7,11 +7,17 @@ RETURNING id, created_at, updated_at;
 -- name: GetPostsByUserId :many
 SELECT id, title, content, tags, created_at, updated_at 
 FROM posts 
-WHERE user_id = $1 
-ORDER BY created_at DESC;
+WHERE 
+    user_id = sqlc.arg('user_id') AND 
+    hidden_at IS NULL AND
+    (sqlc.narg('tag')::TEXT IS NULL OR sqlc.narg('tag')::TEXT = ANY(LOWER(tags::TEXT)::TEXT[])) AND
+    (sqlc.narg('cursor_created_at')::TIMESTAMPTZ IS NULL OR 
+        (created_at, id) < (sqlc.narg('cursor_created_at')::TIMESTAMPTZ, sqlc.narg('cursor_id')::UUID))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('limit');
 
 -- name: GetPostsById :one
-SELECT id, title, content, tags, user_id, created_at, updated_at
+SELECT id, title, content, tags, user_id, created_at, updated_at, hidden_at
 FROM posts 
 WHERE id = $1 LIMIT 1;
 
@@ -28,9 +34,9 @@ SELECT p.title, p.content, p.user_id, author.username, p.tags, p.created_at, p.u
     ) AS comments
 FROM posts p
 JOIN users author ON p.user_id = author.id
-LEFT JOIN comments c ON p.id = c.post_id
+LEFT JOIN comments c ON p.id = c.post_id AND c.hidden_at IS NULL
 LEFT JOIN users u ON c.user_id = u.id
-WHERE p.id = $1
+WHERE p.id = $1 AND p.hidden_at IS NULL AND author.banned_at IS NULL
 GROUP BY p.id, author.username;
 
 -- name: DeletePostById :exec
@@ -48,15 +54,203 @@ RETURNING id, updated_at;
 -- name: GetUserFeed :many
 SELECT p.id, p.title, p.content, p.tags, p.created_at, p.updated_at, 
     u.username,
-    COUNT(c.id) AS comments_count
+    (SELECT COUNT(*) FROM comments c WHERE c.post_id = p.id AND c.hidden_at IS NULL) AS comments_count,
+    (CASE
+        WHEN p.user_id = sqlc.arg('user_id') THEN 'own'
+        WHEN f.follow_id IS NOT NULL THEN 'following'
+        ELSE 'tag'
+    END)::TEXT AS reason,
+    ARRAY(
+        SELECT tf.tag FROM tag_follows tf 
+        WHERE tf.user_id = sqlc.arg('user_id') AND tf.tag = ANY(LOWER(p.tags::TEXT)::TEXT[])
+    )::TEXT[] AS followed_tags
 FROM posts p
 JOIN users u ON p.user_id = u.id
-LEFT JOIN comments c ON p.id = c.post_id
-JOIN follows f ON p.user_id = f.follow_id OR p.user_id = $1
+LEFT JOIN follows f ON f.user_id = sqlc.arg('user_id') AND f.follow_id = p.user_id
 WHERE 
-    f.user_id = $1 AND
-    (p.title ILIKE '%' || $2::TEXT || '%' OR p.content ILIKE '%' || $2::TEXT || '%') AND 
-    (p.tags @> $3 OR $3 = '{}')
-GROUP BY p.id, u.username
+    p.hidden_at IS NULL AND
+    u.banned_at IS NULL AND
+    (
+        p.user_id = sqlc.arg('user_id') OR 
+        f.follow_id IS NOT NULL OR 
+        (NOT u.is_private AND EXISTS (
+            SELECT 1 FROM tag_follows tf 
+            WHERE tf.user_id = sqlc.arg('user_id') AND tf.tag = ANY(LOWER(p.tags::TEXT)::TEXT[])
+        ))
+    ) AND
+    (p.title ILIKE '%' || sqlc.arg('search')::TEXT || '%' OR p.content ILIKE '%' || sqlc.arg('search')::TEXT || '%') AND 
+    (p.tags @> sqlc.arg('tags')::TEXT[] OR sqlc.arg('tags')::TEXT[] = '{}') AND
+    (u.username = ANY(sqlc.arg('authors')::TEXT[]) OR sqlc.arg('authors')::TEXT[] = '{}') AND
+    (sqlc.narg('since')::TIMESTAMPTZ IS NULL OR p.created_at >= sqlc.narg('since')::TIMESTAMPTZ) AND
+    (sqlc.narg('until')::TIMESTAMPTZ IS NULL OR p.created_at < sqlc.narg('until')::TIMESTAMPTZ) AND
+    (
+        sqlc.narg('cursor_created_at')::TIMESTAMPTZ IS NULL OR
+        (p.created_at, p.id) < (sqlc.narg('cursor_created_at')::TIMESTAMPTZ, sqlc.narg('cursor_id')::UUID)
+    )
+ORDER BY p.created_at DESC, p.id DESC
+LIMIT sqlc.arg('limit') OFFSET sqlc.narg('offset');
+
+-- name: GetUserFeedAscending :many
+SELECT p.id, p.title, p.content, p.tags, p.created_at, p.updated_at, 
+    u.username,
+    (SELECT COUNT(*) FROM comments c WHERE c.post_id = p.id AND c.hidden_at IS NULL) AS comments_count,
+    (CASE
+        WHEN p.user_id = sqlc.arg('user_id') THEN 'own'
+        WHEN f.follow_id IS NOT NULL THEN 'following'
+        ELSE 'tag'
+    END)::TEXT AS reason,
+    ARRAY(
+        SELECT tf.tag FROM tag_follows tf 
+        WHERE tf.user_id = sqlc.arg('user_id') AND tf.tag = ANY(LOWER(p.tags::TEXT)::TEXT[])
+    )::TEXT[] AS followed_tags
+FROM posts p
+JOIN users u ON p.user_id = u.id
+LEFT JOIN follows f ON f.user_id = sqlc.arg('user_id') AND f.follow_id = p.user_id
+WHERE 
+    p.hidden_at IS NULL AND
+    u.banned_at IS NULL AND
+    (
+        p.user_id = sqlc.arg('user_id') OR 
+        f.follow_id IS NOT NULL OR 
+        (NOT u.is_private AND EXISTS (
+            SELECT 1 FROM tag_follows tf 
+            WHERE tf.user_id = sqlc.arg('user_id') AND tf.tag = ANY(LOWER(p.tags::TEXT)::TEXT[])
+        ))
+    ) AND
+    (p.title ILIKE '%' || sqlc.arg('search')::TEXT || '%' OR p.content ILIKE '%' || sqlc.arg('search')::TEXT || '%') AND 
+    (p.tags @> sqlc.arg('tags')::TEXT[] OR sqlc.arg('tags')::TEXT[] = '{}') AND
+    (u.username = ANY(sqlc.arg('authors')::TEXT[]) OR sqlc.arg('authors')::TEXT[] = '{}') AND
+    (sqlc.narg('since')::TIMESTAMPTZ IS NULL OR p.created_at >= sqlc.narg('since')::TIMESTAMPTZ) AND
+    (sqlc.narg('until')::TIMESTAMPTZ IS NULL OR p.created_at < sqlc.narg('until')::TIMESTAMPTZ) AND
+    (
+        sqlc.narg('cursor_created_at')::TIMESTAMPTZ IS NULL OR
+        (p.created_at, p.id) > (sqlc.narg('cursor_created_at')::TIMESTAMPTZ, sqlc.narg('cursor_id')::UUID)
+    )
+ORDER BY p.created_at, p.id
+LIMIT sqlc.arg('limit') OFFSET sqlc.narg('offset');
+
+-- name: GetFeedPostsByIds :many
+SELECT p.id, p.title, p.content, p.tags, p.created_at, p.updated_at, 
+    u.username,
+    (SELECT COUNT(*) FROM comments c WHERE c.post_id = p.id AND c.hidden_at IS NULL) AS comments_count,
+    (CASE
+        WHEN p.user_id = sqlc.arg('user_id') THEN 'own'
+        WHEN f.follow_id IS NOT NULL THEN 'following'
+        ELSE 'tag'
+    END)::TEXT AS reason,
+    ARRAY(
+        SELECT tf.tag FROM tag_follows tf 
+        WHERE tf.user_id = sqlc.arg('user_id') AND tf.tag = ANY(LOWER(p.tags::TEXT)::TEXT[])
+    )::TEXT[] AS followed_tags
+FROM posts p
+JOIN users u ON p.user_id = u.id
+LEFT JOIN follows f ON f.user_id = sqlc.arg('user_id') AND f.follow_id = p.user_id
+WHERE 
+    p.hidden_at IS NULL AND
+    u.banned_at IS NULL AND
+    p.id = ANY(sqlc.arg('ids')::UUID[]) AND
+    (p.user_id = sqlc.arg('user_id') OR f.follow_id IS NOT NULL OR NOT u.is_private);
+
+-- name: GetUserFeedCandidates :many
+SELECT p.id, p.title, p.content, p.tags, p.created_at, p.updated_at, 
+    u.username,
+    (SELECT COUNT(*) FROM comments c WHERE c.post_id = p.id AND c.hidden_at IS NULL) AS comments_count,
+    (CASE
+        WHEN p.user_id = sqlc.arg('user_id') THEN 'own'
+        WHEN f.follow_id IS NOT NULL THEN 'following'
+        ELSE 'tag'
+    END)::TEXT AS reason,
+    ARRAY(
+        SELECT tf.tag FROM tag_follows tf 
+        WHERE tf.user_id = sqlc.arg('user_id') AND tf.tag = ANY(LOWER(p.tags::TEXT)::TEXT[])
+    )::TEXT[] AS followed_tags,
+    (
+        SELECT COUNT(*) FROM comments ac
+        JOIN posts ap ON ap.id = ac.post_id
+        WHERE 
+            ac.user_id = sqlc.arg('user_id') AND 
+            ap.user_id = p.user_id AND 
+            ac.created_at > NOW() - INTERVAL '30 days'
+    ) AS author_affinity
+FROM posts p
+JOIN users u ON p.user_id = u.id
+LEFT JOIN follows f ON f.user_id = sqlc.arg('user_id') AND f.follow_id = p.user_id
+WHERE 
+    p.hidden_at IS NULL AND
+    u.banned_at IS NULL AND
+    (
+        p.user_id = sqlc.arg('user_id') OR 
+        f.follow_id IS NOT NULL OR 
+        (NOT u.is_private AND EXISTS (
+            SELECT 1 FROM tag_follows tf 
+            WHERE tf.user_id = sqlc.arg('user_id') AND tf.tag = ANY(LOWER(p.tags::TEXT)::TEXT[])
+        ))
+    ) AND
+    (p.title ILIKE '%' || sqlc.arg('search')::TEXT || '%' OR p.content ILIKE '%' || sqlc.arg('search')::TEXT || '%') AND 
+    (p.tags @> sqlc.arg('tags')::TEXT[] OR sqlc.arg('tags')::TEXT[] = '{}') AND
+    (u.username = ANY(sqlc.arg('authors')::TEXT[]) OR sqlc.arg('authors')::TEXT[] = '{}') AND
+    p.created_at >= sqlc.arg('since')::TIMESTAMPTZ AND
+    (sqlc.narg('until')::TIMESTAMPTZ IS NULL OR p.created_at < sqlc.narg('until')::TIMESTAMPTZ)
+ORDER BY p.created_at DESC, p.id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: GetExplorePosts :many
+SELECT p.id, p.title, p.content, p.tags, p.created_at, p.updated_at, 
+    u.username,
+    (SELECT COUNT(*) FROM comments c WHERE c.post_id = p.id AND c.hidden_at IS NULL) AS comments_count
+FROM posts p
+JOIN users u ON p.user_id = u.id
+WHERE 
+    p.hidden_at IS NULL AND
+    u.banned_at IS NULL AND
+    NOT u.is_private AND 
+    u.verified AND
+    p.created_at >= sqlc.arg('since')::TIMESTAMPTZ
+ORDER BY p.created_at DESC
+LIMIT sqlc.arg('limit');
+
+-- name: GetPublicPostsByTag :many
+SELECT p.id, p.title, p.content, p.tags, p.created_at, p.updated_at, 
+    u.username
+FROM posts p
+JOIN users u ON p.user_id = u.id
+WHERE 
+    p.hidden_at IS NULL AND
+    u.banned_at IS NULL AND
+    NOT u.is_private AND 
+    sqlc.arg('tag')::TEXT = ANY(LOWER(p.tags::TEXT)::TEXT[])
 ORDER BY p.created_at DESC
-LIMIT $4 OFFSET $5;
\ No newline at end of file
+LIMIT sqlc.arg('limit');
+
+-- name: CountUserPostsSince :one
+SELECT COUNT(*) AS count, COALESCE(MIN(created_at), NOW())::TIMESTAMPTZ AS oldest 
+FROM posts 
+WHERE user_id = $1 AND created_at > $2;
+
+-- name: LockUserPosting :exec
+SELECT pg_advisory_xact_lock(hashtextextended(sqlc.arg('user_id')::UUID::TEXT, 0));
+
+-- name: RecentPostWithContentExists :one
+SELECT EXISTS (
+    SELECT 1 
+    FROM posts 
+    WHERE user_id = sqlc.arg('user_id') 
+    AND content_hash(title) = content_hash(sqlc.arg('title')::TEXT) 
+    AND content_hash(content) = content_hash(sqlc.arg('content')::TEXT) 
+    AND created_at > sqlc.arg('since')
+);
+
+-- name: HidePost :exec
+UPDATE posts
+SET hidden_at = NOW()
+WHERE id = $1 AND hidden_at IS NULL;
+
+-- name: UnhidePost :exec
+UPDATE posts
+SET hidden_at = NULL
+WHERE id = $1;
+
+-- name: CountPostsByUserId :one
+SELECT COUNT(*) 
+FROM posts 
+WHERE user_id = $1 AND hidden_at IS NULL;
@@ -0,0 +1,71 @@
+-- name: CreateReport :one
+INSERT 
+INTO reports (reporter_id, target_type, target_id, target_user_id, reason, details) 
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: GetReportById :one
+SELECT * 
+FROM reports 
+WHERE id = $1 LIMIT 1;
+
+-- name: GetReports :many
+SELECT r.id, r.reporter_id, r.target_type, r.target_id, r.target_user_id, r.reason, r.details, 
+    r.status, r.moderator_id, r.actions, r.note, r.created_at, r.claimed_at, r.closed_at,
+    reporter.username AS reporter_username,
+    target_user.username AS target_username,
+    (
+        SELECT COUNT(*) FROM reports o 
+        WHERE o.target_type = r.target_type AND o.target_id = r.target_id AND o.status IN ('open', 'claimed')
+    ) AS pending_reports
+FROM reports r
+LEFT JOIN users reporter ON reporter.id = r.reporter_id
+JOIN users target_user ON target_user.id = r.target_user_id
+WHERE 
+    r.status = ANY(sqlc.arg('statuses')::TEXT[]) AND
+    (sqlc.narg('target_type')::TEXT IS NULL OR r.target_type = sqlc.narg('target_type')::TEXT) AND
+    (sqlc.narg('moderator_id')::UUID IS NULL OR r.moderator_id = sqlc.narg('moderator_id')::UUID)
+ORDER BY r.created_at ASC, r.id ASC
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
+
+-- name: ClaimReport :one
+UPDATE reports
+SET status = 'claimed', moderator_id = sqlc.arg('moderator_id')::UUID, claimed_at = NOW()
+WHERE id = sqlc.arg('id') AND (
+    status = 'open' OR 
+    (status = 'claimed' AND moderator_id = sqlc.arg('moderator_id')::UUID)
+)
+RETURNING *;
+
+-- name: CloseReport :one
+UPDATE reports
+SET 
+    status = sqlc.arg('status'), 
+    actions = sqlc.arg('actions')::TEXT[], 
+    note = sqlc.arg('note'), 
+    moderator_id = sqlc.arg('moderator_id')::UUID, 
+    closed_at = NOW()
+WHERE id = sqlc.arg('id') AND (
+    status = 'open' OR 
+    (status = 'claimed' AND (moderator_id = sqlc.arg('moderator_id')::UUID OR sqlc.arg('force')::BOOLEAN))
+)
+RETURNING *;
+
+-- name: CloseTargetReports :exec
+UPDATE reports
+SET 
+    status = 'resolved', 
+    actions = sqlc.arg('actions')::TEXT[], 
+    note = sqlc.arg('note'), 
+    moderator_id = sqlc.arg('moderator_id')::UUID, 
+    closed_at = NOW()
+WHERE 
+    target_type = sqlc.arg('target_type') AND 
+    target_id = sqlc.arg('target_id') AND 
+    status IN ('open', 'claimed') AND 
+    id <> sqlc.arg('report_id')::UUID;
+
+-- name: CreateUserWarning :exec
+INSERT 
+INTO user_warnings (user_id, report_id, moderator_id, reason) 
+VALUES ($1, $2, $3, $4);
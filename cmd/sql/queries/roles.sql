@@ -1,4 +1,9 @@
 -- name: GetRoleByName :one
 SELECT * 
 FROM roles 
-WHERE name = $1 LIMIT 1;
\ No newline at end of file
+WHERE name = $1 LIMIT 1;
+
+-- name: GetRoles :many
+SELECT * 
+FROM roles 
+ORDER BY id;
\ No newline at end of file
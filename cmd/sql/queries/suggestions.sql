@@ -0,0 +1,50 @@
+-- name: GetFollowSuggestions :many
+WITH following AS (
+    SELECT follow_id FROM follows WHERE user_id = $1
+),
+recent_tags AS (
+    SELECT DISTINCT UNNEST(tags) AS tag
+    FROM posts
+    WHERE user_id = $1 AND created_at > NOW() - INTERVAL '30 days'
+),
+mutuals AS (
+    SELECT f.follow_id AS candidate_id, COUNT(*) AS mutual_count
+    FROM follows f
+    WHERE f.user_id IN (SELECT follow_id FROM following)
+    GROUP BY f.follow_id
+),
+shared_tags AS (
+    SELECT p.user_id AS candidate_id, COUNT(DISTINCT t.tag) AS shared_tag_count
+    FROM posts p, UNNEST(p.tags) AS t(tag)
+    WHERE p.created_at > NOW() - INTERVAL '30 days' AND 
+        t.tag IN (SELECT tag FROM recent_tags)
+    GROUP BY p.user_id
+),
+popularity AS (
+    SELECT follow_id AS candidate_id, COUNT(*) AS follower_count
+    FROM follows
+    GROUP BY follow_id
+)
+SELECT u.id, u.username,
+    COALESCE(m.mutual_count, 0)::BIGINT AS mutual_count,
+    COALESCE(st.shared_tag_count, 0)::BIGINT AS shared_tag_count,
+    COALESCE(pop.follower_count, 0)::BIGINT AS follower_count,
+    (
+        COALESCE(m.mutual_count, 0) * 3 + 
+        COALESCE(st.shared_tag_count, 0) * 2 + 
+        LN(1 + COALESCE(pop.follower_count, 0))
+    )::FLOAT AS score
+FROM users u
+LEFT JOIN mutuals m ON m.candidate_id = u.id
+LEFT JOIN shared_tags st ON st.candidate_id = u.id
+LEFT JOIN popularity pop ON pop.candidate_id = u.id
+WHERE 
+    u.id <> $1 AND 
+    u.verified AND 
+    u.id NOT IN (SELECT follow_id FROM following) AND 
+    NOT EXISTS (
+        SELECT 1 FROM follow_requests fr 
+        WHERE fr.user_id = $1 AND fr.follow_id = u.id
+    )
+ORDER BY score DESC, u.created_at DESC
+LIMIT $2;
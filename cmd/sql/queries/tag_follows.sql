@@ -0,0 +1,20 @@
+-- name: FollowTag :exec
+INSERT 
+INTO tag_follows (user_id, tag) 
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING;
+
+-- name: UnfollowTag :exec
+DELETE FROM tag_follows 
+WHERE user_id = $1 AND tag = $2;
+
+-- name: GetFollowedTags :many
+SELECT tag 
+FROM tag_follows 
+WHERE user_id = $1 
+ORDER BY created_at DESC;
+
+-- name: GetTagFollowerIds :many
+SELECT DISTINCT user_id 
+FROM tag_follows 
+WHERE tag = ANY(LOWER(sqlc.arg('tags')::TEXT)::TEXT[]);
@@ -0,0 +1,15 @@
+-- name: GetTagActivity :many
+SELECT LOWER(t.tag)::TEXT AS tag,
+    COUNT(*) FILTER (WHERE p.created_at >= sqlc.arg('recent_since')::TIMESTAMPTZ) AS recent_count,
+    COUNT(*) FILTER (WHERE p.created_at < sqlc.arg('recent_since')::TIMESTAMPTZ) AS baseline_count
+FROM posts p
+JOIN users u ON p.user_id = u.id
+CROSS JOIN LATERAL UNNEST(p.tags) AS t(tag)
+WHERE 
+    p.hidden_at IS NULL AND
+    u.verified AND
+    u.banned_at IS NULL AND
+    NOT u.is_private AND 
+    p.created_at >= sqlc.arg('baseline_since')::TIMESTAMPTZ
+GROUP BY LOWER(t.tag)
+HAVING COUNT(*) FILTER (WHERE p.created_at >= sqlc.arg('recent_since')::TIMESTAMPTZ) > 0;
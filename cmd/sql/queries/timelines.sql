@@ -0,0 +1,46 @@
+-- name: GetTimelineSeed :many
+SELECT p.id, p.created_at
+FROM posts p
+WHERE 
+    p.hidden_at IS NULL AND (
+        p.user_id = sqlc.arg('user_id') OR 
+        p.user_id IN (
+            SELECT f.follow_id 
+            FROM follows f
+            WHERE f.user_id = sqlc.arg('user_id') AND (
+                SELECT COUNT(*) FROM follows ff WHERE ff.follow_id = f.follow_id
+            ) < sqlc.arg('celebrity_threshold')::BIGINT
+        )
+    )
+ORDER BY p.created_at DESC, p.id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: GetFanOutOnReadPosts :many
+SELECT p.id, p.created_at
+FROM posts p
+JOIN users u ON p.user_id = u.id
+LEFT JOIN follows f ON f.user_id = sqlc.arg('user_id') AND f.follow_id = p.user_id
+WHERE 
+    p.hidden_at IS NULL AND
+    u.banned_at IS NULL AND
+    p.user_id <> sqlc.arg('user_id') AND
+    (
+        (f.follow_id IS NOT NULL AND (
+            SELECT COUNT(*) FROM follows ff WHERE ff.follow_id = p.user_id
+        ) >= sqlc.arg('celebrity_threshold')::BIGINT) OR
+        (f.follow_id IS NULL AND NOT u.is_private AND EXISTS (
+            SELECT 1 FROM tag_follows tf 
+            WHERE tf.user_id = sqlc.arg('user_id') AND tf.tag = ANY(LOWER(p.tags::TEXT)::TEXT[])
+        ))
+    ) AND
+    (
+        sqlc.narg('cursor_created_at')::TIMESTAMPTZ IS NULL OR
+        (sqlc.arg('ascending')::BOOLEAN AND (p.created_at, p.id) > (sqlc.narg('cursor_created_at')::TIMESTAMPTZ, sqlc.narg('cursor_id')::UUID)) OR
+        (NOT sqlc.arg('ascending')::BOOLEAN AND (p.created_at, p.id) < (sqlc.narg('cursor_created_at')::TIMESTAMPTZ, sqlc.narg('cursor_id')::UUID))
+    )
+ORDER BY 
+    CASE WHEN sqlc.arg('ascending')::BOOLEAN THEN p.created_at END ASC,
+    CASE WHEN sqlc.arg('ascending')::BOOLEAN THEN p.id END ASC,
+    p.created_at DESC, 
+    p.id DESC
+LIMIT sqlc.arg('limit');
\ No newline at end of file
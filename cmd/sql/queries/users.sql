@@ -27,4 +27,31 @@ WHERE id = $1;
 -- name: DeleteUser :exec
 DELETE
 FROM users
-WHERE id = $1;
\ No newline at end of file
+WHERE id = $1;
+
+-- name: UpdateUserPrivacy :exec
+UPDATE users
+SET is_private = $2
+WHERE id = $1;
+
+-- name: GetVerifiedUserIds :many
+SELECT id
+FROM users
+WHERE verified
+ORDER BY created_at
+LIMIT $1 OFFSET $2;
+
+-- name: SuspendUser :exec
+UPDATE users
+SET suspended_until = $2, suspension_reason = $3
+WHERE id = $1;
+
+-- name: BanUser :exec
+UPDATE users
+SET banned_at = NOW(), ban_reason = $2
+WHERE id = $1;
+
+-- name: ReinstateUser :exec
+UPDATE users
+SET suspended_until = NULL, suspension_reason = '', banned_at = NULL, ban_reason = ''
+WHERE id = $1;
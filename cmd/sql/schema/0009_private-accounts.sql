@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE IF EXISTS users
+ADD COLUMN IF NOT EXISTS
+is_private BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE IF NOT EXISTS follow_requests (
+  user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  follow_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  created_at TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  PRIMARY KEY (user_id, follow_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_follow_requests_follow_id ON follow_requests (follow_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_follow_requests_follow_id;
+DROP TABLE IF EXISTS follow_requests;
+ALTER TABLE users DROP COLUMN IF EXISTS is_private;
+-- +goose StatementEnd
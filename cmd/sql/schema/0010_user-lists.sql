@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS lists (
+  id UUID DEFAULT gen_random_uuid() PRIMARY KEY,
+  user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  name VARCHAR(100) NOT NULL,
+  is_private BOOLEAN NOT NULL DEFAULT FALSE,
+  created_at TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  UNIQUE (user_id, name)
+);
+
+CREATE TABLE IF NOT EXISTS list_members (
+  list_id UUID NOT NULL REFERENCES lists(id) ON DELETE CASCADE,
+  member_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  created_at TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  PRIMARY KEY (list_id, member_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_list_members_member_id ON list_members (member_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_list_members_member_id;
+DROP TABLE IF EXISTS list_members;
+DROP TABLE IF EXISTS lists;
+-- +goose StatementEnd
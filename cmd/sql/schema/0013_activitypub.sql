@@ -0,0 +1,60 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS actor_keys (
+  user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+  public_key_pem TEXT NOT NULL,
+  private_key_pem TEXT NOT NULL,
+  created_at TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS remote_followers (
+  user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  actor_id TEXT NOT NULL,
+  inbox TEXT NOT NULL,
+  shared_inbox TEXT,
+  created_at TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  PRIMARY KEY (user_id, actor_id)
+);
+
+CREATE TABLE IF NOT EXISTS remote_likes (
+  post_id UUID NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+  actor_id TEXT NOT NULL,
+  created_at TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  PRIMARY KEY (post_id, actor_id)
+);
+
+CREATE TABLE IF NOT EXISTS remote_replies (
+  id TEXT PRIMARY KEY,
+  post_id UUID NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+  actor_id TEXT NOT NULL,
+  content TEXT NOT NULL,
+  published_at TIMESTAMP(0) WITH TIME ZONE NOT NULL,
+  created_at TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS delivery_jobs (
+  id BIGSERIAL PRIMARY KEY,
+  sender_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  inbox TEXT NOT NULL,
+  activity JSONB NOT NULL,
+  attempts INT NOT NULL DEFAULT 0,
+  next_attempt_at TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  last_error TEXT,
+  failed_at TIMESTAMP(0) WITH TIME ZONE,
+  created_at TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_remote_replies_post_id ON remote_replies (post_id);
+CREATE INDEX IF NOT EXISTS idx_delivery_jobs_due ON delivery_jobs (next_attempt_at) WHERE failed_at IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_delivery_jobs_due;
+DROP INDEX IF EXISTS idx_remote_replies_post_id;
+DROP TABLE IF EXISTS delivery_jobs;
+DROP TABLE IF EXISTS remote_replies;
+DROP TABLE IF EXISTS remote_likes;
+DROP TABLE IF EXISTS remote_followers;
+DROP TABLE IF EXISTS actor_keys;
+-- +goose StatementEnd
@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION content_hash(content TEXT) RETURNS TEXT 
+LANGUAGE SQL IMMUTABLE PARALLEL SAFE 
+AS $$ SELECT md5(lower(regexp_replace(btrim(content), '\s+', ' ', 'g'))) $$;
+
+CREATE INDEX IF NOT EXISTS idx_posts_content_hash ON posts (content_hash(title || ' ' || content), created_at);
+CREATE INDEX IF NOT EXISTS idx_comments_user_content_hash ON comments (user_id, content_hash(content), created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_comments_user_content_hash;
+DROP INDEX IF EXISTS idx_posts_content_hash;
+DROP FUNCTION IF EXISTS content_hash(TEXT);
+-- +goose StatementEnd
@@ -0,0 +1,49 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS hidden_at TIMESTAMP(0) WITH TIME ZONE;
+ALTER TABLE comments ADD COLUMN IF NOT EXISTS hidden_at TIMESTAMP(0) WITH TIME ZONE;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS suspended_until TIMESTAMP(0) WITH TIME ZONE;
+
+CREATE TABLE IF NOT EXISTS reports (
+  id UUID DEFAULT gen_random_uuid() PRIMARY KEY,
+  reporter_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  target_type VARCHAR(16) NOT NULL CHECK (target_type IN ('post', 'comment', 'user')),
+  target_id UUID NOT NULL,
+  target_user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  reason VARCHAR(32) NOT NULL,
+  details TEXT NOT NULL DEFAULT '',
+  status VARCHAR(16) NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'claimed', 'resolved', 'dismissed')),
+  moderator_id UUID REFERENCES users(id) ON DELETE SET NULL,
+  actions TEXT[] NOT NULL DEFAULT '{}',
+  note TEXT NOT NULL DEFAULT '',
+  created_at TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  claimed_at TIMESTAMP(0) WITH TIME ZONE,
+  closed_at TIMESTAMP(0) WITH TIME ZONE
+);
+
+-- a user can only have one pending report on the same target
+CREATE UNIQUE INDEX IF NOT EXISTS idx_reports_pending_reporter_target 
+ON reports (reporter_id, target_type, target_id) WHERE status IN ('open', 'claimed');
+CREATE INDEX IF NOT EXISTS idx_reports_status_created_at ON reports (status, created_at);
+CREATE INDEX IF NOT EXISTS idx_reports_target ON reports (target_type, target_id);
+
+CREATE TABLE IF NOT EXISTS user_warnings (
+  id BIGSERIAL PRIMARY KEY,
+  user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  report_id UUID REFERENCES reports(id) ON DELETE SET NULL,
+  moderator_id UUID REFERENCES users(id) ON DELETE SET NULL,
+  reason TEXT NOT NULL,
+  created_at TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_warnings_user_id ON user_warnings (user_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS user_warnings;
+DROP TABLE IF EXISTS reports;
+ALTER TABLE users DROP COLUMN IF EXISTS suspended_until;
+ALTER TABLE comments DROP COLUMN IF EXISTS hidden_at;
+ALTER TABLE posts DROP COLUMN IF EXISTS hidden_at;
+-- +goose StatementEnd
@@ -0,0 +1,30 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS filter_rules (
+  id UUID DEFAULT gen_random_uuid() PRIMARY KEY,
+  kind VARCHAR(16) NOT NULL CHECK (kind IN ('word', 'regex', 'domain')),
+  pattern TEXT NOT NULL,
+  action VARCHAR(16) NOT NULL CHECK (action IN ('reject', 'hold', 'mask')),
+  created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+  created_at TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  UNIQUE (kind, pattern)
+);
+
+CREATE TABLE IF NOT EXISTS muted_words (
+  user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  word VARCHAR(100) NOT NULL,
+  created_at TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  PRIMARY KEY (user_id, word)
+);
+
+-- content held by a filter rule is queued for review without a reporter
+ALTER TABLE reports ALTER COLUMN reporter_id DROP NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM reports WHERE reporter_id IS NULL;
+ALTER TABLE reports ALTER COLUMN reporter_id SET NOT NULL;
+DROP TABLE IF EXISTS muted_words;
+DROP TABLE IF EXISTS filter_rules;
+-- +goose StatementEnd
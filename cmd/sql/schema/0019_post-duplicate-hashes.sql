@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_posts_content_hash;
+CREATE INDEX IF NOT EXISTS idx_posts_user_content_hash ON posts (user_id, content_hash(title), content_hash(content), created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_posts_user_content_hash;
+CREATE INDEX IF NOT EXISTS idx_posts_content_hash ON posts (content_hash(title || ' ' || content), created_at);
+-- +goose StatementEnd
@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_posts_created_at;
+CREATE INDEX IF NOT EXISTS idx_posts_created_at_id ON posts (created_at, id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_posts_created_at_id;
+CREATE INDEX IF NOT EXISTS idx_posts_created_at ON posts (created_at DESC);
+-- +goose StatementEnd
@@ -0,0 +1,282 @@
+package activitypub
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// remoteServer stands in for a remote instance with one actor whose inbox
+// verifies signatures the way Mastodon does.
+type remoteServer struct {
+	*httptest.Server
+	client   *Client
+	received chan Activity
+}
+
+func newRemoteServer(t *testing.T, publicPEM string) *remoteServer {
+	t.Helper()
+
+	rs := &remoteServer{
+		client:   NewClient(ClientConfig{Timeout: time.Second, UserAgent: "test", AllowInsecure: true, KeyCacheTTL: time.Minute}),
+		received: make(chan Activity, 1),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /users/alice", func(w http.ResponseWriter, r *http.Request) {
+		actor := Actor{
+			ID:                rs.URL + "/users/alice",
+			Type:              "Person",
+			PreferredUsername: "alice",
+			Inbox:             rs.URL + "/users/alice/inbox",
+			PublicKey: PublicKey{
+				ID:           rs.URL + "/users/alice#main-key",
+				Owner:        rs.URL + "/users/alice",
+				PublicKeyPem: publicPEM,
+			},
+		}
+		w.Header().Set("Content-Type", ContentType)
+		json.NewEncoder(w).Encode(actor)
+	})
+	mux.HandleFunc("POST /users/alice/inbox", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		_, err := VerifyRequest(r, body, time.Minute, func(keyID string) (*rsa.PublicKey, error) {
+			_, key, err := rs.client.PublicKey(r.Context(), keyID)
+			return key, err
+		})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		var activity Activity
+		if err := json.Unmarshal(body, &activity); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		rs.received <- activity
+		w.WriteHeader(http.StatusAccepted)
+	})
+	mux.HandleFunc("GET /users/moved", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/users/alice", http.StatusFound)
+	})
+	mux.HandleFunc("POST /users/gone/inbox", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusGone)
+	})
+
+	rs.Server = httptest.NewServer(mux)
+	t.Cleanup(rs.Close)
+
+	return rs
+}
+
+func TestDeliver(t *testing.T) {
+	publicPEM, privatePEM, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := ParsePrivateKey(privatePEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rs := newRemoteServer(t, publicPEM)
+	keyID := rs.URL + "/users/alice#main-key"
+	ctx := context.Background()
+
+	follow, err := NewActivity(rs.URL+"/follows/1", "Follow", rs.URL+"/users/alice", "https://example.com/users/bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := json.Marshal(follow)
+
+	t.Run("signed activity is accepted", func(t *testing.T) {
+		if err := rs.client.Deliver(ctx, rs.URL+"/users/alice/inbox", body, keyID, key); err != nil {
+			t.Fatalf("expected delivery to succeed; got %v", err)
+		}
+
+		got := <-rs.received
+		if got.Type != "Follow" || got.ObjectID() != "https://example.com/users/bob" {
+			t.Errorf("unexpected activity %+v", got)
+		}
+	})
+
+	t.Run("signature from another key is rejected", func(t *testing.T) {
+		_, otherPEM, _ := GenerateKey()
+		other, _ := ParsePrivateKey(otherPEM)
+
+		err := rs.client.Deliver(ctx, rs.URL+"/users/alice/inbox", body, keyID, other)
+
+		var derr *DeliveryError
+		if !errors.As(err, &derr) || derr.StatusCode != http.StatusUnauthorized {
+			t.Fatalf("expected a 401 delivery error; got %v", err)
+		}
+	})
+
+	t.Run("gone inbox is a permanent failure", func(t *testing.T) {
+		err := rs.client.Deliver(ctx, rs.URL+"/users/gone/inbox", body, keyID, key)
+
+		var derr *DeliveryError
+		if !errors.As(err, &derr) || !derr.Permanent() {
+			t.Fatalf("expected a permanent delivery error; got %v", err)
+		}
+	})
+}
+
+func TestClientRefusesPrivateAddresses(t *testing.T) {
+	publicPEM, privatePEM, _ := GenerateKey()
+	key, _ := ParsePrivateKey(privatePEM)
+
+	// the remote server listens on loopback, which only the insecure
+	// client may reach
+	rs := newRemoteServer(t, publicPEM)
+	port := rs.Listener.Addr().(*net.TCPAddr).Port
+
+	client := NewClient(ClientConfig{Timeout: time.Second, UserAgent: "test", KeyCacheTTL: time.Minute})
+	ctx := context.Background()
+
+	t.Run("private key ids are refused", func(t *testing.T) {
+		for _, keyID := range []string{
+			"https://10.0.0.1/users/alice#main-key",
+			"https://169.254.169.254/latest/meta-data#main-key",
+			"https://[::1]/users/alice#main-key",
+			"https://[::ffff:192.168.1.1]/users/alice#main-key",
+			fmt.Sprintf("https://127.0.0.1:%d/users/alice#main-key", port),
+			// names are checked after they are resolved
+			fmt.Sprintf("https://localhost:%d/users/alice#main-key", port),
+		} {
+			if _, _, err := client.PublicKey(ctx, keyID); !errors.Is(err, ErrForbiddenAddress) {
+				t.Errorf("%s: expected ErrForbiddenAddress; got %v", keyID, err)
+			}
+		}
+	})
+
+	t.Run("private inboxes are refused", func(t *testing.T) {
+		for _, inbox := range []string{
+			"https://192.168.0.10/inbox",
+			"https://100.64.0.1/inbox",
+			fmt.Sprintf("https://localhost:%d/users/alice/inbox", port),
+		} {
+			err := client.Deliver(ctx, inbox, []byte(`{}`), "https://social.example/users/bob#main-key", key)
+			if !errors.Is(err, ErrForbiddenAddress) {
+				t.Errorf("%s: expected ErrForbiddenAddress; got %v", inbox, err)
+			}
+		}
+	})
+
+	t.Run("plain http is refused", func(t *testing.T) {
+		if _, _, err := client.PublicKey(ctx, "http://remote.example/users/alice#main-key"); err == nil {
+			t.Error("expected an http key id to be refused")
+		}
+		if err := client.Deliver(ctx, "http://remote.example/inbox", []byte(`{}`), "key", key); err == nil {
+			t.Error("expected an http inbox to be refused")
+		}
+	})
+
+	t.Run("redirects are not followed", func(t *testing.T) {
+		_, err := rs.client.FetchActor(ctx, rs.URL+"/users/moved")
+
+		var derr *DeliveryError
+		if !errors.As(err, &derr) || derr.StatusCode != http.StatusFound {
+			t.Fatalf("expected the redirect to be returned; got %v", err)
+		}
+	})
+}
+
+func TestPublicKeyCache(t *testing.T) {
+	publicPEM, _, _ := GenerateKey()
+	rs := newRemoteServer(t, publicPEM)
+	keyID := rs.URL + "/users/alice#main-key"
+	ctx := context.Background()
+
+	_, first, err := rs.client.PublicKey(ctx, keyID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// with the server gone only the cache can answer
+	rs.Close()
+
+	_, cached, err := rs.client.PublicKey(ctx, keyID)
+	if err != nil {
+		t.Fatalf("expected the key to be cached; got %v", err)
+	}
+	if !cached.Equal(first) {
+		t.Error("expected the cached key to match the fetched one")
+	}
+
+	if _, _, err := rs.client.PublicKey(ctx, rs.URL+"/users/bob#main-key"); err == nil {
+		t.Error("expected uncached keys to be fetched")
+	}
+}
+
+func TestVerifyRequest(t *testing.T) {
+	publicPEM, privatePEM, _ := GenerateKey()
+	key, _ := ParsePrivateKey(privatePEM)
+	pub, _ := ParsePublicKey(publicPEM)
+
+	keyFunc := func(string) (*rsa.PublicKey, error) { return pub, nil }
+	body := []byte(`{"type":"Like"}`)
+
+	newSigned := func() *http.Request {
+		r := httptest.NewRequest(http.MethodPost, "https://social.example/v1/ap/users/bob/inbox", nil)
+		if err := SignRequest(r, body, "https://remote.example/users/alice#main-key", key); err != nil {
+			t.Fatal(err)
+		}
+		return r
+	}
+
+	t.Run("valid signature", func(t *testing.T) {
+		keyID, err := VerifyRequest(newSigned(), body, time.Minute, keyFunc)
+		if err != nil || keyID != "https://remote.example/users/alice#main-key" {
+			t.Fatalf("expected signature to verify; got %q, %v", keyID, err)
+		}
+	})
+
+	t.Run("tampered body", func(t *testing.T) {
+		_, err := VerifyRequest(newSigned(), []byte(`{"type":"Undo"}`), time.Minute, keyFunc)
+		if !errors.Is(err, ErrInvalidSignature) {
+			t.Fatalf("expected ErrInvalidSignature; got %v", err)
+		}
+	})
+
+	t.Run("tampered target", func(t *testing.T) {
+		r := newSigned()
+		r.URL.Path = "/v1/ap/users/carol/inbox"
+
+		_, err := VerifyRequest(r, body, time.Minute, keyFunc)
+		if !errors.Is(err, ErrInvalidSignature) {
+			t.Fatalf("expected ErrInvalidSignature; got %v", err)
+		}
+	})
+
+	t.Run("stale date", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodPost, "https://social.example/v1/ap/users/bob/inbox", nil)
+		r.Header.Set("Date", time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat))
+		SignRequest(r, body, "key", key)
+
+		_, err := VerifyRequest(r, body, time.Minute, keyFunc)
+		if !errors.Is(err, ErrInvalidSignature) {
+			t.Fatalf("expected ErrInvalidSignature; got %v", err)
+		}
+	})
+
+	t.Run("unsigned", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodPost, "https://social.example/v1/ap/users/bob/inbox", nil)
+
+		_, err := VerifyRequest(r, body, time.Minute, keyFunc)
+		if !errors.Is(err, ErrMissingSignature) {
+			t.Fatalf("expected ErrMissingSignature; got %v", err)
+		}
+	})
+}
@@ -0,0 +1,314 @@
+package activitypub
+
+import (
+	"bytes"
+	"context"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const (
+	// maxDocumentSize bounds documents read from remote servers.
+	maxDocumentSize = 1 << 20
+	// maxCachedKeys bounds the public key cache.
+	maxCachedKeys = 10000
+)
+
+// ErrForbiddenAddress is returned for URLs that do not lead to a public
+// address on the internet, such as loopback, private networks or cloud
+// metadata services. Key ids and inboxes come from remote servers, so
+// following them blindly would let anyone make requests on our behalf.
+var ErrForbiddenAddress = errors.New("activitypub: address is not public")
+
+// blockedPrefixes are not caught by the netip classification methods but
+// do not lead to the public internet either.
+var blockedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+	netip.MustParsePrefix("64:ff9b:1::/48"),
+	netip.MustParsePrefix("2002::/16"),
+}
+
+func publicAddr(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	if !ip.IsGlobalUnicast() || ip.IsPrivate() {
+		return false
+	}
+	for _, p := range blockedPrefixes {
+		if p.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// refusePrivate is a net.Dialer Control hook. It runs after DNS resolution
+// for every address tried, so names resolving to private addresses are
+// refused as well.
+func refusePrivate(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil || !publicAddr(ip) {
+		return fmt.Errorf("%w: %s", ErrForbiddenAddress, host)
+	}
+	return nil
+}
+
+// DeliveryError is returned when a remote server refuses an activity.
+type DeliveryError struct {
+	StatusCode int
+}
+
+func (e *DeliveryError) Error() string {
+	return fmt.Sprintf("activitypub: remote server responded with %d", e.StatusCode)
+}
+
+// Permanent reports whether retrying the delivery is pointless. Client
+// errors other than rate limiting will not go away on their own.
+func (e *DeliveryError) Permanent() bool {
+	return e.StatusCode >= 400 && e.StatusCode < 500 &&
+		e.StatusCode != http.StatusRequestTimeout && e.StatusCode != http.StatusTooManyRequests
+}
+
+type ClientConfig struct {
+	Timeout   time.Duration
+	UserAgent string
+	// AllowInsecure permits plain http and private addresses, so instances
+	// on a development machine can federate with each other.
+	AllowInsecure bool
+	// KeyCacheTTL is how long fetched public keys are reused before they
+	// are fetched again, e.g. to pick up a rotated key.
+	KeyCacheTTL time.Duration
+}
+
+type Client struct {
+	http          *http.Client
+	userAgent     string
+	allowInsecure bool
+	keyTTL        time.Duration
+
+	mu   sync.Mutex
+	keys map[string]cachedKey
+}
+
+type cachedKey struct {
+	actor   *Actor
+	key     *rsa.PublicKey
+	expires time.Time
+}
+
+func NewClient(cfg ClientConfig) *Client {
+	dialer := &net.Dialer{Timeout: cfg.Timeout}
+	if !cfg.AllowInsecure {
+		dialer.Control = refusePrivate
+	}
+
+	return &Client{
+		http: &http.Client{
+			Timeout: cfg.Timeout,
+			// proxies from the environment would hide the dialed address
+			Transport: &http.Transport{
+				DialContext:         dialer.DialContext,
+				ForceAttemptHTTP2:   true,
+				MaxIdleConns:        100,
+				IdleConnTimeout:     90 * time.Second,
+				TLSHandshakeTimeout: 10 * time.Second,
+			},
+			// redirects could lead anywhere, servers must use their real URLs
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		userAgent:     cfg.UserAgent,
+		allowInsecure: cfg.AllowInsecure,
+		keyTTL:        cfg.KeyCacheTTL,
+		keys:          make(map[string]cachedKey),
+	}
+}
+
+// remoteURL checks that raw is an absolute URL we may send requests to.
+// Addresses are checked again when dialing, after names are resolved.
+func (c *Client) remoteURL(raw string) (*url.URL, error) {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" || u.User != nil {
+		return nil, fmt.Errorf("activitypub: invalid url %q", raw)
+	}
+
+	switch {
+	case u.Scheme == "https":
+	case u.Scheme == "http" && c.allowInsecure:
+	default:
+		return nil, fmt.Errorf("activitypub: url %q must use https", raw)
+	}
+
+	if ip, err := netip.ParseAddr(strings.Trim(u.Hostname(), "[]")); err == nil && !c.allowInsecure && !publicAddr(ip) {
+		return nil, fmt.Errorf("%w: %s", ErrForbiddenAddress, ip)
+	}
+
+	return u, nil
+}
+
+// FetchActor retrieves the actor document at iri. A key id like
+// https://example.com/users/bob#main-key resolves to its owner.
+func (c *Client) FetchActor(ctx context.Context, iri string) (*Actor, error) {
+	u, err := c.remoteURL(iri)
+	if err != nil {
+		return nil, err
+	}
+	u.Fragment = ""
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", AcceptHeader)
+	req.Header.Set("User-Agent", c.userAgent)
+
+	res, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, &DeliveryError{StatusCode: res.StatusCode}
+	}
+
+	var actor Actor
+	if err := json.NewDecoder(io.LimitReader(res.Body, maxDocumentSize)).Decode(&actor); err != nil {
+		return nil, fmt.Errorf("activitypub: decoding actor: %w", err)
+	}
+
+	if actor.ID != u.String() || actor.Inbox == "" {
+		return nil, fmt.Errorf("activitypub: %q is not a valid actor", iri)
+	}
+
+	return &actor, nil
+}
+
+// PublicKey fetches the key behind keyID and checks that the actor owns it.
+// Keys are cached, so busy remote servers do not cost a fetch per activity.
+func (c *Client) PublicKey(ctx context.Context, keyID string) (*Actor, *rsa.PublicKey, error) {
+	if cached, ok := c.cachedKey(keyID); ok {
+		return cached.actor, cached.key, nil
+	}
+
+	actor, err := c.FetchActor(ctx, keyID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if actor.PublicKey.ID != keyID || actor.PublicKey.Owner != actor.ID {
+		return nil, nil, fmt.Errorf("%w: key %q is not owned by %q", ErrInvalidSignature, keyID, actor.ID)
+	}
+
+	key, err := ParsePublicKey(actor.PublicKey.PublicKeyPem)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	c.cacheKey(keyID, cachedKey{actor: actor, key: key, expires: time.Now().Add(c.keyTTL)})
+	return actor, key, nil
+}
+
+func (c *Client) cachedKey(keyID string) (cachedKey, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cached, ok := c.keys[keyID]
+	if !ok || time.Now().After(cached.expires) {
+		return cachedKey{}, false
+	}
+	return cached, true
+}
+
+func (c *Client) cacheKey(keyID string, cached cachedKey) {
+	if c.keyTTL <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.keys) >= maxCachedKeys {
+		now := time.Now()
+		for id, k := range c.keys {
+			if now.After(k.expires) {
+				delete(c.keys, id)
+			}
+		}
+		// still full of live keys, start over rather than grow
+		if len(c.keys) >= maxCachedKeys {
+			clear(c.keys)
+		}
+	}
+
+	c.keys[keyID] = cached
+}
+
+// Deliver posts a signed activity to a remote inbox. Inboxes are named by
+// remote actors, so they are held to the same rules as fetched URLs.
+func (c *Client) Deliver(ctx context.Context, inbox string, activity []byte, keyID string, key *rsa.PrivateKey) error {
+	u, err := c.remoteURL(inbox)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), bytes.NewReader(activity))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", ContentType)
+	req.Header.Set("Accept", AcceptHeader)
+	req.Header.Set("User-Agent", c.userAgent)
+
+	if err := SignRequest(req, activity, keyID, key); err != nil {
+		return err
+	}
+
+	res, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(res.Body, maxDocumentSize))
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return &DeliveryError{StatusCode: res.StatusCode}
+	}
+
+	return nil
+}
+
+// ParseAccount splits an acct: WebFinger resource into user and domain.
+func ParseAccount(resource string) (user, domain string, ok bool) {
+	acct, found := strings.CutPrefix(resource, "acct:")
+	if !found {
+		return "", "", false
+	}
+	acct = strings.TrimPrefix(acct, "@")
+
+	user, domain, ok = strings.Cut(acct, "@")
+	if !ok || user == "" || domain == "" {
+		return "", "", false
+	}
+	return user, domain, true
+}
@@ -0,0 +1,164 @@
+package activitypub
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// signedHeaders are covered by outgoing signatures. This is the set
+// Mastodon requires for POST requests.
+var signedHeaders = []string{"(request-target)", "host", "date", "digest"}
+
+// Digest returns the RFC 3230 Digest header value of body.
+func Digest(body []byte) string {
+	sum := sha256.Sum256(body)
+	return "SHA-256=" + base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// SignRequest signs r with an rsa-sha256 HTTP Signature (draft-cavage-12).
+// The Date, Host and Digest headers are set when missing.
+func SignRequest(r *http.Request, body []byte, keyID string, key *rsa.PrivateKey) error {
+	if r.Header.Get("Date") == "" {
+		r.Header.Set("Date", time.Now().UTC().Format(http.TimeFormat))
+	}
+	if r.Host == "" {
+		r.Host = r.URL.Host
+	}
+	r.Header.Set("Digest", Digest(body))
+
+	hashed := sha256.Sum256([]byte(signingString(r, signedHeaders)))
+	sig, err := rsa.SignPKCS1v15(nil, key, crypto.SHA256, hashed[:])
+	if err != nil {
+		return err
+	}
+
+	r.Header.Set("Signature", fmt.Sprintf(
+		`keyId="%s",algorithm="rsa-sha256",headers="%s",signature="%s"`,
+		keyID, strings.Join(signedHeaders, " "), base64.StdEncoding.EncodeToString(sig),
+	))
+
+	return nil
+}
+
+var (
+	ErrMissingSignature = errors.New("activitypub: request is not signed")
+	ErrInvalidSignature = errors.New("activitypub: invalid signature")
+)
+
+// KeyFunc resolves the public key of a signature's keyId.
+type KeyFunc func(keyID string) (*rsa.PublicKey, error)
+
+// VerifyRequest checks the HTTP Signature of r against body and returns the
+// keyId it was made with. Requests with a date further than maxSkew from
+// now are rejected to limit replays.
+func VerifyRequest(r *http.Request, body []byte, maxSkew time.Duration, keyFunc KeyFunc) (string, error) {
+	header := r.Header.Get("Signature")
+	if header == "" {
+		return "", ErrMissingSignature
+	}
+
+	params := parseSignature(header)
+	keyID, headers, signature := params["keyId"], strings.Fields(params["headers"]), params["signature"]
+	if keyID == "" || signature == "" {
+		return "", fmt.Errorf("%w: malformed signature header", ErrInvalidSignature)
+	}
+	if alg := params["algorithm"]; alg != "" && alg != "rsa-sha256" && alg != "hs2019" {
+		return "", fmt.Errorf("%w: unsupported algorithm %q", ErrInvalidSignature, alg)
+	}
+	if len(headers) == 0 {
+		headers = []string{"date"}
+	}
+
+	// the signature must cover the headers that make it unique to this request
+	required := []string{"(request-target)", "host", "date"}
+	if r.Method == http.MethodPost {
+		required = append(required, "digest")
+	}
+	for _, h := range required {
+		if !containsFold(headers, h) {
+			return "", fmt.Errorf("%w: %s is not signed", ErrInvalidSignature, h)
+		}
+	}
+
+	date, err := http.ParseTime(r.Header.Get("Date"))
+	if err != nil {
+		return "", fmt.Errorf("%w: invalid date", ErrInvalidSignature)
+	}
+	if skew := time.Since(date); skew > maxSkew || skew < -maxSkew {
+		return "", fmt.Errorf("%w: date is outside the allowed window", ErrInvalidSignature)
+	}
+
+	if r.Method == http.MethodPost {
+		got, want := r.Header.Get("Digest"), Digest(body)
+		if subtle.ConstantTimeCompare([]byte(got), []byte(want)) != 1 {
+			return "", fmt.Errorf("%w: digest does not match body", ErrInvalidSignature)
+		}
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return "", fmt.Errorf("%w: signature is not base64", ErrInvalidSignature)
+	}
+
+	key, err := keyFunc(keyID)
+	if err != nil {
+		return "", err
+	}
+
+	hashed := sha256.Sum256([]byte(signingString(r, headers)))
+	if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, hashed[:], sig); err != nil {
+		return "", ErrInvalidSignature
+	}
+
+	return keyID, nil
+}
+
+func signingString(r *http.Request, headers []string) string {
+	lines := make([]string, 0, len(headers))
+	for _, h := range headers {
+		h = strings.ToLower(h)
+
+		var value string
+		switch h {
+		case "(request-target)":
+			value = strings.ToLower(r.Method) + " " + r.URL.RequestURI()
+		case "host":
+			value = r.Host
+		default:
+			value = strings.Join(r.Header.Values(h), ", ")
+		}
+
+		lines = append(lines, h+": "+value)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// parseSignature splits a Signature header into its key="value" params.
+func parseSignature(header string) map[string]string {
+	params := make(map[string]string)
+	for _, part := range strings.Split(header, ",") {
+		k, v, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			continue
+		}
+		params[k] = strings.Trim(v, `"`)
+	}
+	return params
+}
+
+func containsFold(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,164 @@
+// Package activitypub implements the parts of ActivityPub, WebFinger and
+// HTTP Signatures needed to federate users and posts with servers such as
+// Mastodon.
+package activitypub
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+const (
+	ContextActivityStreams = "https://www.w3.org/ns/activitystreams"
+	ContextSecurity        = "https://w3id.org/security/v1"
+
+	// Public is the special collection addressing everyone.
+	Public = "https://www.w3.org/ns/activitystreams#Public"
+
+	ContentType    = "application/activity+json"
+	JRDContentType = "application/jrd+json"
+)
+
+// AcceptHeader asks remote servers for ActivityPub documents.
+const AcceptHeader = `application/activity+json, application/ld+json; profile="https://www.w3.org/ns/activitystreams"`
+
+type PublicKey struct {
+	ID           string `json:"id"`
+	Owner        string `json:"owner"`
+	PublicKeyPem string `json:"publicKeyPem"`
+}
+
+type Endpoints struct {
+	SharedInbox string `json:"sharedInbox,omitempty"`
+}
+
+type Actor struct {
+	Context           any        `json:"@context,omitempty"`
+	ID                string     `json:"id"`
+	Type              string     `json:"type"`
+	PreferredUsername string     `json:"preferredUsername"`
+	Name              string     `json:"name,omitempty"`
+	URL               string     `json:"url,omitempty"`
+	Inbox             string     `json:"inbox"`
+	Outbox            string     `json:"outbox,omitempty"`
+	Followers         string     `json:"followers,omitempty"`
+	Published         string     `json:"published,omitempty"`
+	Endpoints         *Endpoints `json:"endpoints,omitempty"`
+	PublicKey         PublicKey  `json:"publicKey"`
+}
+
+// SharedInbox returns the inbox shared by all actors of the server, or the
+// actor's own inbox when the server has none.
+func (a *Actor) SharedInbox() string {
+	if a.Endpoints != nil && a.Endpoints.SharedInbox != "" {
+		return a.Endpoints.SharedInbox
+	}
+	return a.Inbox
+}
+
+type Hashtag struct {
+	Type string `json:"type"`
+	Href string `json:"href"`
+	Name string `json:"name"`
+}
+
+type Note struct {
+	Context      any       `json:"@context,omitempty"`
+	ID           string    `json:"id"`
+	Type         string    `json:"type"`
+	AttributedTo string    `json:"attributedTo"`
+	Content      string    `json:"content"`
+	URL          string    `json:"url,omitempty"`
+	InReplyTo    string    `json:"inReplyTo,omitempty"`
+	Published    string    `json:"published"`
+	Updated      string    `json:"updated,omitempty"`
+	To           []string  `json:"to,omitempty"`
+	Cc           []string  `json:"cc,omitempty"`
+	Tag          []Hashtag `json:"tag,omitempty"`
+}
+
+// Activity is any activity. Object is kept raw because it is either the id
+// of an object or the object itself.
+type Activity struct {
+	Context   any             `json:"@context,omitempty"`
+	ID        string          `json:"id"`
+	Type      string          `json:"type"`
+	Actor     string          `json:"actor"`
+	Object    json.RawMessage `json:"object"`
+	To        []string        `json:"to,omitempty"`
+	Cc        []string        `json:"cc,omitempty"`
+	Published string          `json:"published,omitempty"`
+}
+
+// NewActivity wraps object, an id or a document, in an activity.
+func NewActivity(id, typ, actor string, object any) (Activity, error) {
+	raw, err := json.Marshal(object)
+	if err != nil {
+		return Activity{}, err
+	}
+
+	return Activity{
+		Context: ContextActivityStreams,
+		ID:      id,
+		Type:    typ,
+		Actor:   actor,
+		Object:  raw,
+	}, nil
+}
+
+// ObjectID returns the id of the object, whether it is embedded or not.
+func (a *Activity) ObjectID() string {
+	var id string
+	if err := json.Unmarshal(a.Object, &id); err == nil {
+		return id
+	}
+
+	var obj struct {
+		ID string `json:"id"`
+	}
+	json.Unmarshal(a.Object, &obj)
+	return obj.ID
+}
+
+// EmbeddedActivity decodes an object that is itself an activity, like the
+// Follow in an Undo.
+func (a *Activity) EmbeddedActivity() (*Activity, error) {
+	var inner Activity
+	if err := json.Unmarshal(a.Object, &inner); err != nil || inner.Type == "" {
+		return nil, errors.New("activitypub: object is not an embedded activity")
+	}
+	return &inner, nil
+}
+
+// EmbeddedNote decodes an object that is a note, like the one in a Create.
+func (a *Activity) EmbeddedNote() (*Note, error) {
+	var note Note
+	if err := json.Unmarshal(a.Object, &note); err != nil || note.Type != "Note" {
+		return nil, errors.New("activitypub: object is not an embedded note")
+	}
+	return &note, nil
+}
+
+type OrderedCollection struct {
+	Context      any    `json:"@context,omitempty"`
+	ID           string `json:"id"`
+	Type         string `json:"type"`
+	TotalItems   int64  `json:"totalItems"`
+	First        string `json:"first,omitempty"`
+	PartOf       string `json:"partOf,omitempty"`
+	Next         string `json:"next,omitempty"`
+	OrderedItems []any  `json:"orderedItems,omitempty"`
+}
+
+// WebFinger is a JSON Resource Descriptor (RFC 7033).
+type WebFinger struct {
+	Subject string          `json:"subject"`
+	Aliases []string        `json:"aliases,omitempty"`
+	Links   []WebFingerLink `json:"links"`
+}
+
+type WebFingerLink struct {
+	Rel  string `json:"rel"`
+	Type string `json:"type,omitempty"`
+	Href string `json:"href,omitempty"`
+}
@@ -0,0 +1,85 @@
+// Package audit records privileged actions and security events in the
+// append-only audit_log table.
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/JaskiratAnand/go-social/internal/store"
+	"github.com/google/uuid"
+)
+
+// Actions are named after their target and verb.
+const (
+	PostUpdate = "post.update"
+	PostDelete = "post.delete"
+
+	ReportClaim   = "report.claim"
+	ReportResolve = "report.resolve"
+	ReportDismiss = "report.dismiss"
+
+	UserSuspend   = "user.suspend"
+	UserBan       = "user.ban"
+	UserReinstate = "user.reinstate"
+
+	FilterRuleCreate = "filter_rule.create"
+	FilterRuleDelete = "filter_rule.delete"
+
+	LoginFailed  = "auth.login_failed"
+	LoginBlocked = "auth.login_blocked"
+
+	LogExport = "audit.export"
+)
+
+// Entry describes one action. Before and After are snapshots of the target
+// marshaled to JSON, left nil when there is nothing to show, such as the
+// after of a deletion.
+type Entry struct {
+	// ActorID is uuid.Nil for anonymous requests such as failed logins.
+	ActorID    uuid.UUID
+	Action     string
+	TargetType string
+	TargetID   string
+	Before     any
+	After      any
+	RequestID  string
+	IP         string
+}
+
+// Recorder is implemented by store.Queries, including those bound to a
+// transaction, so an action and its entry can be committed together.
+type Recorder interface {
+	CreateAuditLog(ctx context.Context, arg store.CreateAuditLogParams) error
+}
+
+// Record appends e to the log.
+func Record(ctx context.Context, r Recorder, e Entry) error {
+	before, err := snapshot(e.Before)
+	if err != nil {
+		return fmt.Errorf("audit %s: before: %w", e.Action, err)
+	}
+	after, err := snapshot(e.After)
+	if err != nil {
+		return fmt.Errorf("audit %s: after: %w", e.Action, err)
+	}
+
+	return r.CreateAuditLog(ctx, store.CreateAuditLogParams{
+		ActorID:    uuid.NullUUID{UUID: e.ActorID, Valid: e.ActorID != uuid.Nil},
+		Action:     e.Action,
+		TargetType: e.TargetType,
+		TargetID:   e.TargetID,
+		Before:     before,
+		After:      after,
+		RequestID:  e.RequestID,
+		Ip:         e.IP,
+	})
+}
+
+func snapshot(v any) (json.RawMessage, error) {
+	if v == nil {
+		return json.RawMessage("null"), nil
+	}
+	return json.Marshal(v)
+}
@@ -0,0 +1,111 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/JaskiratAnand/go-social/internal/store"
+	"github.com/google/uuid"
+)
+
+type recorderFunc func(store.CreateAuditLogParams) error
+
+func (f recorderFunc) CreateAuditLog(_ context.Context, arg store.CreateAuditLogParams) error {
+	return f(arg)
+}
+
+func TestRecord(t *testing.T) {
+	var got store.CreateAuditLogParams
+	r := recorderFunc(func(arg store.CreateAuditLogParams) error {
+		got = arg
+		return nil
+	})
+
+	err := Record(context.Background(), r, Entry{
+		Action:     LoginFailed,
+		TargetType: "user",
+		TargetID:   "42",
+		After:      map[string]string{"reason": "bad password"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got.ActorID.Valid {
+		t.Errorf("expected an anonymous actor to be NULL; got %v", got.ActorID.UUID)
+	}
+	if string(got.Before) != "null" {
+		t.Errorf("expected a missing snapshot to be null; got %s", got.Before)
+	}
+	if string(got.After) != `{"reason":"bad password"}` {
+		t.Errorf("unexpected after snapshot %s", got.After)
+	}
+}
+
+func TestCSVWriter(t *testing.T) {
+	actor := uuid.New()
+	entry := store.AuditLog{
+		ID:         7,
+		ActorID:    uuid.NullUUID{UUID: actor, Valid: true},
+		Action:     PostUpdate,
+		TargetType: "post",
+		TargetID:   "p1",
+		Before:     json.RawMessage(`{"title":"a, b"}`),
+		After:      json.RawMessage(`null`),
+		CreatedAt:  time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+
+	var buf bytes.Buffer
+	w := NewCSVWriter(&buf)
+	for range 2 {
+		if err := w.Write([]store.AuditLog{entry}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected a header and 2 rows; got %d lines", len(lines))
+	}
+	if !strings.HasPrefix(lines[0], "id,created_at,actor_id,") {
+		t.Errorf("unexpected header %q", lines[0])
+	}
+
+	want := `7,2024-01-02T03:04:05Z,` + actor.String() + `,post.update,post,p1,"{""title"":""a, b""}",null,,`
+	if lines[1] != want {
+		t.Errorf("expected row %q; got %q", want, lines[1])
+	}
+
+	t.Run("formulas are escaped", func(t *testing.T) {
+		entry := store.AuditLog{
+			ID:         8,
+			Action:     LoginFailed,
+			TargetType: "user",
+			TargetID:   "-1+1",
+			Before:     json.RawMessage(`null`),
+			After:      json.RawMessage(`null`),
+			RequestID:  `=HYPERLINK("https://evil.example","open")`,
+			Ip:         "@SUM(1)",
+			CreatedAt:  time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		}
+
+		var buf bytes.Buffer
+		if err := NewCSVWriter(&buf).Write([]store.AuditLog{entry}); err != nil {
+			t.Fatal(err)
+		}
+
+		rows, err := csv.NewReader(&buf).ReadAll()
+		if err != nil {
+			t.Fatal(err)
+		}
+		row := rows[1]
+		if row[5] != "'-1+1" || row[8] != `'=HYPERLINK("https://evil.example","open")` || row[9] != "'@SUM(1)" {
+			t.Errorf("expected formula cells to be prefixed with a quote; got %q", row)
+		}
+	})
+}
@@ -0,0 +1,77 @@
+package audit
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/JaskiratAnand/go-social/internal/store"
+)
+
+var csvHeader = []string{
+	"id", "created_at", "actor_id", "action", "target_type", "target_id",
+	"before", "after", "request_id", "ip",
+}
+
+// escapeCell keeps spreadsheets from running cells as formulas. Request ids,
+// target ids and snapshots come from users, and a cell like
+// =HYPERLINK(...) would otherwise be live when an export is opened.
+func escapeCell(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+// CSVWriter writes log entries as CSV, one page at a time, so exports do
+// not hold the whole log in memory.
+type CSVWriter struct {
+	w           *csv.Writer
+	wroteHeader bool
+}
+
+func NewCSVWriter(w io.Writer) *CSVWriter {
+	return &CSVWriter{w: csv.NewWriter(w)}
+}
+
+// Write writes entries after the header, and flushes them.
+func (c *CSVWriter) Write(entries []store.AuditLog) error {
+	if !c.wroteHeader {
+		if err := c.w.Write(csvHeader); err != nil {
+			return err
+		}
+		c.wroteHeader = true
+	}
+
+	for _, e := range entries {
+		var actorID string
+		if e.ActorID.Valid {
+			actorID = e.ActorID.UUID.String()
+		}
+
+		row := []string{
+			strconv.FormatInt(e.ID, 10),
+			e.CreatedAt.UTC().Format(time.RFC3339),
+			actorID,
+			e.Action,
+			e.TargetType,
+			e.TargetID,
+			string(e.Before),
+			string(e.After),
+			e.RequestID,
+			e.Ip,
+		}
+		for i := range row {
+			row[i] = escapeCell(row[i])
+		}
+
+		if err := c.w.Write(row); err != nil {
+			return err
+		}
+	}
+
+	c.w.Flush()
+	return c.w.Error()
+}
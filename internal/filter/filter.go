@@ -0,0 +1,234 @@
+// Package filter matches user content against admin managed rules and
+// user muted words.
+package filter
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Kinds of rules.
+const (
+	// Word matches a word or phrase, ignoring case.
+	Word = "word"
+	// Regex matches a Go regular expression.
+	Regex = "regex"
+	// Domain matches links to a domain and its subdomains.
+	Domain = "domain"
+)
+
+// Actions of rules, from the weakest to the strongest.
+const (
+	// Mask replaces the matched text with asterisks.
+	Mask = "mask"
+	// Hold keeps the content hidden until a moderator reviews it.
+	Hold = "hold"
+	// Reject refuses the content.
+	Reject = "reject"
+)
+
+var wordChar = regexp.MustCompile(`\w`)
+
+var strength = map[string]int{"": 0, Mask: 1, Hold: 2, Reject: 3}
+
+// hostPattern finds host names in text, with or without a scheme.
+var hostPattern = regexp.MustCompile(`(?i)\b(?:[a-z0-9](?:[a-z0-9-]*[a-z0-9])?\.)+[a-z]{2,}\b`)
+
+type Rule struct {
+	ID      string
+	Kind    string
+	Pattern string
+	Action  string
+}
+
+type compiled struct {
+	Rule
+	re *regexp.Regexp
+}
+
+// Filter applies a set of rules. The zero value matches nothing.
+type Filter struct {
+	rules   []compiled
+	domains []compiled
+}
+
+// New compiles rules, failing on the first invalid one.
+func New(rules []Rule) (*Filter, error) {
+	f := &Filter{}
+
+	for _, r := range rules {
+		if _, ok := strength[r.Action]; !ok || r.Action == "" {
+			return nil, fmt.Errorf("rule %s: unknown action %q", r.ID, r.Action)
+		}
+		if err := ValidPattern(r.Kind, r.Pattern); err != nil {
+			return nil, fmt.Errorf("rule %s: %w", r.ID, err)
+		}
+
+		c := compiled{Rule: r}
+		switch r.Kind {
+		case Word:
+			c.re = wordPattern(r.Pattern)
+		case Regex:
+			c.re = regexp.MustCompile(r.Pattern)
+		case Domain:
+			c.Pattern = strings.ToLower(strings.TrimPrefix(r.Pattern, "."))
+			f.domains = append(f.domains, c)
+			continue
+		default:
+			return nil, fmt.Errorf("rule %s: unknown kind %q", r.ID, r.Kind)
+		}
+		f.rules = append(f.rules, c)
+	}
+
+	return f, nil
+}
+
+// wordPattern matches phrase as whole words, ignoring case. Word
+// boundaries are only required next to word characters, so phrases such
+// as "$$$" still match.
+func wordPattern(phrase string) *regexp.Regexp {
+	phrase = strings.TrimSpace(phrase)
+
+	pattern := regexp.QuoteMeta(phrase)
+	if wordChar.MatchString(phrase[:1]) {
+		pattern = `\b` + pattern
+	}
+	if wordChar.MatchString(phrase[len(phrase)-1:]) {
+		pattern += `\b`
+	}
+	return regexp.MustCompile(`(?i)` + pattern)
+}
+
+// Result is the outcome of checking a text.
+type Result struct {
+	// Action is the strongest action of the matching rules, empty when
+	// none matched.
+	Action string
+	// Text is the checked text with the matches of mask rules masked.
+	Text string
+	// Matched are the rules that matched.
+	Matched []Rule
+}
+
+// Check matches text against every rule.
+func (f *Filter) Check(text string) Result {
+	res := Result{Text: text}
+	if f == nil {
+		return res
+	}
+
+	var masks [][]int
+	match := func(r Rule, spans [][]int) {
+		if len(spans) == 0 {
+			return
+		}
+		res.Matched = append(res.Matched, r)
+		res.Action = Stronger(res.Action, r.Action)
+		if r.Action == Mask {
+			masks = append(masks, spans...)
+		}
+	}
+
+	for _, r := range f.rules {
+		match(r.Rule, r.re.FindAllStringIndex(text, -1))
+	}
+
+	if len(f.domains) > 0 {
+		hosts := hostPattern.FindAllStringIndex(text, -1)
+		for _, r := range f.domains {
+			var spans [][]int
+			for _, h := range hosts {
+				if hostMatches(strings.ToLower(text[h[0]:h[1]]), r.Pattern) {
+					spans = append(spans, h)
+				}
+			}
+			match(r.Rule, spans)
+		}
+	}
+
+	if len(masks) > 0 {
+		res.Text = mask(text, masks)
+	}
+	return res
+}
+
+// Stronger returns the stronger of two actions, where the empty action is
+// weaker than any other.
+func Stronger(a, b string) string {
+	if strength[b] > strength[a] {
+		return b
+	}
+	return a
+}
+
+func hostMatches(host, domain string) bool {
+	return host == domain || strings.HasSuffix(host, "."+domain)
+}
+
+// mask replaces every rune within spans with an asterisk.
+func mask(text string, spans [][]int) string {
+	masked := make([]bool, len(text))
+	for _, s := range spans {
+		for i := s[0]; i < s[1]; i++ {
+			masked[i] = true
+		}
+	}
+
+	var b strings.Builder
+	for i, r := range text {
+		if masked[i] {
+			b.WriteByte('*')
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// Muter hides content containing any of a user's muted words.
+type Muter struct {
+	words []*regexp.Regexp
+}
+
+func NewMuter(words []string) *Muter {
+	m := &Muter{}
+	for _, w := range words {
+		if strings.TrimSpace(w) != "" {
+			m.words = append(m.words, wordPattern(w))
+		}
+	}
+	return m
+}
+
+// Mutes reports whether any of texts contains a muted word.
+func (m *Muter) Mutes(texts ...string) bool {
+	for _, re := range m.words {
+		for _, t := range texts {
+			if re.MatchString(t) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ValidPattern checks a rule pattern before it is stored.
+func ValidPattern(kind, pattern string) error {
+	if strings.TrimSpace(pattern) == "" {
+		return fmt.Errorf("pattern must not be empty")
+	}
+
+	switch kind {
+	case Regex:
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("invalid regex: %w", err)
+		}
+	case Domain:
+		domain := strings.TrimPrefix(pattern, ".")
+		if hostPattern.FindString(domain) != domain {
+			return fmt.Errorf("invalid domain %q", pattern)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package filter
+
+import "testing"
+
+func TestFilter(t *testing.T) {
+	f, err := New([]Rule{
+		{ID: "1", Kind: Word, Pattern: "darn", Action: Mask},
+		{ID: "2", Kind: Regex, Pattern: `(?i)free\s+money`, Action: Hold},
+		{ID: "3", Kind: Domain, Pattern: "spam.example", Action: Reject},
+		{ID: "4", Kind: Word, Pattern: "$$$", Action: Mask},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		text   string
+		action string
+		masked string
+	}{
+		{"hello world", "", "hello world"},
+		{"Darn it, darnit", Mask, "**** it, darnit"},
+		{"win $$$ now", Mask, "win *** now"},
+		{"get FREE  money", Hold, "get FREE  money"},
+		{"see https://www.spam.example/offer", Reject, "see https://www.spam.example/offer"},
+		{"see notspam.example", "", "see notspam.example"},
+		{"darn, free money", Hold, "****, free money"},
+	}
+
+	for _, c := range cases {
+		res := f.Check(c.text)
+		if res.Action != c.action {
+			t.Errorf("%q: expected action %q; got %q", c.text, c.action, res.Action)
+		}
+		if res.Text != c.masked {
+			t.Errorf("%q: expected %q; got %q", c.text, c.masked, res.Text)
+		}
+	}
+}
+
+func TestNewRejectsBadRules(t *testing.T) {
+	for _, r := range []Rule{
+		{ID: "1", Kind: Regex, Pattern: "(", Action: Reject},
+		{ID: "2", Kind: Word, Pattern: " ", Action: Reject},
+		{ID: "3", Kind: Domain, Pattern: "not a domain", Action: Reject},
+		{ID: "4", Kind: Word, Pattern: "x", Action: "delete"},
+		{ID: "5", Kind: "phrase", Pattern: "x", Action: Mask},
+	} {
+		if _, err := New([]Rule{r}); err == nil {
+			t.Errorf("expected rule %s to be rejected", r.ID)
+		}
+	}
+}
+
+func TestMuter(t *testing.T) {
+	m := NewMuter([]string{"Spoilers", ""})
+
+	if !m.Mutes("no title", "big spoilers ahead") {
+		t.Error("expected a muted word in any text to mute")
+	}
+	if m.Mutes("spoilersome") {
+		t.Error("expected muted words to match whole words only")
+	}
+}
@@ -6,6 +6,10 @@ const (
 	FromName            = "GoSocial"
 	maxRetries          = 3
 	UserWelcomeTemplate = "user_invitation.tmpl"
+
+	UserSuspendedTemplate  = "user_suspended.tmpl"
+	UserBannedTemplate     = "user_banned.tmpl"
+	UserReinstatedTemplate = "user_reinstated.tmpl"
 )
 
 //go:embed "templates"
@@ -0,0 +1,97 @@
+package ranking
+
+import (
+	"math"
+	"slices"
+	"time"
+)
+
+// Clock returns the current time. Tests pass a fixed clock so scores are
+// deterministic.
+type Clock func() time.Time
+
+// Signals are the inputs of a post's score.
+type Signals struct {
+	CreatedAt time.Time
+	Comments  int64
+	Reactions int64
+	// Affinity measures how much the viewer interacts with the author.
+	Affinity int64
+}
+
+type Weights struct {
+	// HalfLife is the age at which a post's score has halved.
+	HalfLife  time.Duration
+	Comments  float64
+	Reactions float64
+	Affinity  float64
+}
+
+var DefaultWeights = Weights{
+	HalfLife:  12 * time.Hour,
+	Comments:  1.0,
+	Reactions: 0.5,
+	Affinity:  1.5,
+}
+
+type Ranker struct {
+	weights Weights
+	now     Clock
+}
+
+func New(weights Weights, now Clock) *Ranker {
+	if now == nil {
+		now = time.Now
+	}
+	return &Ranker{weights: weights, now: now}
+}
+
+// Score combines engagement and affinity on a log scale, so a handful of
+// interactions matter more than the hundredth one, and decays the result
+// exponentially with the age of the post.
+func (r *Ranker) Score(s Signals) float64 {
+	engagement := 1 +
+		r.weights.Comments*math.Log1p(float64(max(s.Comments, 0))) +
+		r.weights.Reactions*math.Log1p(float64(max(s.Reactions, 0))) +
+		r.weights.Affinity*math.Log1p(float64(max(s.Affinity, 0)))
+
+	age := max(r.now().Sub(s.CreatedAt), 0)
+
+	decay := 1.0
+	if r.weights.HalfLife > 0 {
+		decay = math.Exp2(-age.Hours() / r.weights.HalfLife.Hours())
+	}
+
+	return engagement * decay
+}
+
+// Rank sorts items by descending score. Ties keep the original order, so a
+// chronological input stays chronological among equal scores.
+func Rank[T any](r *Ranker, items []T, signals func(T) Signals) []T {
+	type scored struct {
+		item  T
+		score float64
+	}
+
+	ranked := make([]scored, len(items))
+	for i, item := range items {
+		ranked[i] = scored{item: item, score: r.Score(signals(item))}
+	}
+
+	slices.SortStableFunc(ranked, func(a, b scored) int {
+		switch {
+		case a.score > b.score:
+			return -1
+		case a.score < b.score:
+			return 1
+		default:
+			return 0
+		}
+	})
+
+	out := make([]T, len(ranked))
+	for i, s := range ranked {
+		out[i] = s.item
+	}
+	return out
+}
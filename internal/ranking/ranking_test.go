@@ -0,0 +1,76 @@
+package ranking
+
+import (
+	"testing"
+	"time"
+)
+
+var now = time.Date(2025, 11, 16, 12, 0, 0, 0, time.UTC)
+
+func fixedClock() time.Time {
+	return now
+}
+
+func TestScore(t *testing.T) {
+	r := New(DefaultWeights, fixedClock)
+
+	t.Run("fresh post without engagement scores one", func(t *testing.T) {
+		if got := r.Score(Signals{CreatedAt: now}); got != 1 {
+			t.Errorf("expected score 1; got %v", got)
+		}
+	})
+
+	t.Run("score halves after the half-life", func(t *testing.T) {
+		fresh := r.Score(Signals{CreatedAt: now, Comments: 4})
+		old := r.Score(Signals{CreatedAt: now.Add(-DefaultWeights.HalfLife), Comments: 4})
+
+		if diff := fresh/2 - old; diff > 1e-9 || diff < -1e-9 {
+			t.Errorf("expected %v; got %v", fresh/2, old)
+		}
+	})
+
+	t.Run("future posts are not boosted", func(t *testing.T) {
+		if got := r.Score(Signals{CreatedAt: now.Add(time.Hour)}); got != 1 {
+			t.Errorf("expected score 1; got %v", got)
+		}
+	})
+
+	t.Run("engagement and affinity raise the score", func(t *testing.T) {
+		base := r.Score(Signals{CreatedAt: now})
+
+		for name, s := range map[string]Signals{
+			"comments":  {CreatedAt: now, Comments: 3},
+			"reactions": {CreatedAt: now, Reactions: 3},
+			"affinity":  {CreatedAt: now, Affinity: 3},
+		} {
+			if got := r.Score(s); got <= base {
+				t.Errorf("%s: expected score above %v; got %v", name, base, got)
+			}
+		}
+	})
+}
+
+func TestRank(t *testing.T) {
+	r := New(DefaultWeights, fixedClock)
+
+	type post struct {
+		name    string
+		signals Signals
+	}
+
+	posts := []post{
+		{"recent", Signals{CreatedAt: now.Add(-time.Hour)}},
+		{"old but popular", Signals{CreatedAt: now.Add(-2 * time.Hour), Comments: 50}},
+		{"stale", Signals{CreatedAt: now.Add(-7 * 24 * time.Hour), Comments: 50}},
+		{"also recent", Signals{CreatedAt: now.Add(-time.Hour)}},
+	}
+
+	ranked := Rank(r, posts, func(p post) Signals { return p.signals })
+
+	want := []string{"old but popular", "recent", "also recent", "stale"}
+	for i, name := range want {
+		if ranked[i].name != name {
+			t.Errorf("position %d: expected %q; got %q", i, name, ranked[i].name)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package ranking
+
+import (
+	"slices"
+	"time"
+)
+
+// TagActivity counts the posts using a tag in the recent window and in the
+// longer baseline window before it.
+type TagActivity struct {
+	Tag      string
+	Recent   int64
+	Baseline int64
+}
+
+type TrendingTag struct {
+	Tag      string  `json:"tag"`
+	Posts    int64   `json:"posts"`
+	Velocity float64 `json:"velocity"`
+}
+
+// Trending orders tags by velocity, the rate of posts in the recent window
+// over the rate in the baseline window. The baseline is smoothed by one post
+// so brand new tags do not divide by zero, and tags with fewer than
+// minPosts recent posts are dropped as noise.
+func Trending(activity []TagActivity, recent, baseline time.Duration, minPosts int64) []TrendingTag {
+	if recent <= 0 || baseline <= 0 {
+		return nil
+	}
+
+	tags := make([]TrendingTag, 0, len(activity))
+	for _, a := range activity {
+		if a.Recent < minPosts {
+			continue
+		}
+
+		recentRate := float64(a.Recent) / recent.Hours()
+		baselineRate := float64(a.Baseline+1) / baseline.Hours()
+
+		tags = append(tags, TrendingTag{
+			Tag:      a.Tag,
+			Posts:    a.Recent,
+			Velocity: recentRate / baselineRate,
+		})
+	}
+
+	slices.SortStableFunc(tags, func(a, b TrendingTag) int {
+		switch {
+		case a.Velocity > b.Velocity:
+			return -1
+		case a.Velocity < b.Velocity:
+			return 1
+		case a.Posts != b.Posts:
+			return int(b.Posts - a.Posts)
+		default:
+			return 0
+		}
+	})
+
+	return tags
+}
@@ -0,0 +1,32 @@
+package ranking
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTrending(t *testing.T) {
+	activity := []TagActivity{
+		{Tag: "steady", Recent: 10, Baseline: 69},
+		{Tag: "rising", Recent: 10, Baseline: 2},
+		{Tag: "new", Recent: 3, Baseline: 0},
+		{Tag: "noise", Recent: 1, Baseline: 0},
+	}
+
+	tags := Trending(activity, 24*time.Hour, 7*24*time.Hour, 2)
+
+	if len(tags) != 3 {
+		t.Fatalf("expected 3 tags; got %d", len(tags))
+	}
+
+	want := []string{"rising", "new", "steady"}
+	for i, tag := range tags {
+		if tag.Tag != want[i] {
+			t.Errorf("expected %q at %d; got %q", want[i], i, tag.Tag)
+		}
+	}
+
+	if tags[2].Velocity < 0.99 || tags[2].Velocity > 1.01 {
+		t.Errorf("expected a steady tag to have velocity 1; got %v", tags[2].Velocity)
+	}
+}
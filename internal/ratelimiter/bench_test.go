@@ -0,0 +1,56 @@
+package ratelimiter
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func benchmarkLimiters() map[string]func() Limiter {
+	return map[string]func() Limiter{
+		FixedWindow: func() Limiter {
+			return NewFixedWindowLimiter(1000, time.Minute)
+		},
+		SlidingWindow: func() Limiter {
+			return NewSlidingWindowLimiter(1000, time.Minute, Options{})
+		},
+		TokenBucket: func() Limiter {
+			return NewTokenBucketLimiter(1000, time.Minute, Options{})
+		},
+	}
+}
+
+// BenchmarkAllowSingleClient measures the steady state of one busy client.
+func BenchmarkAllowSingleClient(b *testing.B) {
+	for name, newLimiter := range benchmarkLimiters() {
+		b.Run(name, func(b *testing.B) {
+			rl := newLimiter()
+			for b.Loop() {
+				rl.Allow("10.0.0.1")
+			}
+		})
+	}
+}
+
+// BenchmarkAllowManyClients spreads parallel requests over many clients,
+// which is where per client goroutines and unbounded maps hurt.
+func BenchmarkAllowManyClients(b *testing.B) {
+	ips := make([]string, 10_000)
+	for i := range ips {
+		ips[i] = fmt.Sprintf("10.0.%d.%d", i/256, i%256)
+	}
+
+	for name, newLimiter := range benchmarkLimiters() {
+		b.Run(name, func(b *testing.B) {
+			rl := newLimiter()
+			var n atomic.Uint64
+
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					rl.Allow(ips[n.Add(1)%uint64(len(ips))])
+				}
+			})
+		})
+	}
+}
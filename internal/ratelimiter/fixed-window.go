@@ -5,38 +5,53 @@ import (
 	"time"
 )
 
+type fixedWindow struct {
+	count int
+	reset time.Time
+}
+
 type FixedWindowRateLimiter struct {
 	sync.RWMutex
-	clients map[string]int
+	clients map[string]*fixedWindow
 	limit   int
 	window  time.Duration
 }
 
 func NewFixedWindowLimiter(limit int, window time.Duration) *FixedWindowRateLimiter {
 	return &FixedWindowRateLimiter{
-		clients: make(map[string]int),
+		clients: make(map[string]*fixedWindow),
 		limit:   limit,
 		window:  window,
 	}
 }
 
 func (rl *FixedWindowRateLimiter) Allow(ip string) (bool, time.Duration) {
-	rl.Lock()
-	count, exists := rl.clients[ip]
-	rl.Unlock()
+	res := rl.Take(ip)
+	return res.Allowed, res.RetryAfter
+}
 
-	if !exists || count < rl.limit {
-		rl.Lock()
-		if !exists {
-			go rl.resetCount(ip)
-		}
+func (rl *FixedWindowRateLimiter) Take(ip string) Result {
+	now := time.Now()
 
-		rl.clients[ip]++
-		rl.Unlock()
-		return true, 0
+	rl.Lock()
+	w, exists := rl.clients[ip]
+	if !exists {
+		w = &fixedWindow{reset: now.Add(rl.window)}
+		rl.clients[ip] = w
+		go rl.resetCount(ip)
+	}
+
+	res := Result{Limit: rl.limit, Reset: w.reset.Sub(now)}
+	if w.count < rl.limit {
+		w.count++
+		res.Allowed = true
+	} else {
+		res.RetryAfter = res.Reset
 	}
+	res.Remaining = rl.limit - w.count
+	rl.Unlock()
 
-	return false, rl.window
+	return res
 }
 
 func (rl *FixedWindowRateLimiter) resetCount(ip string) {
@@ -0,0 +1,52 @@
+package ratelimiter
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Sweeper is a limiter whose idle clients can be dropped.
+type Sweeper interface {
+	Sweep(now time.Time)
+}
+
+// Janitor sweeps every registered limiter from a single goroutine, instead
+// of one timer or goroutine per client.
+type Janitor struct {
+	interval time.Duration
+
+	mu       sync.Mutex
+	sweepers []Sweeper
+}
+
+func NewJanitor(interval time.Duration) *Janitor {
+	return &Janitor{interval: interval}
+}
+
+func (j *Janitor) Register(s Sweeper) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.sweepers = append(j.sweepers, s)
+}
+
+// Run sweeps on every interval until ctx is cancelled.
+func (j *Janitor) Run(ctx context.Context) {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			j.mu.Lock()
+			sweepers := j.sweepers
+			j.mu.Unlock()
+
+			for _, s := range sweepers {
+				s.Sweep(now)
+			}
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package ratelimiter
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/netip"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Policy gives the requests it matches a budget of their own. Each
+// selector left empty matches every request, and a request must match all
+// the others.
+type Policy struct {
+	// Name keeps the budgets of different policies apart.
+	Name string `json:"name"`
+	// Routes are chi route patterns, optionally after a method, such as
+	// "POST /v1/auth/token" or "/v1/users/feed".
+	Routes []string `json:"routes"`
+	// Users are ids of authenticated users.
+	Users []string `json:"users"`
+	// Roles are role names such as ADMIN.
+	Roles []string `json:"roles"`
+	// Networks are CIDRs of internal clients, such as "10.0.0.0/8".
+	Networks []string `json:"networks"`
+
+	Limit int `json:"limit"`
+	// Window defaults to Config.TimeFrame. It is written as a duration
+	// such as "1m" in JSON.
+	Window time.Duration `json:"-"`
+	// Exempt lets matching requests through without counting them.
+	Exempt bool `json:"exempt"`
+}
+
+func (p *Policy) UnmarshalJSON(data []byte) error {
+	type policy Policy
+	aux := struct {
+		*policy
+		Window string `json:"window"`
+	}{policy: (*policy)(p)}
+
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	if aux.Window != "" {
+		d, err := time.ParseDuration(aux.Window)
+		if err != nil {
+			return fmt.Errorf("policy %q: invalid window %q", p.Name, aux.Window)
+		}
+		p.Window = d
+	}
+	return nil
+}
+
+// Client describes a request for policy matching.
+type Client struct {
+	// Route is the method and chi route pattern, "GET /v1/posts/{postID}/".
+	Route  string
+	UserID string
+	Role   string
+	IP     netip.Addr
+}
+
+// key identifies the client within a budget. Authenticated users are
+// limited across addresses, everyone else by address.
+func (c Client) key() string {
+	if c.UserID != "" {
+		return "user:" + c.UserID
+	}
+	return "ip:" + c.IP.String()
+}
+
+type policy struct {
+	Policy
+	networks []netip.Prefix
+	limiter  Limiter
+}
+
+func (p *policy) matches(c Client) bool {
+	if len(p.Routes) > 0 && !slices.ContainsFunc(p.Routes, func(route string) bool {
+		return routeMatches(route, c.Route)
+	}) {
+		return false
+	}
+	if len(p.Users) > 0 && !slices.Contains(p.Users, c.UserID) {
+		return false
+	}
+	if len(p.Roles) > 0 && !slices.Contains(p.Roles, c.Role) {
+		return false
+	}
+	if len(p.networks) > 0 && !slices.ContainsFunc(p.networks, func(n netip.Prefix) bool {
+		return n.Contains(c.IP)
+	}) {
+		return false
+	}
+	return true
+}
+
+// routeMatches compares a policy route with the route of a request. A
+// policy route without a method matches every method, and trailing slashes
+// are ignored since chi patterns of nested routes end in one.
+func routeMatches(policyRoute, route string) bool {
+	method, pattern, ok := strings.Cut(route, " ")
+	if !ok {
+		return false
+	}
+
+	want := policyRoute
+	if m, p, ok := strings.Cut(policyRoute, " "); ok {
+		if !strings.EqualFold(m, method) {
+			return false
+		}
+		want = p
+	}
+
+	return strings.TrimSuffix(want, "/") == strings.TrimSuffix(pattern, "/")
+}
+
+// Policies picks the first policy matching a request, and falls back to
+// the limit of the Config for requests matching none.
+type Policies struct {
+	policies []*policy
+	fallback *policy
+}
+
+// NewPolicies builds a limiter for cfg.Policies and one for everything
+// else, all with cfg.Strategy and cfg.Backend.
+func NewPolicies(cfg Config, opts Options) (*Policies, error) {
+	build := func(p Policy) (*policy, error) {
+		compiled := &policy{Policy: p}
+
+		for _, cidr := range p.Networks {
+			n, err := netip.ParsePrefix(cidr)
+			if err != nil {
+				return nil, fmt.Errorf("policy %q: invalid network %q", p.Name, cidr)
+			}
+			compiled.networks = append(compiled.networks, n)
+		}
+
+		if p.Exempt {
+			return compiled, nil
+		}
+		if p.Limit <= 0 {
+			return nil, fmt.Errorf("policy %q: limit must be positive", p.Name)
+		}
+		if p.Window <= 0 {
+			compiled.Window = cfg.TimeFrame
+		}
+
+		limiterCfg := cfg
+		limiterCfg.RequestPerTimeFrame = compiled.Limit
+		limiterCfg.TimeFrame = compiled.Window
+
+		limiter, err := New(limiterCfg, opts)
+		if err != nil {
+			return nil, err
+		}
+		compiled.limiter = limiter
+		return compiled, nil
+	}
+
+	ps := &Policies{}
+	seen := map[string]bool{"default": true}
+
+	for _, p := range cfg.Policies {
+		if p.Name == "" || seen[p.Name] {
+			return nil, fmt.Errorf("policy names must be unique and not empty, got %q", p.Name)
+		}
+		seen[p.Name] = true
+
+		compiled, err := build(p)
+		if err != nil {
+			return nil, err
+		}
+		ps.policies = append(ps.policies, compiled)
+	}
+
+	fallback, err := build(Policy{Name: "default", Limit: cfg.RequestPerTimeFrame, Window: cfg.TimeFrame})
+	if err != nil {
+		return nil, err
+	}
+	ps.fallback = fallback
+
+	return ps, nil
+}
+
+// Take spends one request from the budget c gets. limited is false when c
+// is exempt, in which case nothing was spent.
+func (ps *Policies) Take(c Client) (res Result, limited bool) {
+	p := ps.fallback
+	for _, candidate := range ps.policies {
+		if candidate.matches(c) {
+			p = candidate
+			break
+		}
+	}
+
+	if p.Exempt {
+		return Result{Allowed: true}, false
+	}
+
+	return p.limiter.Take(p.Name + ":" + c.key()), true
+}
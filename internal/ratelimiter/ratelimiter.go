@@ -1,13 +1,92 @@
 package ratelimiter
 
-import "time"
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"time"
+)
 
 type Limiter interface {
 	Allow(ip string) (bool, time.Duration)
+	// Take spends one request like Allow, and reports the state of the
+	// budget for RateLimit headers.
+	Take(key string) Result
 }
 
+// Result is the outcome of spending one request.
+type Result struct {
+	Allowed bool
+	Limit   int
+	// Remaining is how many more requests would be allowed right now.
+	Remaining int
+	// Reset is how long until the budget is back to its full limit, or for
+	// the windows, until the oldest request stops counting.
+	Reset time.Duration
+	// RetryAfter is set when the request was refused.
+	RetryAfter time.Duration
+}
+
+// Strategies accepted in Config.Strategy.
+const (
+	FixedWindow   = "fixed-window"
+	SlidingWindow = "sliding-window"
+	TokenBucket   = "token-bucket"
+)
+
+// Backends accepted in Config.Backend.
+const (
+	MemoryBackend = "memory"
+	RedisBackend  = "redis"
+)
+
 type Config struct {
 	RequestPerTimeFrame int
 	TimeFrame           time.Duration
 	Enabled             bool
+	Strategy            string
+	MaxKeys             int
+	// Backend is memory (the default), which limits each instance on its
+	// own, or redis, which shares one budget between instances.
+	Backend string
+	// FailOpen lets requests through while Redis is unavailable instead
+	// of refusing them.
+	FailOpen bool
+	// Policies are tried in order before falling back to
+	// RequestPerTimeFrame, see NewPolicies.
+	Policies []Policy
+}
+
+// New builds the limiter selected by cfg.Backend and cfg.Strategy.
+// cfg.MaxKeys takes precedence over opts.MaxKeys when set.
+func New(cfg Config, opts Options) (Limiter, error) {
+	if cfg.MaxKeys > 0 {
+		opts.MaxKeys = cfg.MaxKeys
+	}
+	strategy := cmp.Or(cfg.Strategy, SlidingWindow)
+
+	switch cfg.Backend {
+	case MemoryBackend, "":
+	case RedisBackend:
+		if opts.Redis == nil {
+			return nil, errors.New("redis rate limiter needs a redis client")
+		}
+		if _, ok := redisScripts[strategy]; !ok {
+			return nil, fmt.Errorf("unknown rate limiter strategy %q", cfg.Strategy)
+		}
+		return NewRedisLimiter(opts.Redis, strategy, cfg.RequestPerTimeFrame, cfg.TimeFrame, cfg.FailOpen, opts), nil
+	default:
+		return nil, fmt.Errorf("unknown rate limiter backend %q", cfg.Backend)
+	}
+
+	switch strategy {
+	case FixedWindow:
+		return NewFixedWindowLimiter(cfg.RequestPerTimeFrame, cfg.TimeFrame), nil
+	case SlidingWindow:
+		return NewSlidingWindowLimiter(cfg.RequestPerTimeFrame, cfg.TimeFrame, opts), nil
+	case TokenBucket:
+		return NewTokenBucketLimiter(cfg.RequestPerTimeFrame, cfg.TimeFrame, opts), nil
+	}
+
+	return nil, fmt.Errorf("unknown rate limiter strategy %q", cfg.Strategy)
 }
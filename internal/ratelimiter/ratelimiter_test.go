@@ -0,0 +1,315 @@
+package ratelimiter
+
+import (
+	"encoding/json"
+	"errors"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+type clock struct{ t time.Time }
+
+func (c *clock) now() time.Time          { return c.t }
+func (c *clock) advance(d time.Duration) { c.t = c.t.Add(d) }
+func allowN(l Limiter, ip string, n int) int {
+	allowed := 0
+	for range n {
+		if ok, _ := l.Allow(ip); ok {
+			allowed++
+		}
+	}
+	return allowed
+}
+
+func TestSlidingWindow(t *testing.T) {
+	c := &clock{t: time.Unix(0, 0)}
+	rl := NewSlidingWindowLimiter(10, time.Second, Options{now: c.now})
+
+	if got := allowN(rl, "a", 15); got != 10 {
+		t.Fatalf("expected 10 allowed; got %d", got)
+	}
+
+	_, retry := rl.Allow("a")
+	if retry != time.Second {
+		t.Errorf("expected retry after 1s; got %v", retry)
+	}
+
+	// no burst across the window boundary
+	c.advance(999 * time.Millisecond)
+	if got := allowN(rl, "a", 5); got != 0 {
+		t.Errorf("expected nothing allowed before the window slides; got %d", got)
+	}
+
+	c.advance(time.Millisecond)
+	if got := allowN(rl, "a", 15); got != 10 {
+		t.Errorf("expected 10 allowed once the window slid; got %d", got)
+	}
+
+	if got := allowN(rl, "b", 1); got != 1 {
+		t.Errorf("expected other clients to have their own budget")
+	}
+}
+
+func TestTokenBucket(t *testing.T) {
+	c := &clock{t: time.Unix(0, 0)}
+	rl := NewTokenBucketLimiter(10, time.Second, Options{now: c.now})
+
+	if got := allowN(rl, "a", 15); got != 10 {
+		t.Fatalf("expected a burst of 10; got %d", got)
+	}
+
+	_, retry := rl.Allow("a")
+	if retry != 100*time.Millisecond {
+		t.Errorf("expected retry after 100ms; got %v", retry)
+	}
+
+	c.advance(500 * time.Millisecond)
+	if got := allowN(rl, "a", 10); got != 5 {
+		t.Errorf("expected 5 refilled tokens; got %d", got)
+	}
+}
+
+func TestSweepAndMaxKeys(t *testing.T) {
+	c := &clock{t: time.Unix(0, 0)}
+	rl := NewSlidingWindowLimiter(1, time.Second, Options{MaxKeys: 3, now: c.now})
+
+	for _, ip := range []string{"a", "b", "c", "d"} {
+		rl.Allow(ip)
+	}
+	if n := rl.clients.Len(); n != 3 {
+		t.Fatalf("expected at most 3 clients; got %d", n)
+	}
+	if ok, _ := rl.Allow("a"); !ok {
+		t.Error("expected the least recently seen client to be forgotten")
+	}
+
+	c.advance(time.Second)
+	rl.Allow("b")
+	rl.Sweep(c.now())
+
+	if n := rl.clients.Len(); n != 1 {
+		t.Errorf("expected idle clients to be swept; got %d left", n)
+	}
+}
+
+func TestRedisUnavailable(t *testing.T) {
+	// nothing listens on the discard port, so every call fails fast
+	rdb := redis.NewClient(&redis.Options{Addr: "127.0.0.1:9", MaxRetries: -1})
+	defer rdb.Close()
+
+	for _, failOpen := range []bool{true, false} {
+		var errs int
+		rl, err := New(
+			Config{RequestPerTimeFrame: 1, TimeFrame: time.Second, Backend: RedisBackend, FailOpen: failOpen},
+			Options{Redis: rdb, OnError: func(error) { errs++ }},
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ok, retry := rl.Allow("a")
+		if ok != failOpen {
+			t.Errorf("fail open %v: expected allowed %v; got %v", failOpen, failOpen, ok)
+		}
+		if !failOpen && retry != time.Second {
+			t.Errorf("expected retry after the window; got %v", retry)
+		}
+		if errs != 1 {
+			t.Errorf("expected the error to be reported once; got %d", errs)
+		}
+	}
+}
+
+func TestRedisScripts(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer rdb.Close()
+
+	const limit, window = 4, 10 * time.Second
+
+	// TIME in the scripts and key expiry both follow the fake clock
+	start := time.Unix(1_700_000_000, 0)
+	advance := func(d time.Duration) {
+		start = start.Add(d)
+		mr.SetTime(start)
+		mr.FastForward(d)
+	}
+
+	cases := []struct {
+		strategy string
+		// retry is the wait once the budget is spent right away
+		retry time.Duration
+		// refill is how long until the next request is allowed again
+		refill time.Duration
+	}{
+		{FixedWindow, window, window},
+		{SlidingWindow, window, window},
+		{TokenBucket, window / limit, window / limit},
+	}
+
+	for _, c := range cases {
+		t.Run(c.strategy, func(t *testing.T) {
+			mr.FlushAll()
+			mr.SetTime(start)
+
+			var onError error
+			rl := NewRedisLimiter(rdb, c.strategy, limit, window, false, Options{OnError: func(err error) { onError = err }})
+
+			for i := range limit {
+				res := rl.Take("a")
+				if !res.Allowed {
+					t.Fatalf("request %d: expected to be allowed", i)
+				}
+				if res.Remaining != limit-i-1 {
+					t.Errorf("request %d: expected %d remaining; got %d", i, limit-i-1, res.Remaining)
+				}
+			}
+			if onError != nil {
+				t.Fatalf("unexpected redis error %v", onError)
+			}
+
+			res := rl.Take("a")
+			if res.Allowed || res.Remaining != 0 {
+				t.Fatalf("expected the budget to be spent; got %+v", res)
+			}
+			if res.RetryAfter != c.retry {
+				t.Errorf("expected retry after %v; got %v", c.retry, res.RetryAfter)
+			}
+			if res.Reset <= 0 || res.Reset > window {
+				t.Errorf("expected a reset within the window; got %v", res.Reset)
+			}
+
+			if res := rl.Take("b"); !res.Allowed {
+				t.Error("expected other clients to have their own budget")
+			}
+
+			advance(c.refill - time.Millisecond)
+			if res := rl.Take("a"); res.Allowed {
+				t.Errorf("expected nothing allowed %v early", time.Millisecond)
+			}
+
+			advance(time.Millisecond)
+			if res := rl.Take("a"); !res.Allowed {
+				t.Errorf("expected a request to be allowed after %v", c.refill)
+			}
+		})
+	}
+}
+
+func TestThrottleErrors(t *testing.T) {
+	c := &clock{t: time.Unix(0, 0)}
+
+	var dropped []int
+	report := throttleErrors(time.Minute, c.now, func(err error, n int) {
+		dropped = append(dropped, n)
+	})
+
+	errRedis := errors.New("redis down")
+	for range 100 {
+		report(errRedis)
+		c.advance(time.Second)
+	}
+
+	// reported at 0s and 60s, with the 59 errors in between dropped
+	if len(dropped) != 2 {
+		t.Fatalf("expected 2 reports; got %d", len(dropped))
+	}
+	if dropped[0] != 0 || dropped[1] != 59 {
+		t.Errorf("expected 0 and 59 dropped errors; got %v", dropped)
+	}
+}
+
+func TestNewRejectsBadConfig(t *testing.T) {
+	for name, cfg := range map[string]Config{
+		"unknown strategy":   {Strategy: "leaky-bucket"},
+		"unknown backend":    {Backend: "memcached"},
+		"redis without rdb":  {Backend: RedisBackend},
+		"redis bad strategy": {Backend: RedisBackend, Strategy: "leaky-bucket"},
+	} {
+		if _, err := New(cfg, Options{}); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestPolicies(t *testing.T) {
+	var policies []Policy
+	err := json.Unmarshal([]byte(`[
+		{"name": "internal", "networks": ["10.0.0.0/8"], "exempt": true},
+		{"name": "admins", "roles": ["ADMIN"], "exempt": true},
+		{"name": "login", "routes": ["POST /v1/auth/token"], "limit": 2, "window": "1m"},
+		{"name": "feed", "routes": ["/v1/users/feed"], "limit": 3}
+	]`), &policies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if policies[2].Window != time.Minute {
+		t.Fatalf("expected the login window to be parsed; got %v", policies[2].Window)
+	}
+
+	ps, err := NewPolicies(Config{RequestPerTimeFrame: 5, TimeFrame: time.Second, Policies: policies}, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ip := netip.MustParseAddr("192.0.2.1")
+	spend := func(c Client, n int) (allowed int, last Result) {
+		for range n {
+			res, _ := ps.Take(c)
+			if res.Allowed {
+				allowed++
+			}
+			last = res
+		}
+		return allowed, last
+	}
+
+	t.Run("routes have their own budget", func(t *testing.T) {
+		login := Client{Route: "POST /v1/auth/token", IP: ip}
+		if got, _ := spend(login, 3); got != 2 {
+			t.Errorf("expected 2 logins; got %d", got)
+		}
+
+		// trailing slashes of nested routes are ignored
+		feed := Client{Route: "GET /v1/users/feed/", IP: ip}
+		got, last := spend(feed, 4)
+		if got != 3 {
+			t.Errorf("expected 3 feed reads; got %d", got)
+		}
+		if last.Limit != 3 || last.Remaining != 0 || last.RetryAfter <= 0 {
+			t.Errorf("unexpected result %+v", last)
+		}
+
+		other := Client{Route: "GET /v1/explore", IP: ip}
+		if got, _ := spend(other, 6); got != 5 {
+			t.Errorf("expected the default limit of 5; got %d", got)
+		}
+	})
+
+	t.Run("users are limited across addresses", func(t *testing.T) {
+		for i, addr := range []string{"192.0.2.10", "192.0.2.11"} {
+			c := Client{Route: "GET /v1/users/feed", UserID: "u1", IP: netip.MustParseAddr(addr)}
+			want := []int{3, 0}[i]
+			if got, _ := spend(c, 3); got != want {
+				t.Errorf("%s: expected %d allowed; got %d", addr, want, got)
+			}
+		}
+	})
+
+	t.Run("exemptions", func(t *testing.T) {
+		for name, c := range map[string]Client{
+			"admin":    {Route: "POST /v1/auth/token", Role: "ADMIN", IP: ip},
+			"internal": {Route: "POST /v1/auth/token", IP: netip.MustParseAddr("10.1.2.3")},
+		} {
+			for range 10 {
+				if res, limited := ps.Take(c); limited || !res.Allowed {
+					t.Fatalf("%s: expected to be exempt", name)
+				}
+			}
+		}
+	})
+}
@@ -0,0 +1,187 @@
+package ratelimiter
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// DefaultRedisTimeout bounds how long a request waits on Redis before the
+// limiter fails open or closed.
+const DefaultRedisTimeout = 100 * time.Millisecond
+
+var errUnexpectedReply = errors.New("ratelimiter: unexpected reply from redis")
+
+// The scripts read the clock from Redis, so instances with skewed clocks
+// still share one window. Each returns {allowed, retry after, remaining,
+// reset}, with durations in milliseconds.
+var redisScripts = map[string]*redis.Script{
+	FixedWindow: redis.NewScript(`
+local limit = tonumber(ARGV[1])
+local count = redis.call('INCR', KEYS[1])
+if count == 1 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+
+local reset = redis.call('PTTL', KEYS[1])
+if count <= limit then
+	return {1, 0, limit - count, reset}
+end
+return {0, reset, 0, reset}
+`),
+
+	SlidingWindow: redis.NewScript(`
+local limit = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', now - window)
+local count = redis.call('ZCARD', KEYS[1])
+local allowed = 0
+if count < limit then
+	redis.call('ZADD', KEYS[1], now, t[1] .. t[2] .. ARGV[3])
+	redis.call('PEXPIRE', KEYS[1], window)
+	count = count + 1
+	allowed = 1
+end
+
+local reset = 0
+local oldest = redis.call('ZRANGE', KEYS[1], 0, 0, 'WITHSCORES')
+if oldest[2] then
+	reset = tonumber(oldest[2]) + window - now
+end
+
+if allowed == 1 then
+	return {1, 0, limit - count, reset}
+end
+return {0, reset, 0, reset}
+`),
+
+	TokenBucket: redis.NewScript(`
+local limit = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+
+local b = redis.call('HMGET', KEYS[1], 'tokens', 'last')
+local tokens = tonumber(b[1]) or limit
+local last = tonumber(b[2]) or now
+tokens = math.min(limit, tokens + (now - last) * limit / window)
+
+local allowed, retry = 0, 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+else
+	retry = math.ceil((1 - tokens) * window / limit)
+end
+
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'last', now)
+redis.call('PEXPIRE', KEYS[1], window)
+return {allowed, retry, math.floor(tokens), math.ceil((limit - tokens) * window / limit)}
+`),
+}
+
+// RedisRateLimiter keeps client state in Redis, so every instance of the
+// API spends from the same budget.
+type RedisRateLimiter struct {
+	rdb      redis.Scripter
+	script   *redis.Script
+	prefix   string
+	limit    int
+	window   time.Duration
+	failOpen bool
+	timeout  time.Duration
+	onError  func(error)
+}
+
+func NewRedisLimiter(rdb redis.Scripter, strategy string, limit int, window time.Duration, failOpen bool, opts Options) *RedisRateLimiter {
+	script, ok := redisScripts[strategy]
+	if !ok {
+		script = redisScripts[SlidingWindow]
+	}
+
+	rl := &RedisRateLimiter{
+		rdb:      rdb,
+		script:   script,
+		prefix:   "ratelimit:" + strategy + ":",
+		limit:    limit,
+		window:   window,
+		failOpen: failOpen,
+		timeout:  opts.RedisTimeout,
+		onError:  opts.OnError,
+	}
+	if rl.timeout <= 0 {
+		rl.timeout = DefaultRedisTimeout
+	}
+	return rl
+}
+
+func (rl *RedisRateLimiter) Allow(ip string) (bool, time.Duration) {
+	res := rl.Take(ip)
+	return res.Allowed, res.RetryAfter
+}
+
+func (rl *RedisRateLimiter) Take(ip string) Result {
+	ctx, cancel := context.WithTimeout(context.Background(), rl.timeout)
+	defer cancel()
+
+	reply, err := rl.script.Run(ctx, rl.rdb, []string{rl.prefix + ip},
+		rl.limit, rl.window.Milliseconds(), strconv.FormatUint(rand.Uint64(), 36),
+	).Int64Slice()
+	if err == nil && len(reply) != 4 {
+		err = errUnexpectedReply
+	}
+	if err != nil {
+		if rl.onError != nil {
+			rl.onError(err)
+		}
+		if rl.failOpen {
+			return Result{Allowed: true, Limit: rl.limit, Remaining: rl.limit}
+		}
+		return Result{Limit: rl.limit, RetryAfter: rl.window, Reset: rl.window}
+	}
+
+	return Result{
+		Allowed:    reply[0] == 1,
+		RetryAfter: time.Duration(reply[1]) * time.Millisecond,
+		Limit:      rl.limit,
+		Remaining:  int(reply[2]),
+		Reset:      time.Duration(reply[3]) * time.Millisecond,
+	}
+}
+
+// ThrottleErrors wraps fn for Options.OnError so that it runs at most once
+// per interval. During a Redis outage every request fails, and reporting
+// each failure would flood the logs. fn is told how many errors were
+// dropped since it last ran.
+func ThrottleErrors(interval time.Duration, fn func(err error, dropped int)) func(error) {
+	return throttleErrors(interval, time.Now, fn)
+}
+
+func throttleErrors(interval time.Duration, now func() time.Time, fn func(err error, dropped int)) func(error) {
+	var mu sync.Mutex
+	var last time.Time
+	var dropped int
+
+	return func(err error) {
+		mu.Lock()
+		t := now()
+		if !last.IsZero() && t.Sub(last) < interval {
+			dropped++
+			mu.Unlock()
+			return
+		}
+		n := dropped
+		last, dropped = t, 0
+		mu.Unlock()
+
+		fn(err, n)
+	}
+}
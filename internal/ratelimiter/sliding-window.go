@@ -0,0 +1,72 @@
+package ratelimiter
+
+import (
+	"time"
+)
+
+// SlidingWindowRateLimiter keeps the times of each client's requests in
+// the last window, so limit is never exceeded in any window, unlike the
+// fixed window which allows twice the limit across a boundary.
+type SlidingWindowRateLimiter struct {
+	clients *table[[]time.Time]
+	limit   int
+	window  time.Duration
+}
+
+func NewSlidingWindowLimiter(limit int, window time.Duration, opts Options) *SlidingWindowRateLimiter {
+	rl := &SlidingWindowRateLimiter{
+		clients: newTable[[]time.Time](opts, window),
+		limit:   limit,
+		window:  window,
+	}
+
+	if opts.Janitor != nil {
+		opts.Janitor.Register(rl)
+	}
+	return rl
+}
+
+func (rl *SlidingWindowRateLimiter) Allow(ip string) (bool, time.Duration) {
+	res := rl.Take(ip)
+	return res.Allowed, res.RetryAfter
+}
+
+func (rl *SlidingWindowRateLimiter) Take(ip string) Result {
+	rl.clients.mu.Lock()
+	defer rl.clients.mu.Unlock()
+
+	now := rl.clients.now()
+	e := rl.clients.entry(ip, now, func() []time.Time {
+		return make([]time.Time, 0, rl.limit)
+	})
+
+	// the log is ordered, so expired requests are at its start
+	start := now.Add(-rl.window)
+	expired := 0
+	for expired < len(e.state) && !e.state[expired].After(start) {
+		expired++
+	}
+	if expired > 0 {
+		e.state = append(e.state[:0], e.state[expired:]...)
+	}
+
+	res := Result{Limit: rl.limit}
+	if len(e.state) < rl.limit {
+		e.state = append(e.state, now)
+		res.Allowed = true
+	}
+
+	res.Remaining = rl.limit - len(e.state)
+	if len(e.state) > 0 {
+		// the oldest request is the next to free a slot
+		res.Reset = e.state[0].Add(rl.window).Sub(now)
+	}
+	if !res.Allowed {
+		res.RetryAfter = res.Reset
+	}
+	return res
+}
+
+func (rl *SlidingWindowRateLimiter) Sweep(now time.Time) {
+	rl.clients.Sweep(now)
+}
@@ -0,0 +1,112 @@
+package ratelimiter
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// DefaultMaxKeys bounds how many clients a limiter tracks when Options
+// does not say otherwise.
+const DefaultMaxKeys = 100_000
+
+// Options are shared by the limiters. Each only reads the fields that
+// apply to it.
+type Options struct {
+	// MaxKeys bounds memory use. Once reached, the least recently seen
+	// client is forgotten, which only ever gives it a fresh budget.
+	MaxKeys int
+	// Janitor sweeps idle clients. Without one they are only dropped when
+	// MaxKeys is reached.
+	Janitor *Janitor
+	// Redis holds client state for the redis backend.
+	Redis redis.Scripter
+	// RedisTimeout defaults to DefaultRedisTimeout.
+	RedisTimeout time.Duration
+	// OnError is told about every Redis failure, which would otherwise
+	// only show as requests being let through or refused.
+	OnError func(error)
+
+	now func() time.Time
+}
+
+type tableEntry[T any] struct {
+	key      string
+	lastSeen time.Time
+	state    T
+}
+
+// table holds per-client state in least recently seen order, so idle
+// clients are found at the back without scanning the whole map.
+type table[T any] struct {
+	mu      sync.Mutex
+	ll      *list.List
+	items   map[string]*list.Element
+	maxKeys int
+	// idle is how long after its last request a client is back to a full
+	// budget, at which point its state can be dropped.
+	idle time.Duration
+	now  func() time.Time
+}
+
+func newTable[T any](opts Options, idle time.Duration) *table[T] {
+	t := &table[T]{
+		ll:      list.New(),
+		items:   make(map[string]*list.Element),
+		maxKeys: opts.MaxKeys,
+		idle:    idle,
+		now:     opts.now,
+	}
+	if t.maxKeys <= 0 {
+		t.maxKeys = DefaultMaxKeys
+	}
+	if t.now == nil {
+		t.now = time.Now
+	}
+	return t
+}
+
+// entry returns the state of key, creating it with init. It must be called
+// with t.mu held.
+func (t *table[T]) entry(key string, now time.Time, init func() T) *tableEntry[T] {
+	if el, ok := t.items[key]; ok {
+		e := el.Value.(*tableEntry[T])
+		e.lastSeen = now
+		t.ll.MoveToFront(el)
+		return e
+	}
+
+	for t.ll.Len() >= t.maxKeys {
+		t.remove(t.ll.Back())
+	}
+
+	e := &tableEntry[T]{key: key, lastSeen: now, state: init()}
+	t.items[key] = t.ll.PushFront(e)
+	return e
+}
+
+func (t *table[T]) remove(el *list.Element) {
+	e := t.ll.Remove(el).(*tableEntry[T])
+	delete(t.items, e.key)
+}
+
+// Sweep drops clients idle since before now.
+func (t *table[T]) Sweep(now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for el := t.ll.Back(); el != nil; el = t.ll.Back() {
+		if now.Sub(el.Value.(*tableEntry[T]).lastSeen) < t.idle {
+			return
+		}
+		t.remove(el)
+	}
+}
+
+func (t *table[T]) Len() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.ll.Len()
+}
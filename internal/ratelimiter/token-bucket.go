@@ -0,0 +1,72 @@
+package ratelimiter
+
+import (
+	"time"
+)
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// TokenBucketRateLimiter refills each client's bucket continuously at
+// limit per window, and lets it spend up to limit requests at once.
+type TokenBucketRateLimiter struct {
+	clients *table[bucket]
+	rate    float64 // tokens per second
+	burst   float64
+}
+
+func NewTokenBucketLimiter(limit int, window time.Duration, opts Options) *TokenBucketRateLimiter {
+	rl := &TokenBucketRateLimiter{
+		rate:  float64(limit) / window.Seconds(),
+		burst: float64(limit),
+	}
+	// an empty bucket is full again one window later
+	rl.clients = newTable[bucket](opts, window)
+
+	if opts.Janitor != nil {
+		opts.Janitor.Register(rl)
+	}
+	return rl
+}
+
+func (rl *TokenBucketRateLimiter) Allow(ip string) (bool, time.Duration) {
+	res := rl.Take(ip)
+	return res.Allowed, res.RetryAfter
+}
+
+func (rl *TokenBucketRateLimiter) Take(ip string) Result {
+	rl.clients.mu.Lock()
+	defer rl.clients.mu.Unlock()
+
+	now := rl.clients.now()
+	e := rl.clients.entry(ip, now, func() bucket {
+		return bucket{tokens: rl.burst, last: now}
+	})
+
+	b := &e.state
+	b.tokens = min(rl.burst, b.tokens+now.Sub(b.last).Seconds()*rl.rate)
+	b.last = now
+
+	res := Result{Limit: int(rl.burst)}
+	if b.tokens >= 1 {
+		b.tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = rl.refill(1 - b.tokens)
+	}
+
+	res.Remaining = int(b.tokens)
+	res.Reset = rl.refill(rl.burst - b.tokens)
+	return res
+}
+
+// refill is how long it takes for n tokens to be added.
+func (rl *TokenBucketRateLimiter) refill(n float64) time.Duration {
+	return time.Duration(n / rl.rate * float64(time.Second))
+}
+
+func (rl *TokenBucketRateLimiter) Sweep(now time.Time) {
+	rl.clients.Sweep(now)
+}
@@ -0,0 +1,390 @@
+// Package realtime delivers events, such as new posts in a user's feed, to
+// connected clients. Events are published to topics and fanned out by an
+// in-process Hub, which can share them between instances through a Backend.
+package realtime
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	EventPost    = "post"
+	EventComment = "comment"
+	EventFollow  = "follow"
+	EventTyping  = "typing"
+)
+
+// FeedTopic carries new posts for the feed of a user.
+func FeedTopic(userID uuid.UUID) string {
+	return "feed:" + userID.String()
+}
+
+// NotificationsTopic carries comments on a user's posts and new followers.
+func NotificationsTopic(userID uuid.UUID) string {
+	return "notifications:" + userID.String()
+}
+
+// UserTopic is joined by every connection of a user, so they can all be
+// closed at once with Hub.Disconnect. It carries no events.
+func UserTopic(userID uuid.UUID) string {
+	return "user:" + userID.String()
+}
+
+// PostTopic carries new comments and typing indicators of a post.
+func PostTopic(postID uuid.UUID) string {
+	return "post:" + postID.String()
+}
+
+type Event struct {
+	// ID orders events and is used to resume a stream.
+	ID    int64           `json:"id"`
+	Topic string          `json:"topic,omitempty"`
+	Type  string          `json:"type"`
+	Data  json.RawMessage `json:"data"`
+}
+
+// Message addresses one event to many topics, so a post is published once
+// no matter how many followers its author has.
+type Message struct {
+	Topics []string `json:"topics"`
+	Event  Event    `json:"event"`
+	// Transient events, like typing indicators, are not kept for replay.
+	Transient bool `json:"transient,omitempty"`
+	// Disconnect closes the subscriptions of the topics instead of
+	// delivering an event.
+	Disconnect bool `json:"disconnect,omitempty"`
+}
+
+// Backend carries messages between instances. Messages published by any
+// instance, including this one, are passed to deliver.
+type Backend interface {
+	Publish(ctx context.Context, msg Message) error
+	Subscribe(ctx context.Context, deliver func(Message)) error
+}
+
+type Config struct {
+	// Redis shares events between instances over Redis pub/sub.
+	Redis bool
+	// ReplaySize and ReplayWindow bound the events kept per topic for
+	// clients resuming from their last event id.
+	ReplaySize   int
+	ReplayWindow time.Duration
+}
+
+// subscriberBuffer is how many events a slow client may fall behind before
+// it is disconnected. It resumes from the replay buffer on reconnect.
+const subscriberBuffer = 32
+
+// Subscription receives the events of the topics added to it on C. C is
+// closed when the subscription is closed, either by its owner, because it
+// fell behind, or because the hub shut down.
+type Subscription struct {
+	C <-chan Event
+
+	c      chan Event
+	hub    *Hub
+	topics map[string]struct{}
+	closed bool
+	// disconnected is set when the subscription was closed by
+	// Hub.Disconnect rather than dropped.
+	disconnected bool
+}
+
+// Add subscribes to topics and returns their buffered events newer than
+// lastEventID, oldest first, so the client can catch up.
+func (s *Subscription) Add(lastEventID int64, topics ...string) []Event {
+	h := s.hub
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if s.closed {
+		return nil
+	}
+
+	var missed []Event
+	for _, topic := range topics {
+		if _, ok := s.topics[topic]; ok {
+			continue
+		}
+		s.topics[topic] = struct{}{}
+
+		if h.subscribers[topic] == nil {
+			h.subscribers[topic] = make(map[*Subscription]struct{})
+		}
+		h.subscribers[topic][s] = struct{}{}
+
+		if lastEventID > 0 {
+			for _, e := range h.replay[topic] {
+				if e.ID > lastEventID {
+					missed = append(missed, e)
+				}
+			}
+		}
+	}
+
+	slices.SortStableFunc(missed, func(a, b Event) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+
+	return missed
+}
+
+// Remove unsubscribes from topic.
+func (s *Subscription) Remove(topic string) {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+
+	if _, ok := s.topics[topic]; !ok {
+		return
+	}
+
+	delete(s.topics, topic)
+	s.hub.removeSubscriber(topic, s)
+}
+
+// Disconnected reports whether the subscription was closed by
+// Hub.Disconnect, in which case its client should not resume.
+func (s *Subscription) Disconnected() bool {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+
+	return s.disconnected
+}
+
+// Close stops the subscription. It is safe to call more than once.
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+
+	s.hub.closeSubscription(s)
+}
+
+type Hub struct {
+	cfg     Config
+	backend Backend
+
+	mu          sync.Mutex
+	lastID      int64
+	closed      bool
+	subscribers map[string]map[*Subscription]struct{}
+	replay      map[string][]Event
+}
+
+// NewHub creates a hub. With a nil backend events are only delivered to
+// clients of this instance.
+func NewHub(cfg Config, backend Backend) *Hub {
+	return &Hub{
+		cfg:         cfg,
+		backend:     backend,
+		subscribers: make(map[string]map[*Subscription]struct{}),
+		replay:      make(map[string][]Event),
+	}
+}
+
+// Run receives messages from the backend and prunes expired replay buffers
+// until ctx is cancelled.
+func (h *Hub) Run(ctx context.Context) error {
+	go func() {
+		if h.cfg.ReplayWindow <= 0 {
+			return
+		}
+
+		ticker := time.NewTicker(h.cfg.ReplayWindow)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				h.prune()
+			}
+		}
+	}()
+
+	if h.backend == nil {
+		<-ctx.Done()
+		return nil
+	}
+
+	return h.backend.Subscribe(ctx, h.deliver)
+}
+
+// Subscribe creates a subscription without topics.
+func (h *Hub) Subscribe() *Subscription {
+	c := make(chan Event, subscriberBuffer)
+	sub := &Subscription{C: c, c: c, hub: h, topics: make(map[string]struct{})}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		sub.closed = true
+		close(c)
+	}
+
+	return sub
+}
+
+// Publish sends an event of type typ with data to every topic in topics.
+func (h *Hub) Publish(ctx context.Context, topics []string, typ string, data any) error {
+	return h.publish(ctx, topics, typ, data, false)
+}
+
+// Signal is Publish for short-lived events that are not worth replaying.
+func (h *Hub) Signal(ctx context.Context, topics []string, typ string, data any) error {
+	return h.publish(ctx, topics, typ, data, true)
+}
+
+// Disconnect closes every subscription of topics, on all instances, e.g.
+// the connections of a suspended user.
+func (h *Hub) Disconnect(ctx context.Context, topics ...string) error {
+	if len(topics) == 0 {
+		return nil
+	}
+
+	msg := Message{Topics: topics, Disconnect: true}
+	if h.backend == nil {
+		h.deliver(msg)
+		return nil
+	}
+
+	return h.backend.Publish(ctx, msg)
+}
+
+// Close disconnects every subscriber, e.g. when the server shuts down.
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.closed = true
+	for _, subs := range h.subscribers {
+		for sub := range subs {
+			h.closeSubscription(sub)
+		}
+	}
+}
+
+func (h *Hub) publish(ctx context.Context, topics []string, typ string, data any, transient bool) error {
+	if len(topics) == 0 {
+		return nil
+	}
+
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	msg := Message{
+		Topics:    topics,
+		Event:     Event{ID: h.nextID(), Type: typ, Data: raw},
+		Transient: transient,
+	}
+
+	if h.backend == nil {
+		h.deliver(msg)
+		return nil
+	}
+
+	return h.backend.Publish(ctx, msg)
+}
+
+func (h *Hub) deliver(msg Message) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if msg.Disconnect {
+		for _, topic := range msg.Topics {
+			for sub := range h.subscribers[topic] {
+				sub.disconnected = true
+				h.closeSubscription(sub)
+			}
+		}
+		return
+	}
+
+	// keep ids increasing across instances for events published elsewhere
+	h.lastID = max(h.lastID, msg.Event.ID)
+
+	for _, topic := range msg.Topics {
+		e := msg.Event
+		e.Topic = topic
+
+		if !msg.Transient && h.cfg.ReplaySize > 0 {
+			buf := append(h.replay[topic], e)
+			if len(buf) > h.cfg.ReplaySize {
+				buf = buf[len(buf)-h.cfg.ReplaySize:]
+			}
+			h.replay[topic] = buf
+		}
+
+		for sub := range h.subscribers[topic] {
+			select {
+			case sub.c <- e:
+			default:
+				// the client is not keeping up, drop it and let it resume
+				h.closeSubscription(sub)
+			}
+		}
+	}
+}
+
+// closeSubscription must be called with h.mu held.
+func (h *Hub) closeSubscription(sub *Subscription) {
+	if sub.closed {
+		return
+	}
+
+	sub.closed = true
+	for topic := range sub.topics {
+		h.removeSubscriber(topic, sub)
+	}
+	close(sub.c)
+}
+
+// removeSubscriber must be called with h.mu held.
+func (h *Hub) removeSubscriber(topic string, sub *Subscription) {
+	subs := h.subscribers[topic]
+	delete(subs, sub)
+	if len(subs) == 0 {
+		delete(h.subscribers, topic)
+	}
+}
+
+// nextID returns an id based on the current time in microseconds, so ids
+// from different instances interleave in roughly the order they happened.
+func (h *Hub) nextID() int64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.lastID = max(h.lastID+1, time.Now().UnixMicro())
+	return h.lastID
+}
+
+func (h *Hub) prune() {
+	cutoff := time.Now().Add(-h.cfg.ReplayWindow).UnixMicro()
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for topic, buf := range h.replay {
+		i := 0
+		for i < len(buf) && buf[i].ID < cutoff {
+			i++
+		}
+
+		if i == len(buf) {
+			delete(h.replay, topic)
+		} else if i > 0 {
+			h.replay[topic] = append([]Event(nil), buf[i:]...)
+		}
+	}
+}
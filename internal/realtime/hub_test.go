@@ -0,0 +1,322 @@
+package realtime
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func newTestHub() *Hub {
+	return NewHub(Config{ReplaySize: 3, ReplayWindow: time.Minute}, nil)
+}
+
+// receive waits for the next event on sub, failing the test on timeout.
+func receive(t *testing.T, sub *Subscription) Event {
+	t.Helper()
+
+	select {
+	case e, ok := <-sub.C:
+		if !ok {
+			t.Fatal("expected an event; subscription was closed")
+		}
+		return e
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for an event")
+	}
+	return Event{}
+}
+
+func assertNoEvent(t *testing.T, sub *Subscription) {
+	t.Helper()
+
+	select {
+	case e, ok := <-sub.C:
+		if ok {
+			t.Fatalf("expected no event; got %+v", e)
+		}
+	default:
+	}
+}
+
+func TestHubDelivery(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("events reach subscribers of their topics", func(t *testing.T) {
+		h := newTestHub()
+
+		a := h.Subscribe()
+		defer a.Close()
+		a.Add(0, "feed:a")
+
+		b := h.Subscribe()
+		defer b.Close()
+		b.Add(0, "feed:b")
+
+		if err := h.Publish(ctx, []string{"feed:a"}, EventPost, map[string]string{"title": "hi"}); err != nil {
+			t.Fatal(err)
+		}
+
+		e := receive(t, a)
+		if e.Topic != "feed:a" || e.Type != EventPost || string(e.Data) != `{"title":"hi"}` {
+			t.Errorf("unexpected event %+v", e)
+		}
+		assertNoEvent(t, b)
+	})
+
+	t.Run("removed topics are no longer delivered", func(t *testing.T) {
+		h := newTestHub()
+
+		sub := h.Subscribe()
+		defer sub.Close()
+		sub.Add(0, "feed:a", "notifications:a")
+		sub.Remove("feed:a")
+
+		h.Publish(ctx, []string{"feed:a"}, EventPost, nil)
+		h.Publish(ctx, []string{"notifications:a"}, EventFollow, nil)
+
+		if e := receive(t, sub); e.Topic != "notifications:a" {
+			t.Errorf("expected only the remaining topic; got %+v", e)
+		}
+		assertNoEvent(t, sub)
+	})
+
+	t.Run("ids increase", func(t *testing.T) {
+		h := newTestHub()
+
+		sub := h.Subscribe()
+		defer sub.Close()
+		sub.Add(0, "t")
+
+		var last int64
+		for range 5 {
+			h.Publish(ctx, []string{"t"}, EventPost, nil)
+			e := receive(t, sub)
+			if e.ID <= last {
+				t.Fatalf("expected id above %d; got %d", last, e.ID)
+			}
+			last = e.ID
+		}
+	})
+}
+
+func TestHubReplay(t *testing.T) {
+	ctx := context.Background()
+	h := newTestHub()
+
+	for _, topic := range []string{"feed:a", "notifications:a", "feed:a", "feed:a", "feed:a"} {
+		if err := h.Publish(ctx, []string{topic}, EventPost, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	h.Signal(ctx, []string{"feed:a"}, EventTyping, nil)
+
+	// the first event is only used as the last seen id
+	first := h.Subscribe()
+	all := first.Add(1, "feed:a", "notifications:a")
+	first.Close()
+
+	if len(all) != 4 {
+		t.Fatalf("expected 3 buffered feed events and 1 notification; got %d", len(all))
+	}
+	for i := 1; i < len(all); i++ {
+		if all[i].ID <= all[i-1].ID {
+			t.Fatalf("expected events oldest first; got %d before %d", all[i-1].ID, all[i].ID)
+		}
+	}
+	for _, e := range all {
+		if e.Type == EventTyping {
+			t.Error("expected transient events not to be replayed")
+		}
+	}
+
+	t.Run("only events after the last id are replayed", func(t *testing.T) {
+		sub := h.Subscribe()
+		defer sub.Close()
+
+		missed := sub.Add(all[1].ID, "feed:a", "notifications:a")
+		if len(missed) != 2 || missed[0].ID != all[2].ID || missed[1].ID != all[3].ID {
+			t.Errorf("expected the last 2 events; got %+v", missed)
+		}
+	})
+
+	t.Run("new subscribers without a last id get no replay", func(t *testing.T) {
+		sub := h.Subscribe()
+		defer sub.Close()
+
+		if missed := sub.Add(0, "feed:a"); len(missed) != 0 {
+			t.Errorf("expected no replay; got %d events", len(missed))
+		}
+	})
+
+	t.Run("expired events are pruned", func(t *testing.T) {
+		h := NewHub(Config{ReplaySize: 3, ReplayWindow: time.Microsecond}, nil)
+		h.Publish(ctx, []string{"feed:a"}, EventPost, nil)
+
+		time.Sleep(time.Millisecond)
+		h.prune()
+
+		sub := h.Subscribe()
+		defer sub.Close()
+		if missed := sub.Add(1, "feed:a"); len(missed) != 0 {
+			t.Errorf("expected the replay buffer to be pruned; got %d events", len(missed))
+		}
+	})
+}
+
+func TestHubDropsStalledSubscriber(t *testing.T) {
+	ctx := context.Background()
+	h := newTestHub()
+
+	stalled := h.Subscribe()
+	stalled.Add(0, "t")
+
+	live := h.Subscribe()
+	defer live.Close()
+	live.Add(0, "t")
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for range live.C {
+		}
+	}()
+
+	for range subscriberBuffer + 1 {
+		if err := h.Publish(ctx, []string{"t"}, EventPost, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	received := 0
+	for range stalled.C {
+		received++
+	}
+	if received != subscriberBuffer {
+		t.Errorf("expected the buffered %d events before the close; got %d", subscriberBuffer, received)
+	}
+
+	// closing a dropped subscription again is harmless
+	stalled.Close()
+	if missed := stalled.Add(0, "t"); missed != nil {
+		t.Error("expected a closed subscription to ignore new topics")
+	}
+
+	h.Close()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("expected closing the hub to close the remaining subscriptions")
+	}
+
+	if _, ok := <-h.Subscribe().C; ok {
+		t.Error("expected subscriptions of a closed hub to be closed")
+	}
+}
+
+func TestHubDisconnect(t *testing.T) {
+	ctx := context.Background()
+	h := newTestHub()
+
+	kicked := h.Subscribe()
+	kicked.Add(0, "user:a", "feed:a")
+
+	other := h.Subscribe()
+	defer other.Close()
+	other.Add(0, "user:b", "feed:a")
+
+	if err := h.Disconnect(ctx, "user:a"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := <-kicked.C; ok {
+		t.Fatal("expected the subscription to be closed")
+	}
+	if !kicked.Disconnected() {
+		t.Error("expected the subscription to be marked as disconnected")
+	}
+
+	// the closed subscription left its other topics as well
+	h.Publish(ctx, []string{"feed:a"}, EventPost, nil)
+	receive(t, other)
+	if other.Disconnected() {
+		t.Error("expected other subscriptions to be left alone")
+	}
+}
+
+func TestRedisBackendFanIn(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer rdb.Close()
+
+	const channel = "realtime-test"
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	hubs := make([]*Hub, 2)
+	for i := range hubs {
+		hubs[i] = NewHub(Config{ReplaySize: 3, ReplayWindow: time.Minute}, NewRedisBackend(rdb, channel))
+		go hubs[i].Run(ctx)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for mr.PubSubNumSub(channel)[channel] < len(hubs) {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the hubs to subscribe")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	subs := make([]*Subscription, len(hubs))
+	for i, h := range hubs {
+		subs[i] = h.Subscribe()
+		defer subs[i].Close()
+		subs[i].Add(0, "feed:a")
+	}
+
+	if err := hubs[0].Publish(ctx, []string{"feed:a", "feed:b"}, EventPost, "hello"); err != nil {
+		t.Fatal(err)
+	}
+
+	var ids []int64
+	for i, sub := range subs {
+		e := receive(t, sub)
+		if e.Topic != "feed:a" || string(e.Data) != `"hello"` {
+			t.Errorf("hub %d: unexpected event %+v", i, e)
+		}
+		ids = append(ids, e.ID)
+	}
+	if ids[0] != ids[1] {
+		t.Errorf("expected both instances to see the same event id; got %v", ids)
+	}
+
+	// events from elsewhere are replayed and move the local ids forward
+	next := hubs[1].nextID()
+	if next <= ids[0] {
+		t.Errorf("expected ids after %d; got %d", ids[0], next)
+	}
+	sub := hubs[1].Subscribe()
+	defer sub.Close()
+	if missed := sub.Add(1, "feed:b"); len(missed) != 1 {
+		t.Errorf("expected the remote event to be replayable; got %d events", len(missed))
+	}
+
+	// disconnects reach the other instance as well
+	kicked := hubs[1].Subscribe()
+	kicked.Add(0, "user:a")
+	if err := hubs[0].Disconnect(ctx, "user:a"); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case _, ok := <-kicked.C:
+		if ok || !kicked.Disconnected() {
+			t.Error("expected the remote subscription to be disconnected")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for the disconnect")
+	}
+}
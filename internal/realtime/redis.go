@@ -0,0 +1,55 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisBackend shares events between instances over a Redis pub/sub channel.
+type RedisBackend struct {
+	rdb     *redis.Client
+	channel string
+}
+
+func NewRedisBackend(rdb *redis.Client, channel string) *RedisBackend {
+	return &RedisBackend{rdb: rdb, channel: channel}
+}
+
+func (b *RedisBackend) Publish(ctx context.Context, msg Message) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	return b.rdb.Publish(ctx, b.channel, data).Err()
+}
+
+func (b *RedisBackend) Subscribe(ctx context.Context, deliver func(Message)) error {
+	pubsub := b.rdb.Subscribe(ctx, b.channel)
+	defer pubsub.Close()
+
+	// wait for the subscription so no message published after Run is lost
+	if _, err := pubsub.Receive(ctx); err != nil {
+		return err
+	}
+
+	ch := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case m, ok := <-ch:
+			if !ok {
+				return nil
+			}
+
+			var msg Message
+			if err := json.Unmarshal([]byte(m.Payload), &msg); err != nil {
+				continue
+			}
+			deliver(msg)
+		}
+	}
+}
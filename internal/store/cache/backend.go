@@ -0,0 +1,60 @@
+package cache
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// backend is the key/value store behind the typed caches. Values are the
+// JSON encoded entities, so every backend can hold every cache.
+type backend interface {
+	// Get reports whether key was found.
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Delete(ctx context.Context, keys ...string) error
+	// Incr increments the counters at keys and resets their ttl. Missing
+	// counters start from the current time in nanoseconds, so a counter that
+	// was evicted or expired never repeats a value it had before.
+	Incr(ctx context.Context, ttl time.Duration, keys ...string) error
+}
+
+type redisBackend struct {
+	rdb *redis.Client
+}
+
+func (b *redisBackend) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	data, err := b.rdb.Get(ctx, key).Bytes()
+	if err == redis.Nil {
+		return nil, false, nil
+	} else if err != nil {
+		return nil, false, err
+	}
+	return data, true, nil
+}
+
+func (b *redisBackend) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return b.rdb.SetEx(ctx, key, value, ttl).Err()
+}
+
+func (b *redisBackend) Delete(ctx context.Context, keys ...string) error {
+	return b.rdb.Del(ctx, keys...).Err()
+}
+
+func (b *redisBackend) Incr(ctx context.Context, ttl time.Duration, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	_, err := b.rdb.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		seed := time.Now().UnixNano()
+		for _, key := range keys {
+			pipe.SetNX(ctx, key, seed, ttl)
+			pipe.Incr(ctx, key)
+			pipe.Expire(ctx, key, ttl)
+		}
+		return nil
+	})
+	return err
+}
@@ -0,0 +1,87 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/JaskiratAnand/go-social/internal/ranking"
+	"github.com/JaskiratAnand/go-social/internal/store"
+)
+
+type ExploreStore struct {
+	kv backend
+}
+
+// ExploreExpTime outlives the refresh interval of the explore job so the
+// public endpoints keep serving between two runs.
+const ExploreExpTime = 30 * time.Minute
+
+const (
+	explorePostsKey = "explore-posts"
+	trendingTagsKey = "trending-tags"
+)
+
+func (s *ExploreStore) GetPosts(ctx context.Context) ([]store.GetExplorePostsRow, error) {
+	posts := []store.GetExplorePostsRow{}
+	ok, err := s.get(ctx, explorePostsKey, &posts)
+	if !ok {
+		return nil, err
+	}
+	return posts, nil
+}
+
+func (s *ExploreStore) SetPosts(ctx context.Context, posts []store.GetExplorePostsRow) error {
+	if posts == nil {
+		posts = []store.GetExplorePostsRow{}
+	}
+	return s.set(ctx, explorePostsKey, posts)
+}
+
+func (s *ExploreStore) GetTrendingTags(ctx context.Context) ([]ranking.TrendingTag, error) {
+	tags := []ranking.TrendingTag{}
+	ok, err := s.get(ctx, trendingTagsKey, &tags)
+	if !ok {
+		return nil, err
+	}
+	return tags, nil
+}
+
+func (s *ExploreStore) SetTrendingTags(ctx context.Context, tags []ranking.TrendingTag) error {
+	if tags == nil {
+		tags = []ranking.TrendingTag{}
+	}
+	return s.set(ctx, trendingTagsKey, tags)
+}
+
+// Delete drops the explore feed and trending tags, so they are computed
+// again on the next read.
+func (s *ExploreStore) Delete(ctx context.Context) {
+	s.kv.Delete(ctx, explorePostsKey, trendingTagsKey)
+}
+
+func (s *ExploreStore) get(ctx context.Context, key string, v any) (bool, error) {
+	data, ok, err := s.kv.Get(ctx, key)
+	if err != nil {
+		return false, err
+	} else if !ok {
+		recordMiss("explore")
+		return false, nil
+	}
+
+	if err := json.Unmarshal(data, v); err != nil {
+		return false, err
+	}
+
+	recordHit("explore")
+	return true, nil
+}
+
+func (s *ExploreStore) set(ctx context.Context, key string, v any) error {
+	json, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	return s.kv.Set(ctx, key, json, jitter(ExploreExpTime))
+}
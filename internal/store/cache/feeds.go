@@ -0,0 +1,115 @@
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/JaskiratAnand/go-social/internal/store"
+	"github.com/google/uuid"
+)
+
+type FeedStore struct {
+	kv backend
+}
+
+// FeedExpTime is kept short because pages are only invalidated when the
+// set of posts changes, comment counts in them may lag by this much.
+const FeedExpTime = time.Minute
+
+// feedGenerationExpTime keeps the generation counters of idle feeds from
+// piling up. Counters restart from the clock, so one that expired or was
+// evicted never matches pages written under an older value.
+const feedGenerationExpTime = time.Hour
+
+func feedGenerationKey(userID uuid.UUID) string {
+	return fmt.Sprintf("feed-gen-%v", userID)
+}
+
+// key derives the cache key of a page from every query param. Pages are
+// scoped to the current generation of the user's feed, so bumping it in
+// Invalidate drops all of them without scanning keys.
+func (s *FeedStore) key(ctx context.Context, params store.GetUserFeedParams) (string, error) {
+	gen, err := s.generation(ctx, params.UserID)
+	if err != nil {
+		return "", err
+	}
+
+	raw, err := json.Marshal(params)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(raw)
+
+	return fmt.Sprintf("feed-%v-%s-%x", params.UserID, gen, sum[:8]), nil
+}
+
+// generation returns the current generation of the feed of userID, starting
+// one when there is none. Pages are never keyed without a generation, or
+// they would be served again whenever the counter goes missing.
+func (s *FeedStore) generation(ctx context.Context, userID uuid.UUID) (string, error) {
+	key := feedGenerationKey(userID)
+
+	data, ok, err := s.kv.Get(ctx, key)
+	if err != nil || ok {
+		return string(data), err
+	}
+
+	if err := s.kv.Incr(ctx, feedGenerationExpTime, key); err != nil {
+		return "", err
+	}
+	data, _, err = s.kv.Get(ctx, key)
+	return string(data), err
+}
+
+func (s *FeedStore) Get(ctx context.Context, params store.GetUserFeedParams) ([]store.GetUserFeedRow, error) {
+	cacheKey, err := s.key(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	data, ok, err := s.kv.Get(ctx, cacheKey)
+	if err != nil {
+		return nil, err
+	} else if !ok {
+		recordMiss("feeds")
+		return nil, nil
+	}
+
+	feed := []store.GetUserFeedRow{}
+	if err := json.Unmarshal(data, &feed); err != nil {
+		return nil, err
+	}
+
+	recordHit("feeds")
+	return feed, nil
+}
+
+func (s *FeedStore) Set(ctx context.Context, params store.GetUserFeedParams, feed []store.GetUserFeedRow) error {
+	cacheKey, err := s.key(ctx, params)
+	if err != nil {
+		return err
+	}
+
+	if feed == nil {
+		feed = []store.GetUserFeedRow{}
+	}
+
+	json, err := json.Marshal(feed)
+	if err != nil {
+		return err
+	}
+
+	return s.kv.Set(ctx, cacheKey, json, jitter(FeedExpTime))
+}
+
+// Invalidate drops every cached page of the feeds of userIDs.
+func (s *FeedStore) Invalidate(ctx context.Context, userIDs ...uuid.UUID) error {
+	keys := make([]string, len(userIDs))
+	for i, id := range userIDs {
+		keys[i] = feedGenerationKey(id)
+	}
+	return s.kv.Incr(ctx, feedGenerationExpTime, keys...)
+}
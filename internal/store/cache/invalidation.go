@@ -0,0 +1,124 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// Run retries a failed subscription after a delay doubling from
+// busMinBackoff up to busMaxBackoff.
+var (
+	busMinBackoff = 100 * time.Millisecond
+	busMaxBackoff = 30 * time.Second
+)
+
+// InvalidationBus tells every instance to drop keys from its local cache
+// tier over a Redis pub/sub channel. Redis itself is always written
+// directly, only copies held in process memory need to hear about changes.
+type InvalidationBus struct {
+	rdb     *redis.Client
+	channel string
+	// origin identifies this instance, which already applied its own
+	// invalidations before publishing them.
+	origin string
+
+	mu       sync.RWMutex
+	handlers []func(keys []string)
+}
+
+type invalidation struct {
+	Origin string   `json:"origin"`
+	Keys   []string `json:"keys"`
+}
+
+func NewInvalidationBus(rdb *redis.Client, channel string) *InvalidationBus {
+	return &InvalidationBus{rdb: rdb, channel: channel, origin: uuid.NewString()}
+}
+
+func (b *InvalidationBus) Publish(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	data, err := json.Marshal(invalidation{Origin: b.origin, Keys: keys})
+	if err != nil {
+		return err
+	}
+
+	return b.rdb.Publish(ctx, b.channel, data).Err()
+}
+
+// onInvalidate registers a local tier to drop keys invalidated elsewhere.
+func (b *InvalidationBus) onInvalidate(fn func(keys []string)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers = append(b.handlers, fn)
+}
+
+// Run applies invalidations published by other instances until ctx is
+// cancelled. While Redis is unreachable the subscription is retried with
+// backoff, and onError is told about every failed attempt.
+func (b *InvalidationBus) Run(ctx context.Context, onError func(error)) {
+	backoff := busMinBackoff
+	for {
+		subscribed, err := b.listen(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if subscribed {
+			backoff = busMinBackoff
+		}
+		if err != nil && onError != nil {
+			onError(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, busMaxBackoff)
+	}
+}
+
+// listen subscribes to the channel and applies invalidations until ctx is
+// cancelled or the subscription ends.
+func (b *InvalidationBus) listen(ctx context.Context) (subscribed bool, err error) {
+	pubsub := b.rdb.Subscribe(ctx, b.channel)
+	defer pubsub.Close()
+
+	// wait for the subscription so no invalidation published after Run is lost
+	if _, err := pubsub.Receive(ctx); err != nil {
+		return false, err
+	}
+
+	ch := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return true, nil
+		case m, ok := <-ch:
+			if !ok {
+				return true, nil
+			}
+
+			var msg invalidation
+			if err := json.Unmarshal([]byte(m.Payload), &msg); err != nil || msg.Origin == b.origin {
+				continue
+			}
+
+			recordInvalidation(len(msg.Keys))
+
+			b.mu.RLock()
+			for _, fn := range b.handlers {
+				fn(msg.Keys)
+			}
+			b.mu.RUnlock()
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+const testChannel = "cache-invalidation-test"
+
+// waitSubscribed waits until n buses listen on the test channel.
+func waitSubscribed(t *testing.T, mr *miniredis.Miniredis, n int) {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for mr.PubSubNumSub(testChannel)[testChannel] < n {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the buses to subscribe")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// eventually polls cond until it holds, failing the test after a second.
+func eventually(t *testing.T, msg string, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal(msg)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestInvalidationBus(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer rdb.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	instances := make([]*tieredBackend, 2)
+	for i := range instances {
+		bus := NewInvalidationBus(rdb, testChannel)
+		instances[i] = newTieredBackend(rdb, MemoryConfig{}, time.Minute, bus)
+		go bus.Run(ctx, nil)
+	}
+	waitSubscribed(t, mr, len(instances))
+
+	a, b := instances[0], instances[1]
+	cached := func(b *tieredBackend, key string) bool {
+		_, ok, _ := b.local.Get(ctx, key)
+		return ok
+	}
+
+	t.Run("writes drop the copies of other instances", func(t *testing.T) {
+		if err := a.Set(ctx, "k", []byte("v1"), time.Hour); err != nil {
+			t.Fatal(err)
+		}
+		// reading through the remote tier fills the local copy of b
+		if v, ok, _ := b.Get(ctx, "k"); !ok || string(v) != "v1" {
+			t.Fatalf("expected v1; got %q, %v", v, ok)
+		}
+
+		if err := a.Set(ctx, "k", []byte("v2"), time.Hour); err != nil {
+			t.Fatal(err)
+		}
+		eventually(t, "expected the stale copy to be dropped", func() bool { return !cached(b, "k") })
+
+		if v, _, _ := b.Get(ctx, "k"); string(v) != "v2" {
+			t.Errorf("expected v2; got %q", v)
+		}
+	})
+
+	t.Run("deletes drop the copies of other instances", func(t *testing.T) {
+		b.Get(ctx, "k")
+		if err := a.Delete(ctx, "k"); err != nil {
+			t.Fatal(err)
+		}
+		eventually(t, "expected the deleted copy to be dropped", func() bool { return !cached(b, "k") })
+	})
+
+	t.Run("own invalidations are ignored", func(t *testing.T) {
+		// once b dropped its copy, a has seen the broadcast as well
+		b.local.Set(ctx, "own", []byte("v"), time.Hour)
+		if err := a.Set(ctx, "own", []byte("v"), time.Hour); err != nil {
+			t.Fatal(err)
+		}
+		eventually(t, "expected the broadcast to arrive", func() bool { return !cached(b, "own") })
+
+		if !cached(a, "own") {
+			t.Error("expected the writer to keep its own copy")
+		}
+	})
+}
+
+func TestInvalidationBusRetries(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr(), MaxRetries: -1})
+	defer rdb.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	mr.Close()
+
+	bus := NewInvalidationBus(rdb, testChannel)
+	received := make(chan []string, 1)
+	bus.onInvalidate(func(keys []string) { received <- keys })
+
+	failures := make(chan error, 100)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		bus.Run(ctx, func(err error) {
+			select {
+			case failures <- err:
+			default:
+			}
+		})
+	}()
+
+	select {
+	case err := <-failures:
+		if err == nil {
+			t.Error("expected the failed subscription to be reported")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the unreachable server to be reported")
+	}
+
+	if err := mr.Restart(); err != nil {
+		t.Fatal(err)
+	}
+	waitSubscribed(t, mr, 1)
+
+	if err := NewInvalidationBus(rdb, testChannel).Publish(ctx, "k"); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case keys := <-received:
+		if len(keys) != 1 || keys[0] != "k" {
+			t.Errorf("unexpected keys %v", keys)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected invalidations after the reconnect")
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("expected Run to stop with its context")
+	}
+}
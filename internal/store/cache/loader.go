@@ -0,0 +1,145 @@
+package cache
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"math/rand/v2"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// NegativeExpTime is how long a lookup of a missing entity is remembered,
+// so a flood of requests for an unknown id does not reach the database.
+const NegativeExpTime = 30 * time.Second
+
+// loadTimeout bounds loads shared by several callers or running in the
+// background, which must not depend on the context of a single request.
+const loadTimeout = 5 * time.Second
+
+// jitter spreads ttl by up to ±10% so entries written together, e.g. by a
+// background job, do not all expire in the same instant.
+func jitter(ttl time.Duration) time.Duration {
+	spread := int64(ttl) / 10
+	if spread <= 0 {
+		return ttl
+	}
+	return ttl + time.Duration(rand.Int64N(2*spread+1)-spread)
+}
+
+// policy configures how long a read-through cache keeps an entry.
+type policy struct {
+	name string
+	// fresh is how long an entry is served as is.
+	fresh time.Duration
+	// stale is how long an entry is still served after it stopped being
+	// fresh, while a single background load refreshes it.
+	stale time.Duration
+}
+
+// envelope is what read-through caches store. A null Data records that the
+// entity does not exist.
+type envelope struct {
+	Data       json.RawMessage `json:"d"`
+	FreshUntil time.Time       `json:"f"`
+}
+
+// loader reads entities through a backend. Concurrent loads of the same key
+// on one instance are coalesced into a single database query.
+type loader struct {
+	kv    backend
+	group singleflight.Group
+	now   func() time.Time
+}
+
+func newLoader(kv backend) *loader {
+	return &loader{kv: kv, now: time.Now}
+}
+
+func (l *loader) get(ctx context.Context, key string) (*envelope, bool, error) {
+	data, ok, err := l.kv.Get(ctx, key)
+	if err != nil || !ok {
+		return nil, false, err
+	}
+
+	// entries written in another format are treated as missing
+	var env envelope
+	if err := json.Unmarshal(data, &env); err != nil || len(env.Data) == 0 {
+		return nil, false, nil
+	}
+	return &env, true, nil
+}
+
+// readThrough returns the cached value of key or loads it. A load failing
+// with sql.ErrNoRows is cached as a negative entry and reported as
+// sql.ErrNoRows again until it expires. Cache errors only cost a load.
+func readThrough[T any](ctx context.Context, l *loader, key string, p policy, load func(context.Context) (*T, error)) (*T, error) {
+	env, ok, _ := l.get(ctx, key)
+	if ok {
+		recordHit(p.name)
+		if l.now().After(env.FreshUntil) {
+			l.group.DoChan(key, func() (any, error) {
+				ctx, cancel := context.WithTimeout(context.Background(), loadTimeout)
+				defer cancel()
+				return fill(ctx, l, key, p, load)
+			})
+		}
+		return decode[T](env)
+	}
+
+	recordMiss(p.name)
+	v, err, _ := l.group.Do(key, func() (any, error) {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), loadTimeout)
+		defer cancel()
+		return fill(ctx, l, key, p, load)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(*T), nil
+}
+
+// fill loads key and stores the result, including a negative one.
+func fill[T any](ctx context.Context, l *loader, key string, p policy, load func(context.Context) (*T, error)) (*T, error) {
+	value, err := load(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		l.put(ctx, key, nil, NegativeExpTime, 0)
+		return nil, err
+	} else if err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	l.put(ctx, key, data, jitter(p.fresh), p.stale)
+	return value, nil
+}
+
+func (l *loader) put(ctx context.Context, key string, data json.RawMessage, fresh, stale time.Duration) error {
+	if data == nil {
+		data = json.RawMessage("null")
+	}
+
+	raw, err := json.Marshal(envelope{Data: data, FreshUntil: l.now().Add(fresh)})
+	if err != nil {
+		return err
+	}
+	return l.kv.Set(ctx, key, raw, fresh+stale)
+}
+
+func decode[T any](env *envelope) (*T, error) {
+	if string(env.Data) == "null" {
+		return nil, sql.ErrNoRows
+	}
+
+	var value T
+	if err := json.Unmarshal(env.Data, &value); err != nil {
+		return nil, err
+	}
+	return &value, nil
+}
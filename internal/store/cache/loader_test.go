@@ -0,0 +1,112 @@
+package cache
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type item struct {
+	Name string `json:"name"`
+}
+
+func TestReadThrough(t *testing.T) {
+	ctx := context.Background()
+	p := policy{name: "test", fresh: time.Minute, stale: time.Minute}
+
+	t.Run("concurrent misses share one load", func(t *testing.T) {
+		l := newLoader(newMemoryBackend(MemoryConfig{}))
+
+		var loads atomic.Int32
+		release := make(chan struct{})
+		load := func(context.Context) (*item, error) {
+			loads.Add(1)
+			<-release
+			return &item{Name: "a"}, nil
+		}
+
+		var wg sync.WaitGroup
+		for range 10 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if v, err := readThrough(ctx, l, "k", p, load); err != nil || v.Name != "a" {
+					t.Errorf("unexpected result %v, %v", v, err)
+				}
+			}()
+		}
+
+		time.Sleep(50 * time.Millisecond)
+		close(release)
+		wg.Wait()
+
+		if n := loads.Load(); n != 1 {
+			t.Errorf("expected 1 load; got %d", n)
+		}
+	})
+
+	t.Run("missing entities are cached", func(t *testing.T) {
+		l := newLoader(newMemoryBackend(MemoryConfig{}))
+
+		var loads int
+		load := func(context.Context) (*item, error) {
+			loads++
+			return nil, sql.ErrNoRows
+		}
+
+		for range 3 {
+			if _, err := readThrough(ctx, l, "k", p, load); !errors.Is(err, sql.ErrNoRows) {
+				t.Fatalf("expected sql.ErrNoRows; got %v", err)
+			}
+		}
+
+		if loads != 1 {
+			t.Errorf("expected 1 load; got %d", loads)
+		}
+	})
+
+	t.Run("stale entries are served while refreshing", func(t *testing.T) {
+		l := newLoader(newMemoryBackend(MemoryConfig{}))
+		now := time.Now()
+		l.now = func() time.Time { return now }
+
+		refreshed := make(chan struct{})
+		name := "old"
+		load := func(context.Context) (*item, error) {
+			if name == "new" {
+				defer close(refreshed)
+			}
+			return &item{Name: name}, nil
+		}
+
+		readThrough(ctx, l, "k", p, load)
+
+		now = now.Add(2 * p.fresh)
+		name = "new"
+
+		v, err := readThrough(ctx, l, "k", p, load)
+		if err != nil || v.Name != "old" {
+			t.Fatalf("expected stale value; got %v, %v", v, err)
+		}
+
+		<-refreshed
+		time.Sleep(10 * time.Millisecond)
+
+		v, _ = readThrough(ctx, l, "k", p, load)
+		if v.Name != "new" {
+			t.Errorf("expected refreshed value; got %q", v.Name)
+		}
+	})
+}
+
+func TestJitter(t *testing.T) {
+	for range 100 {
+		if d := jitter(time.Minute); d < 54*time.Second || d > 66*time.Second {
+			t.Fatalf("expected jitter within 10%%; got %v", d)
+		}
+	}
+}
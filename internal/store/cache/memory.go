@@ -0,0 +1,200 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// MemoryConfig bounds an in-process cache. Least recently used entries are
+// evicted once either limit is reached.
+type MemoryConfig struct {
+	MaxEntries int
+	MaxBytes   int64
+}
+
+type memoryEntry struct {
+	key       string
+	value     []byte
+	expiresAt time.Time
+}
+
+func (e *memoryEntry) size() int64 {
+	return int64(len(e.key) + len(e.value))
+}
+
+// memoryBackend is an LRU cache with per entry expiry. Expired entries are
+// dropped when they are read or reach the back of the list.
+type memoryBackend struct {
+	cfg MemoryConfig
+	now func() time.Time
+
+	mu    sync.Mutex
+	ll    *list.List
+	items map[string]*list.Element
+	bytes int64
+}
+
+func newMemoryBackend(cfg MemoryConfig) *memoryBackend {
+	return &memoryBackend{
+		cfg:   cfg,
+		now:   time.Now,
+		ll:    list.New(),
+		items: make(map[string]*list.Element),
+	}
+}
+
+func (b *memoryBackend) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	value, ok := b.get(key)
+	return value, ok, nil
+}
+
+func (b *memoryBackend) get(key string) ([]byte, bool) {
+	el, ok := b.items[key]
+	if !ok {
+		return nil, false
+	}
+
+	entry := el.Value.(*memoryEntry)
+	if !b.now().Before(entry.expiresAt) {
+		b.remove(el)
+		return nil, false
+	}
+
+	b.ll.MoveToFront(el)
+	return entry.value, true
+}
+
+func (b *memoryBackend) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.set(key, value, ttl)
+	return nil
+}
+
+func (b *memoryBackend) set(key string, value []byte, ttl time.Duration) {
+	entry := &memoryEntry{key: key, value: value, expiresAt: b.now().Add(ttl)}
+
+	// an entry larger than the whole cache would only evict everything else
+	if b.cfg.MaxBytes > 0 && entry.size() > b.cfg.MaxBytes {
+		if el, ok := b.items[key]; ok {
+			b.remove(el)
+		}
+		return
+	}
+
+	if el, ok := b.items[key]; ok {
+		b.bytes += entry.size() - el.Value.(*memoryEntry).size()
+		el.Value = entry
+		b.ll.MoveToFront(el)
+	} else {
+		b.items[key] = b.ll.PushFront(entry)
+		b.bytes += entry.size()
+	}
+
+	b.evict()
+}
+
+func (b *memoryBackend) evict() {
+	for b.ll.Len() > 0 &&
+		((b.cfg.MaxEntries > 0 && b.ll.Len() > b.cfg.MaxEntries) ||
+			(b.cfg.MaxBytes > 0 && b.bytes > b.cfg.MaxBytes)) {
+		b.remove(b.ll.Back())
+	}
+}
+
+func (b *memoryBackend) remove(el *list.Element) {
+	entry := b.ll.Remove(el).(*memoryEntry)
+	delete(b.items, entry.key)
+	b.bytes -= entry.size()
+}
+
+func (b *memoryBackend) Delete(ctx context.Context, keys ...string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, key := range keys {
+		if el, ok := b.items[key]; ok {
+			b.remove(el)
+		}
+	}
+	return nil
+}
+
+func (b *memoryBackend) Incr(ctx context.Context, ttl time.Duration, keys ...string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, key := range keys {
+		n := b.now().UnixNano()
+		if value, ok := b.get(key); ok {
+			n, _ = strconv.ParseInt(string(value), 10, 64)
+		}
+		b.set(key, []byte(strconv.FormatInt(n+1, 10)), ttl)
+	}
+	return nil
+}
+
+// Len returns the number of entries, including expired ones not yet dropped.
+func (b *memoryBackend) Len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.ll.Len()
+}
+
+// tieredBackend keeps recently read entries in process memory in front of
+// Redis. Writes and deletions are broadcast on the bus so other instances
+// drop their copies, localTTL bounds how stale a copy gets if a broadcast
+// is missed.
+type tieredBackend struct {
+	local    *memoryBackend
+	remote   backend
+	bus      *InvalidationBus
+	localTTL time.Duration
+}
+
+func (b *tieredBackend) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	if value, ok, _ := b.local.Get(ctx, key); ok {
+		return value, true, nil
+	}
+
+	value, ok, err := b.remote.Get(ctx, key)
+	if err != nil || !ok {
+		return nil, false, err
+	}
+
+	b.local.Set(ctx, key, value, b.localTTL)
+	return value, true, nil
+}
+
+// Set replaces the value everywhere, so other instances drop the copies
+// they hold of the old one.
+func (b *tieredBackend) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	if err := b.remote.Set(ctx, key, value, ttl); err != nil {
+		return err
+	}
+	b.local.Set(ctx, key, value, min(ttl, b.localTTL))
+	return b.bus.Publish(ctx, key)
+}
+
+func (b *tieredBackend) Delete(ctx context.Context, keys ...string) error {
+	b.local.Delete(ctx, keys...)
+	if err := b.remote.Delete(ctx, keys...); err != nil {
+		return err
+	}
+	return b.bus.Publish(ctx, keys...)
+}
+
+func (b *tieredBackend) Incr(ctx context.Context, ttl time.Duration, keys ...string) error {
+	b.local.Delete(ctx, keys...)
+	if err := b.remote.Incr(ctx, ttl, keys...); err != nil {
+		return err
+	}
+	return b.bus.Publish(ctx, keys...)
+}
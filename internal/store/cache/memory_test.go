@@ -0,0 +1,85 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestMemoryBackend(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("expired entries are not returned", func(t *testing.T) {
+		b := newMemoryBackend(MemoryConfig{})
+		now := time.Now()
+		b.now = func() time.Time { return now }
+
+		b.Set(ctx, "k", []byte("v"), time.Minute)
+		if _, ok, _ := b.Get(ctx, "k"); !ok {
+			t.Fatal("expected entry before expiry")
+		}
+
+		now = now.Add(time.Minute)
+		if _, ok, _ := b.Get(ctx, "k"); ok {
+			t.Fatal("expected entry to expire")
+		}
+		if b.Len() != 0 {
+			t.Errorf("expected expired entry to be dropped; got %d entries", b.Len())
+		}
+	})
+
+	t.Run("least recently used entry is evicted", func(t *testing.T) {
+		b := newMemoryBackend(MemoryConfig{MaxEntries: 2})
+
+		b.Set(ctx, "a", []byte("1"), time.Minute)
+		b.Set(ctx, "b", []byte("2"), time.Minute)
+		b.Get(ctx, "a")
+		b.Set(ctx, "c", []byte("3"), time.Minute)
+
+		for key, want := range map[string]bool{"a": true, "b": false, "c": true} {
+			if _, ok, _ := b.Get(ctx, key); ok != want {
+				t.Errorf("%s: expected present=%v", key, want)
+			}
+		}
+	})
+
+	t.Run("size limit is enforced", func(t *testing.T) {
+		b := newMemoryBackend(MemoryConfig{MaxBytes: 100})
+
+		for i := range 10 {
+			b.Set(ctx, fmt.Sprintf("key-%d", i), make([]byte, 20), time.Minute)
+		}
+		if b.bytes > 100 {
+			t.Errorf("expected at most 100 bytes; got %d", b.bytes)
+		}
+
+		b.Set(ctx, "huge", make([]byte, 200), time.Minute)
+		if _, ok, _ := b.Get(ctx, "huge"); ok {
+			t.Error("expected entry larger than the cache to be skipped")
+		}
+	})
+
+	t.Run("counters increment", func(t *testing.T) {
+		b := newMemoryBackend(MemoryConfig{})
+		now := time.Unix(0, 1000)
+		b.now = func() time.Time { return now }
+
+		b.Incr(ctx, time.Minute, "gen")
+		b.Incr(ctx, time.Minute, "gen")
+
+		if v, _, _ := b.Get(ctx, "gen"); string(v) != "1002" {
+			t.Errorf("expected counter 1002; got %q", v)
+		}
+
+		// a counter that is gone starts over from the clock, above any
+		// value it had before
+		b.Delete(ctx, "gen")
+		now = now.Add(time.Millisecond)
+		b.Incr(ctx, time.Minute, "gen")
+
+		if v, _, _ := b.Get(ctx, "gen"); string(v) != "1001001" {
+			t.Errorf("expected counter 1001001; got %q", v)
+		}
+	})
+}
@@ -0,0 +1,20 @@
+package cache
+
+import "expvar"
+
+// metrics counts hits and misses per cache. It is served with the other
+// expvars on /v1/debug/vars, e.g. "cache": {"posts.hits": 10, ...}.
+var metrics = expvar.NewMap("cache")
+
+func recordHit(name string) {
+	metrics.Add(name+".hits", 1)
+}
+
+func recordMiss(name string) {
+	metrics.Add(name+".misses", 1)
+}
+
+// recordInvalidation counts keys dropped on behalf of other instances.
+func recordInvalidation(keys int) {
+	metrics.Add("invalidations.received", int64(keys))
+}
@@ -3,6 +3,7 @@ package cache
 import (
 	"context"
 
+	"github.com/JaskiratAnand/go-social/internal/ranking"
 	"github.com/JaskiratAnand/go-social/internal/store"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/mock"
@@ -10,7 +11,11 @@ import (
 
 func NewMockCache() Storage {
 	return Storage{
-		Users: &MockUserCache{},
+		Users:       &MockUserCache{},
+		Suggestions: &MockSuggestionCache{},
+		Feeds:       &MockFeedCache{},
+		Posts:       &MockPostCache{},
+		Explore:     &MockExploreCache{},
 	}
 }
 
@@ -23,9 +28,113 @@ func (m *MockUserCache) Get(ctx context.Context, userID uuid.UUID) (*store.Users
 	return nil, args.Error(1)
 }
 
+// Load goes through Get and Set so tests can assert on both.
+func (m *MockUserCache) Load(ctx context.Context, userID uuid.UUID, load func(context.Context) (*store.Users, error)) (*store.Users, error) {
+	if user, _ := m.Get(ctx, userID); user != nil {
+		return user, nil
+	}
+
+	user, err := load(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return user, m.Set(ctx, user)
+}
+
 func (m *MockUserCache) Set(ctx context.Context, user *store.Users) error {
 	args := m.Called(user)
 	return args.Error(0)
 }
 
 func (m *MockUserCache) Delete(ctx context.Context, userID uuid.UUID) {}
+
+type MockSuggestionCache struct {
+	mock.Mock
+}
+
+func (m *MockSuggestionCache) Get(ctx context.Context, userID uuid.UUID) ([]store.GetFollowSuggestionsRow, error) {
+	args := m.Called(userID)
+	return nil, args.Error(1)
+}
+
+func (m *MockSuggestionCache) Set(ctx context.Context, userID uuid.UUID, suggestions []store.GetFollowSuggestionsRow) error {
+	args := m.Called(userID, suggestions)
+	return args.Error(0)
+}
+
+func (m *MockSuggestionCache) Delete(ctx context.Context, userID uuid.UUID) {}
+
+type MockFeedCache struct {
+	mock.Mock
+}
+
+func (m *MockFeedCache) Get(ctx context.Context, params store.GetUserFeedParams) ([]store.GetUserFeedRow, error) {
+	args := m.Called(params)
+	return nil, args.Error(1)
+}
+
+func (m *MockFeedCache) Set(ctx context.Context, params store.GetUserFeedParams, feed []store.GetUserFeedRow) error {
+	args := m.Called(params, feed)
+	return args.Error(0)
+}
+
+func (m *MockFeedCache) Invalidate(ctx context.Context, userIDs ...uuid.UUID) error {
+	args := m.Called(userIDs)
+	return args.Error(0)
+}
+
+type MockPostCache struct {
+	mock.Mock
+}
+
+func (m *MockPostCache) Get(ctx context.Context, postID uuid.UUID) (*store.GetPostWithCommentsByIdRow, error) {
+	args := m.Called(postID)
+	return nil, args.Error(1)
+}
+
+func (m *MockPostCache) Load(ctx context.Context, postID uuid.UUID, load func(context.Context) (*store.GetPostWithCommentsByIdRow, error)) (*store.GetPostWithCommentsByIdRow, error) {
+	if post, _ := m.Get(ctx, postID); post != nil {
+		return post, nil
+	}
+
+	post, err := load(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return post, m.Set(ctx, postID, post)
+}
+
+func (m *MockPostCache) Set(ctx context.Context, postID uuid.UUID, post *store.GetPostWithCommentsByIdRow) error {
+	args := m.Called(postID, post)
+	return args.Error(0)
+}
+
+func (m *MockPostCache) Delete(ctx context.Context, postID uuid.UUID) {}
+
+type MockExploreCache struct {
+	mock.Mock
+}
+
+func (m *MockExploreCache) GetPosts(ctx context.Context) ([]store.GetExplorePostsRow, error) {
+	args := m.Called()
+	return nil, args.Error(1)
+}
+
+func (m *MockExploreCache) SetPosts(ctx context.Context, posts []store.GetExplorePostsRow) error {
+	args := m.Called(posts)
+	return args.Error(0)
+}
+
+func (m *MockExploreCache) GetTrendingTags(ctx context.Context) ([]ranking.TrendingTag, error) {
+	args := m.Called()
+	return nil, args.Error(1)
+}
+
+func (m *MockExploreCache) SetTrendingTags(ctx context.Context, tags []ranking.TrendingTag) error {
+	args := m.Called(tags)
+	return args.Error(0)
+}
+
+func (m *MockExploreCache) Delete(ctx context.Context) {}
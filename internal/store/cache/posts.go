@@ -0,0 +1,64 @@
+package cache
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/JaskiratAnand/go-social/internal/store"
+	"github.com/google/uuid"
+)
+
+type PostStore struct {
+	loader *loader
+}
+
+const PostExpTime = 5 * time.Minute
+
+var postPolicy = policy{name: "posts", fresh: PostExpTime, stale: time.Minute}
+
+func postCacheKey(postID uuid.UUID) string {
+	return fmt.Sprintf("post-%v", postID)
+}
+
+func (s *PostStore) Get(ctx context.Context, postID uuid.UUID) (*store.GetPostWithCommentsByIdRow, error) {
+	env, ok, err := s.loader.get(ctx, postCacheKey(postID))
+	if err != nil {
+		return nil, err
+	} else if !ok {
+		recordMiss("posts")
+		return nil, nil
+	}
+
+	post, err := decode[store.GetPostWithCommentsByIdRow](env)
+	if errors.Is(err, sql.ErrNoRows) {
+		recordMiss("posts")
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	recordHit("posts")
+	return post, nil
+}
+
+// Load returns the cached post or loads it, see readThrough.
+func (s *PostStore) Load(ctx context.Context, postID uuid.UUID, load func(context.Context) (*store.GetPostWithCommentsByIdRow, error)) (*store.GetPostWithCommentsByIdRow, error) {
+	return readThrough(ctx, s.loader, postCacheKey(postID), postPolicy, load)
+}
+
+func (s *PostStore) Set(ctx context.Context, postID uuid.UUID, post *store.GetPostWithCommentsByIdRow) error {
+	json, err := json.Marshal(post)
+	if err != nil {
+		return err
+	}
+
+	return s.loader.put(ctx, postCacheKey(postID), json, jitter(postPolicy.fresh), postPolicy.stale)
+}
+
+func (s *PostStore) Delete(ctx context.Context, postID uuid.UUID) {
+	s.loader.kv.Delete(ctx, postCacheKey(postID))
+}
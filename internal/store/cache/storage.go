@@ -2,7 +2,9 @@ package cache
 
 import (
 	"context"
+	"time"
 
+	"github.com/JaskiratAnand/go-social/internal/ranking"
 	"github.com/JaskiratAnand/go-social/internal/store"
 	"github.com/google/uuid"
 	"github.com/redis/go-redis/v9"
@@ -11,13 +13,74 @@ import (
 type Storage struct {
 	Users interface {
 		Get(context.Context, uuid.UUID) (*store.Users, error)
+		Load(context.Context, uuid.UUID, func(context.Context) (*store.Users, error)) (*store.Users, error)
 		Set(context.Context, *store.Users) error
 		Delete(context.Context, uuid.UUID)
 	}
+	Suggestions interface {
+		Get(context.Context, uuid.UUID) ([]store.GetFollowSuggestionsRow, error)
+		Set(context.Context, uuid.UUID, []store.GetFollowSuggestionsRow) error
+		Delete(context.Context, uuid.UUID)
+	}
+	Feeds interface {
+		Get(context.Context, store.GetUserFeedParams) ([]store.GetUserFeedRow, error)
+		Set(context.Context, store.GetUserFeedParams, []store.GetUserFeedRow) error
+		Invalidate(context.Context, ...uuid.UUID) error
+	}
+	Posts interface {
+		Get(context.Context, uuid.UUID) (*store.GetPostWithCommentsByIdRow, error)
+		Load(context.Context, uuid.UUID, func(context.Context) (*store.GetPostWithCommentsByIdRow, error)) (*store.GetPostWithCommentsByIdRow, error)
+		Set(context.Context, uuid.UUID, *store.GetPostWithCommentsByIdRow) error
+		Delete(context.Context, uuid.UUID)
+	}
+	Explore interface {
+		GetPosts(context.Context) ([]store.GetExplorePostsRow, error)
+		SetPosts(context.Context, []store.GetExplorePostsRow) error
+		GetTrendingTags(context.Context) ([]ranking.TrendingTag, error)
+		SetTrendingTags(context.Context, []ranking.TrendingTag) error
+		Delete(context.Context)
+	}
 }
 
 func NewRedisStorage(rdb *redis.Client) Storage {
+	return newStorage(&redisBackend{rdb: rdb})
+}
+
+// NewMemoryStorage keeps every cache in process memory. It is used when
+// Redis is disabled, so each instance has caches of its own.
+func NewMemoryStorage(cfg MemoryConfig) Storage {
+	return newStorage(newMemoryBackend(cfg))
+}
+
+// NewTieredStorage serves hot entries from process memory and falls back
+// to Redis, which stays the shared source of truth between instances. The
+// bus must be running for deletions on other instances to reach this one.
+func NewTieredStorage(rdb *redis.Client, cfg MemoryConfig, localTTL time.Duration, bus *InvalidationBus) Storage {
+	return newStorage(newTieredBackend(rdb, cfg, localTTL, bus))
+}
+
+func newTieredBackend(rdb *redis.Client, cfg MemoryConfig, localTTL time.Duration, bus *InvalidationBus) *tieredBackend {
+	local := newMemoryBackend(cfg)
+	bus.onInvalidate(func(keys []string) {
+		local.Delete(context.Background(), keys...)
+	})
+
+	return &tieredBackend{
+		local:    local,
+		remote:   &redisBackend{rdb: rdb},
+		bus:      bus,
+		localTTL: localTTL,
+	}
+}
+
+func newStorage(kv backend) Storage {
+	loader := newLoader(kv)
+
 	return Storage{
-		Users: &UserStore{rdb: rdb},
+		Users:       &UserStore{loader: loader},
+		Suggestions: &SuggestionStore{kv: kv},
+		Feeds:       &FeedStore{kv: kv},
+		Posts:       &PostStore{loader: loader},
+		Explore:     &ExploreStore{kv: kv},
 	}
 }
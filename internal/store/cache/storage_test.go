@@ -0,0 +1,172 @@
+package cache
+
+import (
+	"context"
+	"database/sql"
+	"expvar"
+	"testing"
+	"time"
+
+	"github.com/JaskiratAnand/go-social/internal/store"
+	"github.com/google/uuid"
+)
+
+// metric reads a counter of the cache expvar.
+func metric(name string) int64 {
+	if v, ok := metrics.Get(name).(*expvar.Int); ok {
+		return v.Value()
+	}
+	return 0
+}
+
+// assertMetrics checks how much the hit and miss counters of cache moved
+// while fn ran.
+func assertMetrics(t *testing.T, cache string, hits, misses int64, fn func()) {
+	t.Helper()
+
+	h, m := metric(cache+".hits"), metric(cache+".misses")
+	fn()
+
+	if got := metric(cache+".hits") - h; got != hits {
+		t.Errorf("expected %d %s hits; got %d", hits, cache, got)
+	}
+	if got := metric(cache+".misses") - m; got != misses {
+		t.Errorf("expected %d %s misses; got %d", misses, cache, got)
+	}
+}
+
+func TestFeedStore(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStorage(MemoryConfig{}).Feeds
+
+	alice, bob := uuid.New(), uuid.New()
+	page := store.GetUserFeedParams{UserID: alice, Limit: 20}
+	feed := []store.GetUserFeedRow{{ID: uuid.New(), Title: "hi"}}
+
+	assertMetrics(t, "feeds", 0, 1, func() {
+		if got, err := s.Get(ctx, page); err != nil || got != nil {
+			t.Fatalf("expected a miss; got %v, %v", got, err)
+		}
+	})
+
+	if err := s.Set(ctx, page, feed); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Set(ctx, store.GetUserFeedParams{UserID: bob, Limit: 20}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	assertMetrics(t, "feeds", 1, 0, func() {
+		got, err := s.Get(ctx, page)
+		if err != nil || len(got) != 1 || got[0].Title != "hi" {
+			t.Fatalf("expected the cached page; got %v, %v", got, err)
+		}
+	})
+
+	t.Run("pages are keyed by every param", func(t *testing.T) {
+		other := page
+		other.Offset = sql.NullInt64{Int64: 20, Valid: true}
+		if got, _ := s.Get(ctx, other); got != nil {
+			t.Error("expected another page of the same feed to miss")
+		}
+	})
+
+	t.Run("empty pages are cached", func(t *testing.T) {
+		got, _ := s.Get(ctx, store.GetUserFeedParams{UserID: bob, Limit: 20})
+		if got == nil {
+			t.Error("expected an empty page to be a hit")
+		}
+	})
+
+	t.Run("invalidation bumps the generation of each user", func(t *testing.T) {
+		if err := s.Invalidate(ctx, alice); err != nil {
+			t.Fatal(err)
+		}
+
+		assertMetrics(t, "feeds", 1, 1, func() {
+			if got, _ := s.Get(ctx, page); got != nil {
+				t.Error("expected the invalidated feed to miss")
+			}
+			if got, _ := s.Get(ctx, store.GetUserFeedParams{UserID: bob, Limit: 20}); got == nil {
+				t.Error("expected other feeds to stay cached")
+			}
+		})
+
+		// pages written after the bump are served again
+		s.Set(ctx, page, feed)
+		if got, _ := s.Get(ctx, page); got == nil {
+			t.Error("expected pages of the new generation to hit")
+		}
+
+		if err := s.Invalidate(ctx, alice, bob); err != nil {
+			t.Fatal(err)
+		}
+		for _, id := range []uuid.UUID{alice, bob} {
+			if got, _ := s.Get(ctx, store.GetUserFeedParams{UserID: id, Limit: 20}); got != nil {
+				t.Errorf("%v: expected the feed to be invalidated", id)
+			}
+		}
+	})
+
+	t.Run("evicted generations do not bring back old pages", func(t *testing.T) {
+		b := newMemoryBackend(MemoryConfig{})
+		now := time.Now()
+		b.now = func() time.Time { return now }
+		s := &FeedStore{kv: b}
+
+		s.Set(ctx, page, feed)
+		s.Invalidate(ctx, alice)
+
+		// the counter is evicted while the old page is still cached
+		b.Delete(ctx, feedGenerationKey(alice))
+		now = now.Add(time.Millisecond)
+
+		if got, _ := s.Get(ctx, page); got != nil {
+			t.Error("expected the page of an older generation to miss")
+		}
+	})
+}
+
+func TestPostStore(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStorage(MemoryConfig{}).Posts
+
+	postID := uuid.New()
+	post := &store.GetPostWithCommentsByIdRow{Title: "hi"}
+
+	assertMetrics(t, "posts", 0, 1, func() {
+		if got, err := s.Get(ctx, postID); err != nil || got != nil {
+			t.Fatalf("expected a miss; got %v, %v", got, err)
+		}
+	})
+
+	if err := s.Set(ctx, postID, post); err != nil {
+		t.Fatal(err)
+	}
+
+	assertMetrics(t, "posts", 1, 0, func() {
+		if got, err := s.Get(ctx, postID); err != nil || got == nil || got.Title != "hi" {
+			t.Fatalf("expected the cached post; got %v, %v", got, err)
+		}
+	})
+
+	t.Run("deleted posts are loaded again", func(t *testing.T) {
+		s.Delete(ctx, postID)
+
+		loads := 0
+		load := func(context.Context) (*store.GetPostWithCommentsByIdRow, error) {
+			loads++
+			return &store.GetPostWithCommentsByIdRow{Title: "edited"}, nil
+		}
+
+		for range 2 {
+			got, err := s.Load(ctx, postID, load)
+			if err != nil || got.Title != "edited" {
+				t.Fatalf("expected the reloaded post; got %v, %v", got, err)
+			}
+		}
+		if loads != 1 {
+			t.Errorf("expected 1 load; got %d", loads)
+		}
+	})
+}
@@ -0,0 +1,62 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/JaskiratAnand/go-social/internal/store"
+	"github.com/google/uuid"
+)
+
+type SuggestionStore struct {
+	kv backend
+}
+
+// SuggestionExpTime outlives the refresh interval of the suggestions job so
+// cached results do not expire between two runs.
+const SuggestionExpTime = 2 * time.Hour
+
+func (s *SuggestionStore) Get(ctx context.Context, userID uuid.UUID) ([]store.GetFollowSuggestionsRow, error) {
+	cacheKey := fmt.Sprintf("suggestions-%v", userID)
+
+	data, ok, err := s.kv.Get(ctx, cacheKey)
+	if err != nil {
+		return nil, err
+	} else if !ok {
+		recordMiss("suggestions")
+		return nil, nil
+	}
+
+	suggestions := []store.GetFollowSuggestionsRow{}
+	if len(data) > 0 {
+		err := json.Unmarshal(data, &suggestions)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	recordHit("suggestions")
+	return suggestions, nil
+}
+
+func (s *SuggestionStore) Set(ctx context.Context, userID uuid.UUID, suggestions []store.GetFollowSuggestionsRow) error {
+	cacheKey := fmt.Sprintf("suggestions-%v", userID)
+
+	if suggestions == nil {
+		suggestions = []store.GetFollowSuggestionsRow{}
+	}
+
+	json, err := json.Marshal(suggestions)
+	if err != nil {
+		return err
+	}
+
+	return s.kv.Set(ctx, cacheKey, json, jitter(SuggestionExpTime))
+}
+
+func (s *SuggestionStore) Delete(ctx context.Context, userID uuid.UUID) {
+	cacheKey := fmt.Sprintf("suggestions-%v", userID)
+	s.kv.Delete(ctx, cacheKey)
+}
@@ -2,54 +2,64 @@ package cache
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/JaskiratAnand/go-social/internal/store"
 	"github.com/google/uuid"
-	"github.com/redis/go-redis/v9"
 )
 
 type UserStore struct {
-	rdb *redis.Client
+	loader *loader
 }
 
 const UserExpTime = 10 * time.Minute
 
+var userPolicy = policy{name: "users", fresh: UserExpTime, stale: time.Minute}
+
+func userCacheKey(userID uuid.UUID) string {
+	return fmt.Sprintf("user-%v", userID)
+}
+
 func (s *UserStore) Get(ctx context.Context, userID uuid.UUID) (*store.Users, error) {
-	cacheKey := fmt.Sprintf("user-%v", userID)
+	env, ok, err := s.loader.get(ctx, userCacheKey(userID))
+	if err != nil {
+		return nil, err
+	} else if !ok {
+		recordMiss("users")
+		return nil, nil
+	}
 
-	data, err := s.rdb.Get(ctx, cacheKey).Result()
-	if err == redis.Nil {
+	user, err := decode[store.Users](env)
+	if errors.Is(err, sql.ErrNoRows) {
+		// only Load makes use of negative entries
+		recordMiss("users")
 		return nil, nil
 	} else if err != nil {
 		return nil, err
 	}
 
-	var user store.Users
-	if data != "" {
-		err := json.Unmarshal([]byte(data), &user)
-		if err != nil {
-			return nil, err
-		}
-	}
+	recordHit("users")
+	return user, nil
+}
 
-	return &user, nil
+// Load returns the cached user or loads it, see readThrough.
+func (s *UserStore) Load(ctx context.Context, userID uuid.UUID, load func(context.Context) (*store.Users, error)) (*store.Users, error) {
+	return readThrough(ctx, s.loader, userCacheKey(userID), userPolicy, load)
 }
 
 func (s *UserStore) Set(ctx context.Context, user *store.Users) error {
-	cacheKey := fmt.Sprintf("user-%v", user.ID)
-
 	json, err := json.Marshal(user)
 	if err != nil {
 		return err
 	}
 
-	return s.rdb.SetEx(ctx, cacheKey, json, UserExpTime).Err()
+	return s.loader.put(ctx, userCacheKey(user.ID), json, jitter(userPolicy.fresh), userPolicy.stale)
 }
 
 func (s *UserStore) Delete(ctx context.Context, userID uuid.UUID) {
-	cacheKey := fmt.Sprintf("user-%d", userID)
-	s.rdb.Del(ctx, cacheKey)
+	s.loader.kv.Delete(ctx, userCacheKey(userID))
 }
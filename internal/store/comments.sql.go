@@ -12,6 +12,29 @@ import (
 	"github.com/google/uuid"
 )
 
+const countUserCommentsSince = `-- name: CountUserCommentsSince :one
+SELECT COUNT(*) AS count, COALESCE(MIN(created_at), NOW())::TIMESTAMPTZ AS oldest 
+FROM comments 
+WHERE user_id = $1 AND created_at > $2
+`
+
+type CountUserCommentsSinceParams struct {
+	UserID    uuid.UUID `json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type CountUserCommentsSinceRow struct {
+	Count  int64     `json:"count"`
+	Oldest time.Time `json:"oldest"`
+}
+
+func (q *Queries) CountUserCommentsSince(ctx context.Context, arg CountUserCommentsSinceParams) (CountUserCommentsSinceRow, error) {
+	row := q.db.QueryRowContext(ctx, countUserCommentsSince, arg.UserID, arg.CreatedAt)
+	var i CountUserCommentsSinceRow
+	err := row.Scan(&i.Count, &i.Oldest)
+	return i, err
+}
+
 const createComment = `-- name: CreateComment :one
 INSERT 
 INTO comments (post_id, user_id, content) 
@@ -37,11 +60,31 @@ func (q *Queries) CreateComment(ctx context.Context, arg CreateCommentParams) (C
 	return i, err
 }
 
+const getCommentById = `-- name: GetCommentById :one
+SELECT id, post_id, user_id, content, created_at, hidden_at 
+FROM comments 
+WHERE id = $1 LIMIT 1
+`
+
+func (q *Queries) GetCommentById(ctx context.Context, id uuid.UUID) (Comments, error) {
+	row := q.db.QueryRowContext(ctx, getCommentById, id)
+	var i Comments
+	err := row.Scan(
+		&i.ID,
+		&i.PostID,
+		&i.UserID,
+		&i.Content,
+		&i.CreatedAt,
+		&i.HiddenAt,
+	)
+	return i, err
+}
+
 const getCommentsByPostId = `-- name: GetCommentsByPostId :many
 SELECT c.id, c.content, c.created_at, c.user_id, u.username 
 FROM comments c
 JOIN users u ON u.id = c.user_id
-WHERE c.post_id = $1
+WHERE c.post_id = $1 AND c.hidden_at IS NULL
 ORDER BY c.created_at DESC
 `
 
@@ -81,3 +124,48 @@ func (q *Queries) GetCommentsByPostId(ctx context.Context, postID uuid.UUID) ([]
 	}
 	return items, nil
 }
+
+const hideComment = `-- name: HideComment :exec
+UPDATE comments
+SET hidden_at = NOW()
+WHERE id = $1 AND hidden_at IS NULL
+`
+
+func (q *Queries) HideComment(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, hideComment, id)
+	return err
+}
+
+const recentCommentWithContentExists = `-- name: RecentCommentWithContentExists :one
+SELECT EXISTS (
+    SELECT 1 
+    FROM comments 
+    WHERE user_id = $1 
+    AND content_hash(content) = content_hash($2::TEXT) 
+    AND created_at > $3
+)
+`
+
+type RecentCommentWithContentExistsParams struct {
+	UserID  uuid.UUID `json:"user_id"`
+	Content string    `json:"content"`
+	Since   time.Time `json:"since"`
+}
+
+func (q *Queries) RecentCommentWithContentExists(ctx context.Context, arg RecentCommentWithContentExistsParams) (bool, error) {
+	row := q.db.QueryRowContext(ctx, recentCommentWithContentExists, arg.UserID, arg.Content, arg.Since)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
+const unhideComment = `-- name: UnhideComment :exec
+UPDATE comments
+SET hidden_at = NULL
+WHERE id = $1
+`
+
+func (q *Queries) UnhideComment(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, unhideComment, id)
+	return err
+}
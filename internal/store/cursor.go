@@ -0,0 +1,77 @@
+package store
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// FeedCursor marks a position in a feed ordered by (created_at, id). Prev
+// cursors page towards newer posts, next cursors towards older ones.
+type FeedCursor struct {
+	CreatedAt time.Time `json:"t"`
+	ID        uuid.UUID `json:"i"`
+	Prev      bool      `json:"p,omitempty"`
+}
+
+// CursorCodec encodes cursors as opaque tokens signed with HMAC-SHA256 so
+// clients cannot forge positions.
+type CursorCodec struct {
+	secret []byte
+}
+
+func NewCursorCodec(secret string) *CursorCodec {
+	return &CursorCodec{secret: []byte(secret)}
+}
+
+func (c *CursorCodec) Encode(cursor FeedCursor) string {
+	payload, _ := json.Marshal(cursor)
+
+	enc := base64.RawURLEncoding
+	return enc.EncodeToString(payload) + "." + enc.EncodeToString(c.sign(payload))
+}
+
+func (c *CursorCodec) Decode(token string) (FeedCursor, error) {
+	var cursor FeedCursor
+
+	enc := base64.RawURLEncoding
+
+	payloadPart, sigPart, ok := strings.Cut(token, ".")
+	if !ok {
+		return cursor, ErrInvalidCursor
+	}
+
+	payload, err := enc.DecodeString(payloadPart)
+	if err != nil {
+		return cursor, ErrInvalidCursor
+	}
+
+	sig, err := enc.DecodeString(sigPart)
+	if err != nil {
+		return cursor, ErrInvalidCursor
+	}
+
+	if !hmac.Equal(sig, c.sign(payload)) {
+		return cursor, ErrInvalidCursor
+	}
+
+	if err := json.Unmarshal(payload, &cursor); err != nil {
+		return cursor, ErrInvalidCursor
+	}
+
+	return cursor, nil
+}
+
+func (c *CursorCodec) sign(payload []byte) []byte {
+	mac := hmac.New(sha256.New, c.secret)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
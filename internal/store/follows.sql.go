@@ -11,6 +11,40 @@ import (
 	"github.com/google/uuid"
 )
 
+const canViewUserPosts = `-- name: CanViewUserPosts :one
+SELECT (NOT u.is_private OR u.id = $1 OR EXISTS (
+    SELECT 1 FROM follows f 
+    WHERE f.user_id = $1 AND f.follow_id = u.id
+))::BOOLEAN AS can_view
+FROM users u
+WHERE u.id = $2
+`
+
+type CanViewUserPostsParams struct {
+	UserID uuid.UUID `json:"user_id"`
+	ID     uuid.UUID `json:"id"`
+}
+
+func (q *Queries) CanViewUserPosts(ctx context.Context, arg CanViewUserPostsParams) (bool, error) {
+	row := q.db.QueryRowContext(ctx, canViewUserPosts, arg.UserID, arg.ID)
+	var can_view bool
+	err := row.Scan(&can_view)
+	return can_view, err
+}
+
+const countFollowers = `-- name: CountFollowers :one
+SELECT COUNT(*) 
+FROM follows 
+WHERE follow_id = $1
+`
+
+func (q *Queries) CountFollowers(ctx context.Context, followID uuid.UUID) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countFollowers, followID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const followUser = `-- name: FollowUser :exec
 INSERT 
 INTO follows (user_id, follow_id) 
@@ -27,6 +61,54 @@ func (q *Queries) FollowUser(ctx context.Context, arg FollowUserParams) error {
 	return err
 }
 
+const getFollowerIds = `-- name: GetFollowerIds :many
+SELECT user_id 
+FROM follows 
+WHERE follow_id = $1
+`
+
+func (q *Queries) GetFollowerIds(ctx context.Context, followID uuid.UUID) ([]uuid.UUID, error) {
+	rows, err := q.db.QueryContext(ctx, getFollowerIds, followID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []uuid.UUID
+	for rows.Next() {
+		var user_id uuid.UUID
+		if err := rows.Scan(&user_id); err != nil {
+			return nil, err
+		}
+		items = append(items, user_id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const isFollowing = `-- name: IsFollowing :one
+SELECT EXISTS (
+    SELECT 1 FROM follows 
+    WHERE user_id = $1 AND follow_id = $2
+)
+`
+
+type IsFollowingParams struct {
+	UserID   uuid.UUID `json:"user_id"`
+	FollowID uuid.UUID `json:"follow_id"`
+}
+
+func (q *Queries) IsFollowing(ctx context.Context, arg IsFollowingParams) (bool, error) {
+	row := q.db.QueryRowContext(ctx, isFollowing, arg.UserID, arg.FollowID)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
 const unfollowUser = `-- name: UnfollowUser :exec
 DELETE FROM follows 
 WHERE user_id = $1 AND follow_id = $2
@@ -5,16 +5,66 @@
 package store
 
 import (
+	"database/sql"
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+type ActorKeys struct {
+	UserID        uuid.UUID `json:"user_id"`
+	PublicKeyPem  string    `json:"public_key_pem"`
+	PrivateKeyPem string    `json:"private_key_pem"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type AuditLog struct {
+	ID         int64           `json:"id"`
+	ActorID    uuid.NullUUID   `json:"actor_id"`
+	Action     string          `json:"action"`
+	TargetType string          `json:"target_type"`
+	TargetID   string          `json:"target_id"`
+	Before     json.RawMessage `json:"before"`
+	After      json.RawMessage `json:"after"`
+	RequestID  string          `json:"request_id"`
+	Ip         string          `json:"ip"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
+
 type Comments struct {
-	ID        uuid.UUID `json:"id"`
-	PostID    uuid.UUID `json:"post_id"`
+	ID        uuid.UUID    `json:"id"`
+	PostID    uuid.UUID    `json:"post_id"`
+	UserID    uuid.UUID    `json:"user_id"`
+	Content   string       `json:"content"`
+	CreatedAt time.Time    `json:"created_at"`
+	HiddenAt  sql.NullTime `json:"hidden_at"`
+}
+
+type DeliveryJobs struct {
+	ID            int64           `json:"id"`
+	SenderID      uuid.UUID       `json:"sender_id"`
+	Inbox         string          `json:"inbox"`
+	Activity      json.RawMessage `json:"activity"`
+	Attempts      int32           `json:"attempts"`
+	NextAttemptAt time.Time       `json:"next_attempt_at"`
+	LastError     sql.NullString  `json:"last_error"`
+	FailedAt      sql.NullTime    `json:"failed_at"`
+	CreatedAt     time.Time       `json:"created_at"`
+}
+
+type FilterRules struct {
+	ID        uuid.UUID     `json:"id"`
+	Kind      string        `json:"kind"`
+	Pattern   string        `json:"pattern"`
+	Action    string        `json:"action"`
+	CreatedBy uuid.NullUUID `json:"created_by"`
+	CreatedAt time.Time     `json:"created_at"`
+}
+
+type FollowRequests struct {
 	UserID    uuid.UUID `json:"user_id"`
-	Content   string    `json:"content"`
+	FollowID  uuid.UUID `json:"follow_id"`
 	CreatedAt time.Time `json:"created_at"`
 }
 
@@ -24,14 +74,75 @@ type Follows struct {
 	CreatedAt time.Time `json:"created_at"`
 }
 
-type Posts struct {
+type ListMembers struct {
+	ListID    uuid.UUID `json:"list_id"`
+	MemberID  uuid.UUID `json:"member_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type Lists struct {
 	ID        uuid.UUID `json:"id"`
-	Title     string    `json:"title"`
-	Content   string    `json:"content"`
-	Tags      []string  `json:"tags"`
 	UserID    uuid.UUID `json:"user_id"`
+	Name      string    `json:"name"`
+	IsPrivate bool      `json:"is_private"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type MutedWords struct {
+	UserID    uuid.UUID `json:"user_id"`
+	Word      string    `json:"word"`
 	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type Posts struct {
+	ID        uuid.UUID    `json:"id"`
+	Title     string       `json:"title"`
+	Content   string       `json:"content"`
+	Tags      []string     `json:"tags"`
+	UserID    uuid.UUID    `json:"user_id"`
+	CreatedAt time.Time    `json:"created_at"`
+	UpdatedAt time.Time    `json:"updated_at"`
+	HiddenAt  sql.NullTime `json:"hidden_at"`
+}
+
+type RemoteFollowers struct {
+	UserID      uuid.UUID      `json:"user_id"`
+	ActorID     string         `json:"actor_id"`
+	Inbox       string         `json:"inbox"`
+	SharedInbox sql.NullString `json:"shared_inbox"`
+	CreatedAt   time.Time      `json:"created_at"`
+}
+
+type RemoteLikes struct {
+	PostID    uuid.UUID `json:"post_id"`
+	ActorID   string    `json:"actor_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type RemoteReplies struct {
+	ID          string    `json:"id"`
+	PostID      uuid.UUID `json:"post_id"`
+	ActorID     string    `json:"actor_id"`
+	Content     string    `json:"content"`
+	PublishedAt time.Time `json:"published_at"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type Reports struct {
+	ID           uuid.UUID     `json:"id"`
+	ReporterID   uuid.NullUUID `json:"reporter_id"`
+	TargetType   string        `json:"target_type"`
+	TargetID     uuid.UUID     `json:"target_id"`
+	TargetUserID uuid.UUID     `json:"target_user_id"`
+	Reason       string        `json:"reason"`
+	Details      string        `json:"details"`
+	Status       string        `json:"status"`
+	ModeratorID  uuid.NullUUID `json:"moderator_id"`
+	Actions      []string      `json:"actions"`
+	Note         string        `json:"note"`
+	CreatedAt    time.Time     `json:"created_at"`
+	ClaimedAt    sql.NullTime  `json:"claimed_at"`
+	ClosedAt     sql.NullTime  `json:"closed_at"`
 }
 
 type Roles struct {
@@ -40,18 +151,38 @@ type Roles struct {
 	Description string `json:"description"`
 }
 
+type TagFollows struct {
+	UserID    uuid.UUID `json:"user_id"`
+	Tag       string    `json:"tag"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
 type UserInvitations struct {
 	Token   uuid.UUID `json:"token"`
 	UserID  uuid.UUID `json:"user_id"`
 	Expiary time.Time `json:"expiary"`
 }
 
+type UserWarnings struct {
+	ID          int64         `json:"id"`
+	UserID      uuid.UUID     `json:"user_id"`
+	ReportID    uuid.NullUUID `json:"report_id"`
+	ModeratorID uuid.NullUUID `json:"moderator_id"`
+	Reason      string        `json:"reason"`
+	CreatedAt   time.Time     `json:"created_at"`
+}
+
 type Users struct {
-	ID        uuid.UUID `json:"id"`
-	Email     string    `json:"email"`
-	Username  string    `json:"username"`
-	Password  []byte    `json:"password"`
-	CreatedAt time.Time `json:"created_at"`
-	Verified  bool      `json:"verified"`
-	RoleID    int32     `json:"role_id"`
+	ID               uuid.UUID    `json:"id"`
+	Email            string       `json:"email"`
+	Username         string       `json:"username"`
+	Password         []byte       `json:"password"`
+	CreatedAt        time.Time    `json:"created_at"`
+	Verified         bool         `json:"verified"`
+	RoleID           int32        `json:"role_id"`
+	IsPrivate        bool         `json:"is_private"`
+	SuspendedUntil   sql.NullTime `json:"suspended_until"`
+	SuspensionReason string       `json:"suspension_reason"`
+	BannedAt         sql.NullTime `json:"banned_at"`
+	BanReason        string       `json:"ban_reason"`
 }
@@ -1,6 +1,8 @@
 package store
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
 	"strings"
@@ -8,15 +10,20 @@ import (
 )
 
 type PaginatedFeedQuery struct {
-	Limit  int      `json:"limit" validate:"gte=1,lte=20"`
-	Offset int      `json:"offset" validate:"gte=0"`
-	Tags   []string `json:"tags" validate:"max=5"`
-	Search string   `json:"search" validate:"max=100"`
-	Since  string   `json:"since"`
-	Until  string   `json:"until"`
-	// Sort   string `json:"sort" validate:"oneof=asc desc"`
+	Limit   int       `json:"limit" validate:"gte=1,lte=20"`
+	Offset  int       `json:"offset" validate:"gte=0"`
+	Tags    []string  `json:"tags" validate:"max=5"`
+	Authors []string  `json:"authors" validate:"max=5,dive,max=100"`
+	Search  string    `json:"search" validate:"max=100"`
+	Since   time.Time `json:"since"`
+	Until   time.Time `json:"until"`
+	Cursor  string    `json:"cursor" validate:"max=512"`
+	Sort    string    `json:"sort" validate:"oneof=top latest"`
+	Order   string    `json:"order" validate:"oneof=asc desc"`
 }
 
+// Parse reads the feed query string. Malformed values are reported back
+// to the caller instead of being ignored.
 func (fq PaginatedFeedQuery) Parse(r *http.Request) (PaginatedFeedQuery, error) {
 	qs := r.URL.Query()
 
@@ -24,28 +31,43 @@ func (fq PaginatedFeedQuery) Parse(r *http.Request) (PaginatedFeedQuery, error)
 	if limit != "" {
 		l, err := strconv.Atoi(limit)
 		if err != nil {
-			return fq, nil
+			return fq, fmt.Errorf("invalid limit %q", limit)
 		}
 		fq.Limit = l
 	}
 
 	offset := qs.Get("offset")
-	if limit != "" {
+	if offset != "" {
 		o, err := strconv.Atoi(offset)
 		if err != nil {
-			return fq, nil
+			return fq, fmt.Errorf("invalid offset %q", offset)
 		}
 		fq.Offset = o
 	}
 
-	// sort := qs.Get("sort")
-	// if sort != "" {
-	// 	fq.Sort = sort
-	// }
+	sort := qs.Get("sort")
+	if sort != "" {
+		fq.Sort = sort
+	}
+
+	order := qs.Get("order")
+	if order != "" {
+		fq.Order = strings.ToLower(order)
+	}
+
+	cursor := qs.Get("cursor")
+	if cursor != "" {
+		fq.Cursor = cursor
+	}
 
 	tags := qs.Get("tags")
 	if tags != "" {
-		fq.Tags = strings.Split("tags", ",")
+		fq.Tags = strings.Split(tags, ",")
+	}
+
+	authors := qs.Get("authors")
+	if authors != "" {
+		fq.Authors = strings.Split(authors, ",")
 	}
 
 	search := qs.Get("search")
@@ -53,23 +75,109 @@ func (fq PaginatedFeedQuery) Parse(r *http.Request) (PaginatedFeedQuery, error)
 		fq.Search = search
 	}
 
+	loc := time.UTC
+	tz := qs.Get("tz")
+	if tz != "" {
+		l, err := time.LoadLocation(tz)
+		if err != nil {
+			return fq, fmt.Errorf("invalid tz %q", tz)
+		}
+		loc = l
+	}
+
+	now := time.Now()
+
 	since := qs.Get("since")
 	if since != "" {
-		fq.Since = parseTime(since)
+		t, err := parseTime(since, now, loc)
+		if err != nil {
+			return fq, fmt.Errorf("invalid since: %w", err)
+		}
+		fq.Since = t
 	}
 
 	until := qs.Get("until")
 	if until != "" {
-		fq.Until = parseTime(until)
+		t, err := parseTime(until, now, loc)
+		if err != nil {
+			return fq, fmt.Errorf("invalid until: %w", err)
+		}
+		fq.Until = t
+	}
+
+	if !fq.Since.IsZero() && !fq.Until.IsZero() && !fq.Since.Before(fq.Until) {
+		return fq, errors.New("since must be before until")
 	}
 
 	return fq, nil
 }
 
-func parseTime(s string) string {
-	t, err := time.Parse(time.DateTime, s)
+// Filtered reports whether the query narrows the feed beyond pagination.
+func (fq PaginatedFeedQuery) Filtered() bool {
+	return fq.Search != "" || len(fq.Tags) > 0 || len(fq.Authors) > 0 ||
+		!fq.Since.IsZero() || !fq.Until.IsZero()
+}
+
+// timeLayouts are tried in order for absolute times. Layouts without a
+// zone are read in the location given by the tz param.
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	time.DateTime,
+	"2006-01-02T15:04:05",
+	time.DateOnly,
+}
+
+// parseTime reads an absolute time or a duration before now such as
+// "90m", "24h", "7d" or "2w".
+func parseTime(s string, now time.Time, loc *time.Location) (time.Time, error) {
+	if d, ok, err := parseRelative(s); ok {
+		if err != nil {
+			return time.Time{}, err
+		}
+		return now.Add(-d), nil
+	}
+
+	// an unescaped "+" in a zone offset arrives as a space
+	if strings.Contains(s, "T") {
+		s = strings.Replace(s, " ", "+", 1)
+	}
+
+	for _, layout := range timeLayouts {
+		if t, err := time.ParseInLocation(layout, s, loc); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("%q is not an RFC 3339 time, a date or a relative duration", s)
+}
+
+// parseRelative reports whether s looks like a duration, and parses it.
+// Days and weeks are accepted on top of the time.ParseDuration units.
+func parseRelative(s string) (time.Duration, bool, error) {
+	if s == "" || (s[0] < '0' || s[0] > '9') || strings.ContainsAny(s, "-:T ") {
+		return 0, false, nil
+	}
+
+	unit := time.Duration(0)
+	switch s[len(s)-1] {
+	case 'd':
+		unit = 24 * time.Hour
+	case 'w':
+		unit = 7 * 24 * time.Hour
+	}
+
+	if unit == 0 {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return 0, true, fmt.Errorf("invalid duration %q", s)
+		}
+		return d, true, nil
+	}
+
+	n, err := strconv.Atoi(s[:len(s)-1])
 	if err != nil {
-		return ""
+		return 0, true, fmt.Errorf("invalid duration %q", s)
 	}
-	return t.Format(time.DateTime)
+
+	return time.Duration(n) * unit, true, nil
 }
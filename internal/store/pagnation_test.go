@@ -0,0 +1,108 @@
+package store
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestParseTime(t *testing.T) {
+	now := time.Date(2025, 11, 16, 12, 0, 0, 0, time.UTC)
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip("time zone database unavailable")
+	}
+
+	cases := []struct {
+		in   string
+		loc  *time.Location
+		want time.Time
+	}{
+		{"90m", time.UTC, now.Add(-90 * time.Minute)},
+		{"24h", time.UTC, now.Add(-24 * time.Hour)},
+		{"7d", time.UTC, now.AddDate(0, 0, -7)},
+		{"2w", time.UTC, now.AddDate(0, 0, -14)},
+		{"2025-11-01T10:00:00Z", time.UTC, time.Date(2025, 11, 1, 10, 0, 0, 0, time.UTC)},
+		{"2025-11-01T10:00:00.5+02:00", time.UTC, time.Date(2025, 11, 1, 8, 0, 0, 5e8, time.UTC)},
+		// "+" decoded as a space from an unescaped query string
+		{"2025-11-01T10:00:00 02:00", time.UTC, time.Date(2025, 11, 1, 8, 0, 0, 0, time.UTC)},
+		{"2025-11-01 10:00:00", berlin, time.Date(2025, 11, 1, 10, 0, 0, 0, berlin)},
+		{"2025-11-01", berlin, time.Date(2025, 11, 1, 0, 0, 0, 0, berlin)},
+	}
+
+	for _, c := range cases {
+		got, err := parseTime(c.in, now, c.loc)
+		if err != nil {
+			t.Errorf("%q: unexpected error %v", c.in, err)
+			continue
+		}
+		if !got.Equal(c.want) {
+			t.Errorf("%q: expected %v; got %v", c.in, c.want, got)
+		}
+	}
+
+	for _, in := range []string{"yesterday", "7x", "1.5d", "5dd", "2025-13-01", "10:00"} {
+		if _, err := parseTime(in, now, time.UTC); err == nil {
+			t.Errorf("%q: expected an error", in)
+		}
+	}
+}
+
+func TestPaginatedFeedQueryParse(t *testing.T) {
+	parse := func(query url.Values) (PaginatedFeedQuery, error) {
+		r := httptest.NewRequest("GET", "/v1/users/feed?"+query.Encode(), nil)
+		return PaginatedFeedQuery{Limit: 20, Sort: "latest", Order: "desc"}.Parse(r)
+	}
+
+	t.Run("filters are read", func(t *testing.T) {
+		fq, err := parse(url.Values{
+			"order":   {"ASC"},
+			"authors": {"alice,bob"},
+			"tags":    {"go"},
+			"since":   {"2025-11-01T00:00:00Z"},
+			"until":   {"2025-11-02"},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if fq.Order != "asc" {
+			t.Errorf("expected order to be lowercased; got %q", fq.Order)
+		}
+		if len(fq.Authors) != 2 || fq.Authors[1] != "bob" {
+			t.Errorf("unexpected authors %v", fq.Authors)
+		}
+		if !fq.Until.Equal(time.Date(2025, 11, 2, 0, 0, 0, 0, time.UTC)) {
+			t.Errorf("unexpected until %v", fq.Until)
+		}
+		if !fq.Filtered() {
+			t.Error("expected the query to be filtered")
+		}
+	})
+
+	t.Run("invalid values are reported", func(t *testing.T) {
+		for _, query := range []url.Values{
+			{"limit": {"ten"}},
+			{"offset": {"-"}},
+			{"tz": {"Mars/Olympus"}},
+			{"since": {"soon"}},
+			{"until": {"2025-02-30"}},
+			{"since": {"2025-11-02"}, "until": {"2025-11-01"}},
+			{"since": {"2025-11-01"}, "until": {"2025-11-01"}},
+		} {
+			if _, err := parse(query); err == nil {
+				t.Errorf("%v: expected an error", query)
+			}
+		}
+	})
+
+	t.Run("relative since is before until", func(t *testing.T) {
+		if _, err := parse(url.Values{"since": {"7d"}, "until": {"1d"}}); err != nil {
+			t.Errorf("unexpected error %v", err)
+		}
+		if _, err := parse(url.Values{"since": {"1d"}, "until": {"7d"}}); err == nil {
+			t.Error("expected since after until to be rejected")
+		}
+	})
+}
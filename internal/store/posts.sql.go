@@ -7,6 +7,7 @@ package store
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
 	"time"
 
@@ -14,6 +15,42 @@ import (
 	"github.com/lib/pq"
 )
 
+const countPostsByUserId = `-- name: CountPostsByUserId :one
+SELECT COUNT(*) 
+FROM posts 
+WHERE user_id = $1 AND hidden_at IS NULL
+`
+
+func (q *Queries) CountPostsByUserId(ctx context.Context, userID uuid.UUID) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countPostsByUserId, userID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countUserPostsSince = `-- name: CountUserPostsSince :one
+SELECT COUNT(*) AS count, COALESCE(MIN(created_at), NOW())::TIMESTAMPTZ AS oldest 
+FROM posts 
+WHERE user_id = $1 AND created_at > $2
+`
+
+type CountUserPostsSinceParams struct {
+	UserID    uuid.UUID `json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type CountUserPostsSinceRow struct {
+	Count  int64     `json:"count"`
+	Oldest time.Time `json:"oldest"`
+}
+
+func (q *Queries) CountUserPostsSince(ctx context.Context, arg CountUserPostsSinceParams) (CountUserPostsSinceRow, error) {
+	row := q.db.QueryRowContext(ctx, countUserPostsSince, arg.UserID, arg.CreatedAt)
+	var i CountUserPostsSinceRow
+	err := row.Scan(&i.Count, &i.Oldest)
+	return i, err
+}
+
 const createPost = `-- name: CreatePost :one
 INSERT 
 INTO posts (title, content, user_id, tags) 
@@ -55,6 +92,145 @@ func (q *Queries) DeletePostById(ctx context.Context, id uuid.UUID) error {
 	return err
 }
 
+const getExplorePosts = `-- name: GetExplorePosts :many
+SELECT p.id, p.title, p.content, p.tags, p.created_at, p.updated_at, 
+    u.username,
+    (SELECT COUNT(*) FROM comments c WHERE c.post_id = p.id AND c.hidden_at IS NULL) AS comments_count
+FROM posts p
+JOIN users u ON p.user_id = u.id
+WHERE 
+    p.hidden_at IS NULL AND
+    u.banned_at IS NULL AND
+    NOT u.is_private AND 
+    u.verified AND
+    p.created_at >= $1::TIMESTAMPTZ
+ORDER BY p.created_at DESC
+LIMIT $2
+`
+
+type GetExplorePostsParams struct {
+	Since time.Time `json:"since"`
+	Limit int64     `json:"limit"`
+}
+
+type GetExplorePostsRow struct {
+	ID            uuid.UUID `json:"id"`
+	Title         string    `json:"title"`
+	Content       string    `json:"content"`
+	Tags          []string  `json:"tags"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+	Username      string    `json:"username"`
+	CommentsCount int64     `json:"comments_count"`
+}
+
+func (q *Queries) GetExplorePosts(ctx context.Context, arg GetExplorePostsParams) ([]GetExplorePostsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getExplorePosts, arg.Since, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetExplorePostsRow
+	for rows.Next() {
+		var i GetExplorePostsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.Content,
+			pq.Array(&i.Tags),
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Username,
+			&i.CommentsCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getFeedPostsByIds = `-- name: GetFeedPostsByIds :many
+SELECT p.id, p.title, p.content, p.tags, p.created_at, p.updated_at, 
+    u.username,
+    (SELECT COUNT(*) FROM comments c WHERE c.post_id = p.id AND c.hidden_at IS NULL) AS comments_count,
+    (CASE
+        WHEN p.user_id = $1 THEN 'own'
+        WHEN f.follow_id IS NOT NULL THEN 'following'
+        ELSE 'tag'
+    END)::TEXT AS reason,
+    ARRAY(
+        SELECT tf.tag FROM tag_follows tf 
+        WHERE tf.user_id = $1 AND tf.tag = ANY(LOWER(p.tags::TEXT)::TEXT[])
+    )::TEXT[] AS followed_tags
+FROM posts p
+JOIN users u ON p.user_id = u.id
+LEFT JOIN follows f ON f.user_id = $1 AND f.follow_id = p.user_id
+WHERE 
+    p.hidden_at IS NULL AND
+    u.banned_at IS NULL AND
+    p.id = ANY($2::UUID[]) AND
+    (p.user_id = $1 OR f.follow_id IS NOT NULL OR NOT u.is_private)
+`
+
+type GetFeedPostsByIdsParams struct {
+	UserID uuid.UUID   `json:"user_id"`
+	Ids    []uuid.UUID `json:"ids"`
+}
+
+type GetFeedPostsByIdsRow struct {
+	ID            uuid.UUID `json:"id"`
+	Title         string    `json:"title"`
+	Content       string    `json:"content"`
+	Tags          []string  `json:"tags"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+	Username      string    `json:"username"`
+	CommentsCount int64     `json:"comments_count"`
+	Reason        string    `json:"reason"`
+	FollowedTags  []string  `json:"followed_tags"`
+}
+
+func (q *Queries) GetFeedPostsByIds(ctx context.Context, arg GetFeedPostsByIdsParams) ([]GetFeedPostsByIdsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getFeedPostsByIds, arg.UserID, pq.Array(arg.Ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetFeedPostsByIdsRow
+	for rows.Next() {
+		var i GetFeedPostsByIdsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.Content,
+			pq.Array(&i.Tags),
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Username,
+			&i.CommentsCount,
+			&i.Reason,
+			pq.Array(&i.FollowedTags),
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getPostWithCommentsById = `-- name: GetPostWithCommentsById :one
 SELECT p.title, p.content, p.user_id, author.username, p.tags, p.created_at, p.updated_at, 
     JSON_AGG(
@@ -68,9 +244,9 @@ SELECT p.title, p.content, p.user_id, author.username, p.tags, p.created_at, p.u
     ) AS comments
 FROM posts p
 JOIN users author ON p.user_id = author.id
-LEFT JOIN comments c ON p.id = c.post_id
+LEFT JOIN comments c ON p.id = c.post_id AND c.hidden_at IS NULL
 LEFT JOIN users u ON c.user_id = u.id
-WHERE p.id = $1
+WHERE p.id = $1 AND p.hidden_at IS NULL AND author.banned_at IS NULL
 GROUP BY p.id, author.username
 `
 
@@ -102,7 +278,7 @@ func (q *Queries) GetPostWithCommentsById(ctx context.Context, id uuid.UUID) (Ge
 }
 
 const getPostsById = `-- name: GetPostsById :one
-SELECT id, title, content, tags, user_id, created_at, updated_at
+SELECT id, title, content, tags, user_id, created_at, updated_at, hidden_at
 FROM posts 
 WHERE id = $1 LIMIT 1
 `
@@ -118,6 +294,7 @@ func (q *Queries) GetPostsById(ctx context.Context, id uuid.UUID) (Posts, error)
 		&i.UserID,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.HiddenAt,
 	)
 	return i, err
 }
@@ -125,10 +302,24 @@ func (q *Queries) GetPostsById(ctx context.Context, id uuid.UUID) (Posts, error)
 const getPostsByUserId = `-- name: GetPostsByUserId :many
 SELECT id, title, content, tags, created_at, updated_at 
 FROM posts 
-WHERE user_id = $1 
-ORDER BY created_at DESC
+WHERE 
+    user_id = $1 AND 
+    hidden_at IS NULL AND
+    ($2::TEXT IS NULL OR $2::TEXT = ANY(LOWER(tags::TEXT)::TEXT[])) AND
+    ($3::TIMESTAMPTZ IS NULL OR 
+        (created_at, id) < ($3::TIMESTAMPTZ, $4::UUID))
+ORDER BY created_at DESC, id DESC
+LIMIT $5
 `
 
+type GetPostsByUserIdParams struct {
+	UserID          uuid.UUID      `json:"user_id"`
+	Tag             sql.NullString `json:"tag"`
+	CursorCreatedAt sql.NullTime   `json:"cursor_created_at"`
+	CursorID        uuid.NullUUID  `json:"cursor_id"`
+	Limit           int64          `json:"limit"`
+}
+
 type GetPostsByUserIdRow struct {
 	ID        uuid.UUID `json:"id"`
 	Title     string    `json:"title"`
@@ -138,8 +329,14 @@ type GetPostsByUserIdRow struct {
 	UpdatedAt time.Time `json:"updated_at"`
 }
 
-func (q *Queries) GetPostsByUserId(ctx context.Context, userID uuid.UUID) ([]GetPostsByUserIdRow, error) {
-	rows, err := q.db.QueryContext(ctx, getPostsByUserId, userID)
+func (q *Queries) GetPostsByUserId(ctx context.Context, arg GetPostsByUserIdParams) ([]GetPostsByUserIdRow, error) {
+	rows, err := q.db.QueryContext(ctx, getPostsByUserId,
+		arg.UserID,
+		arg.Tag,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.Limit,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -168,29 +365,117 @@ func (q *Queries) GetPostsByUserId(ctx context.Context, userID uuid.UUID) ([]Get
 	return items, nil
 }
 
+const getPublicPostsByTag = `-- name: GetPublicPostsByTag :many
+SELECT p.id, p.title, p.content, p.tags, p.created_at, p.updated_at, 
+    u.username
+FROM posts p
+JOIN users u ON p.user_id = u.id
+WHERE 
+    p.hidden_at IS NULL AND
+    u.banned_at IS NULL AND
+    NOT u.is_private AND 
+    $1::TEXT = ANY(LOWER(p.tags::TEXT)::TEXT[])
+ORDER BY p.created_at DESC
+LIMIT $2
+`
+
+type GetPublicPostsByTagParams struct {
+	Tag   string `json:"tag"`
+	Limit int64  `json:"limit"`
+}
+
+type GetPublicPostsByTagRow struct {
+	ID        uuid.UUID `json:"id"`
+	Title     string    `json:"title"`
+	Content   string    `json:"content"`
+	Tags      []string  `json:"tags"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Username  string    `json:"username"`
+}
+
+func (q *Queries) GetPublicPostsByTag(ctx context.Context, arg GetPublicPostsByTagParams) ([]GetPublicPostsByTagRow, error) {
+	rows, err := q.db.QueryContext(ctx, getPublicPostsByTag, arg.Tag, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetPublicPostsByTagRow
+	for rows.Next() {
+		var i GetPublicPostsByTagRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.Content,
+			pq.Array(&i.Tags),
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Username,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getUserFeed = `-- name: GetUserFeed :many
 SELECT p.id, p.title, p.content, p.tags, p.created_at, p.updated_at, 
     u.username,
-    COUNT(c.id) AS comments_count
+    (SELECT COUNT(*) FROM comments c WHERE c.post_id = p.id AND c.hidden_at IS NULL) AS comments_count,
+    (CASE
+        WHEN p.user_id = $1 THEN 'own'
+        WHEN f.follow_id IS NOT NULL THEN 'following'
+        ELSE 'tag'
+    END)::TEXT AS reason,
+    ARRAY(
+        SELECT tf.tag FROM tag_follows tf 
+        WHERE tf.user_id = $1 AND tf.tag = ANY(LOWER(p.tags::TEXT)::TEXT[])
+    )::TEXT[] AS followed_tags
 FROM posts p
 JOIN users u ON p.user_id = u.id
-LEFT JOIN comments c ON p.id = c.post_id
-JOIN follows f ON p.user_id = f.follow_id OR p.user_id = $1
+LEFT JOIN follows f ON f.user_id = $1 AND f.follow_id = p.user_id
 WHERE 
-    f.user_id = $1 AND
+    p.hidden_at IS NULL AND
+    u.banned_at IS NULL AND
+    (
+        p.user_id = $1 OR 
+        f.follow_id IS NOT NULL OR 
+        (NOT u.is_private AND EXISTS (
+            SELECT 1 FROM tag_follows tf 
+            WHERE tf.user_id = $1 AND tf.tag = ANY(LOWER(p.tags::TEXT)::TEXT[])
+        ))
+    ) AND
     (p.title ILIKE '%' || $2::TEXT || '%' OR p.content ILIKE '%' || $2::TEXT || '%') AND 
-    (p.tags @> $3 OR $3 = '{}')
-GROUP BY p.id, u.username
-ORDER BY p.created_at DESC
-LIMIT $4 OFFSET $5
+    (p.tags @> $3::TEXT[] OR $3::TEXT[] = '{}') AND
+    (u.username = ANY($4::TEXT[]) OR $4::TEXT[] = '{}') AND
+    ($5::TIMESTAMPTZ IS NULL OR p.created_at >= $5::TIMESTAMPTZ) AND
+    ($6::TIMESTAMPTZ IS NULL OR p.created_at < $6::TIMESTAMPTZ) AND
+    (
+        $7::TIMESTAMPTZ IS NULL OR
+        (p.created_at, p.id) < ($7::TIMESTAMPTZ, $8::UUID)
+    )
+ORDER BY p.created_at DESC, p.id DESC
+LIMIT $9 OFFSET $10
 `
 
 type GetUserFeedParams struct {
-	UserID  uuid.UUID `json:"user_id"`
-	Column2 string    `json:"column_2"`
-	Tags    []string  `json:"tags"`
-	Limit   int64     `json:"limit"`
-	Offset  int64     `json:"offset"`
+	UserID          uuid.UUID     `json:"user_id"`
+	Search          string        `json:"search"`
+	Tags            []string      `json:"tags"`
+	Authors         []string      `json:"authors"`
+	Since           sql.NullTime  `json:"since"`
+	Until           sql.NullTime  `json:"until"`
+	CursorCreatedAt sql.NullTime  `json:"cursor_created_at"`
+	CursorID        uuid.NullUUID `json:"cursor_id"`
+	Limit           int64         `json:"limit"`
+	Offset          sql.NullInt64 `json:"offset"`
 }
 
 type GetUserFeedRow struct {
@@ -202,13 +487,20 @@ type GetUserFeedRow struct {
 	UpdatedAt     time.Time `json:"updated_at"`
 	Username      string    `json:"username"`
 	CommentsCount int64     `json:"comments_count"`
+	Reason        string    `json:"reason"`
+	FollowedTags  []string  `json:"followed_tags"`
 }
 
 func (q *Queries) GetUserFeed(ctx context.Context, arg GetUserFeedParams) ([]GetUserFeedRow, error) {
 	rows, err := q.db.QueryContext(ctx, getUserFeed,
 		arg.UserID,
-		arg.Column2,
+		arg.Search,
 		pq.Array(arg.Tags),
+		pq.Array(arg.Authors),
+		arg.Since,
+		arg.Until,
+		arg.CursorCreatedAt,
+		arg.CursorID,
 		arg.Limit,
 		arg.Offset,
 	)
@@ -228,6 +520,230 @@ func (q *Queries) GetUserFeed(ctx context.Context, arg GetUserFeedParams) ([]Get
 			&i.UpdatedAt,
 			&i.Username,
 			&i.CommentsCount,
+			&i.Reason,
+			pq.Array(&i.FollowedTags),
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getUserFeedAscending = `-- name: GetUserFeedAscending :many
+SELECT p.id, p.title, p.content, p.tags, p.created_at, p.updated_at, 
+    u.username,
+    (SELECT COUNT(*) FROM comments c WHERE c.post_id = p.id AND c.hidden_at IS NULL) AS comments_count,
+    (CASE
+        WHEN p.user_id = $1 THEN 'own'
+        WHEN f.follow_id IS NOT NULL THEN 'following'
+        ELSE 'tag'
+    END)::TEXT AS reason,
+    ARRAY(
+        SELECT tf.tag FROM tag_follows tf 
+        WHERE tf.user_id = $1 AND tf.tag = ANY(LOWER(p.tags::TEXT)::TEXT[])
+    )::TEXT[] AS followed_tags
+FROM posts p
+JOIN users u ON p.user_id = u.id
+LEFT JOIN follows f ON f.user_id = $1 AND f.follow_id = p.user_id
+WHERE 
+    p.hidden_at IS NULL AND
+    u.banned_at IS NULL AND
+    (
+        p.user_id = $1 OR 
+        f.follow_id IS NOT NULL OR 
+        (NOT u.is_private AND EXISTS (
+            SELECT 1 FROM tag_follows tf 
+            WHERE tf.user_id = $1 AND tf.tag = ANY(LOWER(p.tags::TEXT)::TEXT[])
+        ))
+    ) AND
+    (p.title ILIKE '%' || $2::TEXT || '%' OR p.content ILIKE '%' || $2::TEXT || '%') AND 
+    (p.tags @> $3::TEXT[] OR $3::TEXT[] = '{}') AND
+    (u.username = ANY($4::TEXT[]) OR $4::TEXT[] = '{}') AND
+    ($5::TIMESTAMPTZ IS NULL OR p.created_at >= $5::TIMESTAMPTZ) AND
+    ($6::TIMESTAMPTZ IS NULL OR p.created_at < $6::TIMESTAMPTZ) AND
+    (
+        $7::TIMESTAMPTZ IS NULL OR
+        (p.created_at, p.id) > ($7::TIMESTAMPTZ, $8::UUID)
+    )
+ORDER BY p.created_at, p.id
+LIMIT $9 OFFSET $10
+`
+
+type GetUserFeedAscendingParams struct {
+	UserID          uuid.UUID     `json:"user_id"`
+	Search          string        `json:"search"`
+	Tags            []string      `json:"tags"`
+	Authors         []string      `json:"authors"`
+	Since           sql.NullTime  `json:"since"`
+	Until           sql.NullTime  `json:"until"`
+	CursorCreatedAt sql.NullTime  `json:"cursor_created_at"`
+	CursorID        uuid.NullUUID `json:"cursor_id"`
+	Limit           int64         `json:"limit"`
+	Offset          sql.NullInt64 `json:"offset"`
+}
+
+type GetUserFeedAscendingRow struct {
+	ID            uuid.UUID `json:"id"`
+	Title         string    `json:"title"`
+	Content       string    `json:"content"`
+	Tags          []string  `json:"tags"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+	Username      string    `json:"username"`
+	CommentsCount int64     `json:"comments_count"`
+	Reason        string    `json:"reason"`
+	FollowedTags  []string  `json:"followed_tags"`
+}
+
+func (q *Queries) GetUserFeedAscending(ctx context.Context, arg GetUserFeedAscendingParams) ([]GetUserFeedAscendingRow, error) {
+	rows, err := q.db.QueryContext(ctx, getUserFeedAscending,
+		arg.UserID,
+		arg.Search,
+		pq.Array(arg.Tags),
+		pq.Array(arg.Authors),
+		arg.Since,
+		arg.Until,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.Limit,
+		arg.Offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetUserFeedAscendingRow
+	for rows.Next() {
+		var i GetUserFeedAscendingRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.Content,
+			pq.Array(&i.Tags),
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Username,
+			&i.CommentsCount,
+			&i.Reason,
+			pq.Array(&i.FollowedTags),
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getUserFeedCandidates = `-- name: GetUserFeedCandidates :many
+SELECT p.id, p.title, p.content, p.tags, p.created_at, p.updated_at, 
+    u.username,
+    (SELECT COUNT(*) FROM comments c WHERE c.post_id = p.id AND c.hidden_at IS NULL) AS comments_count,
+    (CASE
+        WHEN p.user_id = $1 THEN 'own'
+        WHEN f.follow_id IS NOT NULL THEN 'following'
+        ELSE 'tag'
+    END)::TEXT AS reason,
+    ARRAY(
+        SELECT tf.tag FROM tag_follows tf 
+        WHERE tf.user_id = $1 AND tf.tag = ANY(LOWER(p.tags::TEXT)::TEXT[])
+    )::TEXT[] AS followed_tags,
+    (
+        SELECT COUNT(*) FROM comments ac
+        JOIN posts ap ON ap.id = ac.post_id
+        WHERE 
+            ac.user_id = $1 AND 
+            ap.user_id = p.user_id AND 
+            ac.created_at > NOW() - INTERVAL '30 days'
+    ) AS author_affinity
+FROM posts p
+JOIN users u ON p.user_id = u.id
+LEFT JOIN follows f ON f.user_id = $1 AND f.follow_id = p.user_id
+WHERE 
+    p.hidden_at IS NULL AND
+    u.banned_at IS NULL AND
+    (
+        p.user_id = $1 OR 
+        f.follow_id IS NOT NULL OR 
+        (NOT u.is_private AND EXISTS (
+            SELECT 1 FROM tag_follows tf 
+            WHERE tf.user_id = $1 AND tf.tag = ANY(LOWER(p.tags::TEXT)::TEXT[])
+        ))
+    ) AND
+    (p.title ILIKE '%' || $2::TEXT || '%' OR p.content ILIKE '%' || $2::TEXT || '%') AND 
+    (p.tags @> $3::TEXT[] OR $3::TEXT[] = '{}') AND
+    (u.username = ANY($4::TEXT[]) OR $4::TEXT[] = '{}') AND
+    p.created_at >= $5::TIMESTAMPTZ AND
+    ($6::TIMESTAMPTZ IS NULL OR p.created_at < $6::TIMESTAMPTZ)
+ORDER BY p.created_at DESC, p.id DESC
+LIMIT $7
+`
+
+type GetUserFeedCandidatesParams struct {
+	UserID  uuid.UUID    `json:"user_id"`
+	Search  string       `json:"search"`
+	Tags    []string     `json:"tags"`
+	Authors []string     `json:"authors"`
+	Since   time.Time    `json:"since"`
+	Until   sql.NullTime `json:"until"`
+	Limit   int64        `json:"limit"`
+}
+
+type GetUserFeedCandidatesRow struct {
+	ID             uuid.UUID `json:"id"`
+	Title          string    `json:"title"`
+	Content        string    `json:"content"`
+	Tags           []string  `json:"tags"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+	Username       string    `json:"username"`
+	CommentsCount  int64     `json:"comments_count"`
+	Reason         string    `json:"reason"`
+	FollowedTags   []string  `json:"followed_tags"`
+	AuthorAffinity int64     `json:"author_affinity"`
+}
+
+func (q *Queries) GetUserFeedCandidates(ctx context.Context, arg GetUserFeedCandidatesParams) ([]GetUserFeedCandidatesRow, error) {
+	rows, err := q.db.QueryContext(ctx, getUserFeedCandidates,
+		arg.UserID,
+		arg.Search,
+		pq.Array(arg.Tags),
+		pq.Array(arg.Authors),
+		arg.Since,
+		arg.Until,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetUserFeedCandidatesRow
+	for rows.Next() {
+		var i GetUserFeedCandidatesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.Content,
+			pq.Array(&i.Tags),
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Username,
+			&i.CommentsCount,
+			&i.Reason,
+			pq.Array(&i.FollowedTags),
+			&i.AuthorAffinity,
 		); err != nil {
 			return nil, err
 		}
@@ -242,6 +758,67 @@ func (q *Queries) GetUserFeed(ctx context.Context, arg GetUserFeedParams) ([]Get
 	return items, nil
 }
 
+const hidePost = `-- name: HidePost :exec
+UPDATE posts
+SET hidden_at = NOW()
+WHERE id = $1 AND hidden_at IS NULL
+`
+
+func (q *Queries) HidePost(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, hidePost, id)
+	return err
+}
+
+const lockUserPosting = `-- name: LockUserPosting :exec
+SELECT pg_advisory_xact_lock(hashtextextended($1::UUID::TEXT, 0))
+`
+
+func (q *Queries) LockUserPosting(ctx context.Context, userID uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, lockUserPosting, userID)
+	return err
+}
+
+const recentPostWithContentExists = `-- name: RecentPostWithContentExists :one
+SELECT EXISTS (
+    SELECT 1 
+    FROM posts 
+    WHERE user_id = $1 
+    AND content_hash(title) = content_hash($2::TEXT) 
+    AND content_hash(content) = content_hash($3::TEXT) 
+    AND created_at > $4
+)
+`
+
+type RecentPostWithContentExistsParams struct {
+	UserID  uuid.UUID `json:"user_id"`
+	Title   string    `json:"title"`
+	Content string    `json:"content"`
+	Since   time.Time `json:"since"`
+}
+
+func (q *Queries) RecentPostWithContentExists(ctx context.Context, arg RecentPostWithContentExistsParams) (bool, error) {
+	row := q.db.QueryRowContext(ctx, recentPostWithContentExists,
+		arg.UserID,
+		arg.Title,
+		arg.Content,
+		arg.Since,
+	)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
+const unhidePost = `-- name: UnhidePost :exec
+UPDATE posts
+SET hidden_at = NULL
+WHERE id = $1
+`
+
+func (q *Queries) UnhidePost(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, unhidePost, id)
+	return err
+}
+
 const updatePostById = `-- name: UpdatePostById :one
 UPDATE posts
 SET 
@@ -21,3 +21,32 @@ func (q *Queries) GetRoleByName(ctx context.Context, name string) (Roles, error)
 	err := row.Scan(&i.ID, &i.Name, &i.Description)
 	return i, err
 }
+
+const getRoles = `-- name: GetRoles :many
+SELECT id, name, description 
+FROM roles 
+ORDER BY id
+`
+
+func (q *Queries) GetRoles(ctx context.Context) ([]Roles, error) {
+	rows, err := q.db.QueryContext(ctx, getRoles)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Roles
+	for rows.Next() {
+		var i Roles
+		if err := rows.Scan(&i.ID, &i.Name, &i.Description); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
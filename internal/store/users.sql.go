@@ -7,6 +7,7 @@ package store
 
 import (
 	"context"
+	"database/sql"
 
 	"github.com/google/uuid"
 )
@@ -22,6 +23,22 @@ func (q *Queries) ActivateUser(ctx context.Context, id uuid.UUID) error {
 	return err
 }
 
+const banUser = `-- name: BanUser :exec
+UPDATE users
+SET banned_at = NOW(), ban_reason = $2
+WHERE id = $1
+`
+
+type BanUserParams struct {
+	ID        uuid.UUID `json:"id"`
+	BanReason string    `json:"ban_reason"`
+}
+
+func (q *Queries) BanUser(ctx context.Context, arg BanUserParams) error {
+	_, err := q.db.ExecContext(ctx, banUser, arg.ID, arg.BanReason)
+	return err
+}
+
 const createUser = `-- name: CreateUser :one
 INSERT 
 INTO users (username, email, password, role_id) 
@@ -60,7 +77,7 @@ func (q *Queries) DeleteUser(ctx context.Context, id uuid.UUID) error {
 }
 
 const getUserByEmail = `-- name: GetUserByEmail :one
-SELECT id, email, username, password, created_at, verified, role_id 
+SELECT id, email, username, password, created_at, verified, role_id, is_private, suspended_until, suspension_reason, banned_at, ban_reason 
 FROM users 
 WHERE email = $1 LIMIT 1
 `
@@ -76,12 +93,17 @@ func (q *Queries) GetUserByEmail(ctx context.Context, email string) (Users, erro
 		&i.CreatedAt,
 		&i.Verified,
 		&i.RoleID,
+		&i.IsPrivate,
+		&i.SuspendedUntil,
+		&i.SuspensionReason,
+		&i.BannedAt,
+		&i.BanReason,
 	)
 	return i, err
 }
 
 const getUserByUserId = `-- name: GetUserByUserId :one
-SELECT id, email, username, password, created_at, verified, role_id 
+SELECT id, email, username, password, created_at, verified, role_id, is_private, suspended_until, suspension_reason, banned_at, ban_reason 
 FROM users 
 WHERE id = $1 LIMIT 1
 `
@@ -97,12 +119,17 @@ func (q *Queries) GetUserByUserId(ctx context.Context, id uuid.UUID) (Users, err
 		&i.CreatedAt,
 		&i.Verified,
 		&i.RoleID,
+		&i.IsPrivate,
+		&i.SuspendedUntil,
+		&i.SuspensionReason,
+		&i.BannedAt,
+		&i.BanReason,
 	)
 	return i, err
 }
 
 const getUserByUsername = `-- name: GetUserByUsername :one
-SELECT id, email, username, password, created_at, verified, role_id 
+SELECT id, email, username, password, created_at, verified, role_id, is_private, suspended_until, suspension_reason, banned_at, ban_reason 
 FROM users 
 WHERE username = $1 LIMIT 1
 `
@@ -118,6 +145,91 @@ func (q *Queries) GetUserByUsername(ctx context.Context, username string) (Users
 		&i.CreatedAt,
 		&i.Verified,
 		&i.RoleID,
+		&i.IsPrivate,
+		&i.SuspendedUntil,
+		&i.SuspensionReason,
+		&i.BannedAt,
+		&i.BanReason,
 	)
 	return i, err
 }
+
+const getVerifiedUserIds = `-- name: GetVerifiedUserIds :many
+SELECT id
+FROM users
+WHERE verified
+ORDER BY created_at
+LIMIT $1 OFFSET $2
+`
+
+type GetVerifiedUserIdsParams struct {
+	Limit  int64 `json:"limit"`
+	Offset int64 `json:"offset"`
+}
+
+func (q *Queries) GetVerifiedUserIds(ctx context.Context, arg GetVerifiedUserIdsParams) ([]uuid.UUID, error) {
+	rows, err := q.db.QueryContext(ctx, getVerifiedUserIds, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const reinstateUser = `-- name: ReinstateUser :exec
+UPDATE users
+SET suspended_until = NULL, suspension_reason = '', banned_at = NULL, ban_reason = ''
+WHERE id = $1
+`
+
+func (q *Queries) ReinstateUser(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, reinstateUser, id)
+	return err
+}
+
+const suspendUser = `-- name: SuspendUser :exec
+UPDATE users
+SET suspended_until = $2, suspension_reason = $3
+WHERE id = $1
+`
+
+type SuspendUserParams struct {
+	ID               uuid.UUID    `json:"id"`
+	SuspendedUntil   sql.NullTime `json:"suspended_until"`
+	SuspensionReason string       `json:"suspension_reason"`
+}
+
+func (q *Queries) SuspendUser(ctx context.Context, arg SuspendUserParams) error {
+	_, err := q.db.ExecContext(ctx, suspendUser, arg.ID, arg.SuspendedUntil, arg.SuspensionReason)
+	return err
+}
+
+const updateUserPrivacy = `-- name: UpdateUserPrivacy :exec
+UPDATE users
+SET is_private = $2
+WHERE id = $1
+`
+
+type UpdateUserPrivacyParams struct {
+	ID        uuid.UUID `json:"id"`
+	IsPrivate bool      `json:"is_private"`
+}
+
+func (q *Queries) UpdateUserPrivacy(ctx context.Context, arg UpdateUserPrivacyParams) error {
+	_, err := q.db.ExecContext(ctx, updateUserPrivacy, arg.ID, arg.IsPrivate)
+	return err
+}
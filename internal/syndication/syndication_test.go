@@ -0,0 +1,88 @@
+package syndication
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files")
+
+func testFeed() Feed {
+	published := time.Date(2025, 11, 16, 12, 0, 0, 0, time.UTC)
+
+	return Feed{
+		Title:       "alice",
+		Description: "Posts by alice",
+		Link:        "https://social.example/users/alice",
+		FeedURL:     "https://api.social.example/v1/users/alice/feed.rss",
+		Author:      "alice",
+		Updated:     published.Add(time.Hour),
+		Items: []Item{
+			{
+				ID:        "urn:uuid:6f1c0a7e-0000-4000-8000-000000000001",
+				Title:     "Fish & <chips>",
+				Content:   "Tasty \"fish\" & chips, see https://example.com?a=1&b=2",
+				Link:      "https://social.example/posts/6f1c0a7e-0000-4000-8000-000000000001",
+				Author:    "alice",
+				Tags:      []string{"food", "uk"},
+				Published: published,
+				Updated:   published.Add(time.Hour),
+			},
+			{
+				ID:        "urn:uuid:6f1c0a7e-0000-4000-8000-000000000002",
+				Title:     "Guest post",
+				Content:   "Written by someone else",
+				Link:      "https://social.example/posts/6f1c0a7e-0000-4000-8000-000000000002",
+				Author:    "bob",
+				Published: published.Add(-24 * time.Hour),
+				Updated:   published.Add(-24 * time.Hour),
+			},
+		},
+	}
+}
+
+func TestRender(t *testing.T) {
+	for _, format := range []string{FormatRSS, FormatAtom, FormatJSON} {
+		t.Run(format, func(t *testing.T) {
+			got, err := Render(testFeed(), format)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			golden := filepath.Join("testdata", "feed."+format+".golden")
+			if *update {
+				if err := os.WriteFile(golden, got, 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("%s differs from %s, run with -update after checking the change:\n%s", format, golden, got)
+			}
+		})
+	}
+
+	t.Run("empty feeds have an empty item list", func(t *testing.T) {
+		got, err := Render(Feed{Title: "empty"}, FormatJSON)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Contains(got, []byte(`"items": []`)) {
+			t.Errorf("expected an empty items array; got %s", got)
+		}
+	})
+
+	t.Run("unknown formats are rejected", func(t *testing.T) {
+		if _, err := Render(testFeed(), "yaml"); err == nil {
+			t.Error("expected an error")
+		}
+	})
+}
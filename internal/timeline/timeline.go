@@ -0,0 +1,186 @@
+package timeline
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// Entry is a post in a home timeline. Timelines are ordered by
+// (CreatedAt, PostID), matching the keyset order of the SQL feed.
+type Entry struct {
+	PostID    uuid.UUID
+	CreatedAt time.Time
+}
+
+type Config struct {
+	Enabled bool
+	// MaxLength is the number of entries kept per timeline.
+	MaxLength int64
+	// CelebrityThreshold is the follower count from which an author's posts
+	// are no longer pushed to followers but merged in when reading.
+	CelebrityThreshold int64
+}
+
+// RedisTimeline keeps a sorted set of post IDs per user, scored by the
+// post creation time in milliseconds.
+type RedisTimeline struct {
+	rdb    *redis.Client
+	maxLen int64
+}
+
+func NewRedisTimeline(rdb *redis.Client, maxLen int64) *RedisTimeline {
+	return &RedisTimeline{rdb: rdb, maxLen: maxLen}
+}
+
+// seededTTL is how long a rebuilt timeline counts as seeded. Empty
+// timelines have no key in Redis, so without the marker every read of an
+// empty timeline would reseed it.
+const seededTTL = 10 * time.Minute
+
+func timelineKey(userID uuid.UUID) string {
+	return fmt.Sprintf("timeline-%v", userID)
+}
+
+func seededKey(userID uuid.UUID) string {
+	return fmt.Sprintf("timeline-seeded-%v", userID)
+}
+
+func score(t time.Time) float64 {
+	return float64(t.UnixMilli())
+}
+
+// Push adds the entry to the timeline of every user and trims them.
+func (t *RedisTimeline) Push(ctx context.Context, entry Entry, userIDs []uuid.UUID) error {
+	pipe := t.rdb.Pipeline()
+	for _, userID := range userIDs {
+		key := timelineKey(userID)
+		pipe.ZAdd(ctx, key, redis.Z{Score: score(entry.CreatedAt), Member: entry.PostID.String()})
+		pipe.ZRemRangeByRank(ctx, key, 0, -(t.maxLen + 1))
+	}
+
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// Rebuild atomically replaces the timeline of user with entries and marks it
+// as seeded, even when entries is empty.
+func (t *RedisTimeline) Rebuild(ctx context.Context, userID uuid.UUID, entries []Entry) error {
+	key := timelineKey(userID)
+
+	pipe := t.rdb.TxPipeline()
+	pipe.Del(ctx, key)
+	if len(entries) > 0 {
+		members := make([]redis.Z, len(entries))
+		for i, entry := range entries {
+			members[i] = redis.Z{Score: score(entry.CreatedAt), Member: entry.PostID.String()}
+		}
+		pipe.ZAdd(ctx, key, members...)
+		pipe.ZRemRangeByRank(ctx, key, 0, -(t.maxLen + 1))
+	}
+	pipe.Set(ctx, seededKey(userID), 1, seededTTL)
+
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// Seeded reports whether the timeline of user was rebuilt recently, so an
+// empty timeline is known to be empty rather than missing.
+func (t *RedisTimeline) Seeded(ctx context.Context, userID uuid.UUID) (bool, error) {
+	n, err := t.rdb.Exists(ctx, seededKey(userID)).Result()
+	return n > 0, err
+}
+
+// Remove drops posts from the timeline of user, e.g. after they were deleted.
+func (t *RedisTimeline) Remove(ctx context.Context, userID uuid.UUID, postIDs ...uuid.UUID) error {
+	if len(postIDs) == 0 {
+		return nil
+	}
+
+	members := make([]any, len(postIDs))
+	for i, postID := range postIDs {
+		members[i] = postID.String()
+	}
+
+	return t.rdb.ZRem(ctx, timelineKey(userID), members...).Err()
+}
+
+func (t *RedisTimeline) Len(ctx context.Context, userID uuid.UUID) (int64, error) {
+	return t.rdb.ZCard(ctx, timelineKey(userID)).Result()
+}
+
+// MaxLength reports how many entries a timeline holds at most.
+func (t *RedisTimeline) MaxLength() int64 {
+	return t.maxLen
+}
+
+// Range returns up to limit entries strictly after cursor, newest first, or
+// oldest first when ascending is set. A nil cursor starts at the newest entry.
+func (t *RedisTimeline) Range(ctx context.Context, userID uuid.UUID, cursor *Entry, ascending bool, limit int64) ([]Entry, error) {
+	args := redis.ZRangeArgs{
+		Key:     timelineKey(userID),
+		Start:   "-inf",
+		Stop:    "+inf",
+		ByScore: true,
+		Rev:     !ascending,
+		Count:   limit,
+	}
+
+	if cursor != nil {
+		// the cursor score is inclusive, entries sharing it are filtered below
+		bound := strconv.FormatFloat(score(cursor.CreatedAt), 'f', -1, 64)
+		if ascending {
+			args.Start = bound
+		} else {
+			args.Stop = bound
+		}
+	}
+
+	entries := make([]Entry, 0, limit)
+	for {
+		batch, err := t.rdb.ZRangeArgsWithScores(ctx, args).Result()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, z := range batch {
+			postID, err := uuid.Parse(z.Member.(string))
+			if err != nil {
+				continue
+			}
+
+			entry := Entry{PostID: postID, CreatedAt: time.UnixMilli(int64(z.Score))}
+			if cursor != nil && !after(entry, *cursor, ascending) {
+				continue
+			}
+
+			entries = append(entries, entry)
+			if int64(len(entries)) == limit {
+				return entries, nil
+			}
+		}
+
+		if int64(len(batch)) < args.Count {
+			return entries, nil
+		}
+		args.Offset += args.Count
+	}
+}
+
+// after reports whether e comes after cursor in the requested direction.
+func after(e, cursor Entry, ascending bool) bool {
+	if !e.CreatedAt.Equal(cursor.CreatedAt) {
+		return e.CreatedAt.After(cursor.CreatedAt) == ascending
+	}
+
+	cmp := bytes.Compare(e.PostID[:], cursor.PostID[:])
+	if ascending {
+		return cmp > 0
+	}
+	return cmp < 0
+}
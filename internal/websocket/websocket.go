@@ -0,0 +1,450 @@
+// Package websocket is a minimal server side implementation of the
+// WebSocket protocol (RFC 6455) on top of net/http. It supports what the
+// API gateway needs: the opening handshake, text and binary messages,
+// fragmentation, ping/pong and the closing handshake. Extensions such as
+// compression are not negotiated.
+package websocket
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// Message opcodes.
+const (
+	continuationFrame = 0
+	TextMessage       = 1
+	BinaryMessage     = 2
+	CloseMessage      = 8
+	PingMessage       = 9
+	PongMessage       = 10
+)
+
+// Close codes.
+const (
+	CloseNormalClosure    = 1000
+	CloseGoingAway        = 1001
+	CloseProtocolError    = 1002
+	CloseUnsupportedData  = 1003
+	CloseNoStatusReceived = 1005
+	CloseInvalidPayload   = 1007
+	ClosePolicyViolation  = 1008
+	CloseMessageTooBig    = 1009
+	CloseInternalError    = 1011
+	CloseTryAgainLater    = 1013
+)
+
+// acceptGUID is appended to the client key to compute Sec-WebSocket-Accept.
+const acceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// maxControlPayload is the largest payload a control frame may carry.
+const maxControlPayload = 125
+
+var (
+	ErrBadHandshake   = errors.New("websocket: bad handshake")
+	ErrMessageTooBig  = errors.New("websocket: message too big")
+	ErrCloseSent      = errors.New("websocket: close sent")
+	errProtocol       = errors.New("websocket: protocol error")
+	errInvalidPayload = errors.New("websocket: invalid utf-8 in text message")
+)
+
+// CloseError is returned by ReadMessage when the peer closed the connection.
+type CloseError struct {
+	Code int
+	Text string
+}
+
+func (e *CloseError) Error() string {
+	return fmt.Sprintf("websocket: close %d %s", e.Code, e.Text)
+}
+
+// Upgrader upgrades HTTP requests to WebSocket connections.
+type Upgrader struct {
+	// ReadLimit is the largest message accepted from the client. Zero
+	// means no limit.
+	ReadLimit int64
+	// CheckOrigin rejects cross-origin requests when it returns false. A
+	// nil CheckOrigin accepts every origin; clients authenticate with a
+	// bearer token, not cookies.
+	CheckOrigin func(r *http.Request) bool
+}
+
+// IsUpgrade reports whether r asks for a WebSocket connection.
+func IsUpgrade(r *http.Request) bool {
+	return headerContains(r.Header, "Connection", "upgrade") &&
+		headerContains(r.Header, "Upgrade", "websocket")
+}
+
+// Upgrade completes the opening handshake and takes over the connection.
+// On failure it has already replied with an HTTP error.
+func (u *Upgrader) Upgrade(w http.ResponseWriter, r *http.Request) (*Conn, error) {
+	if r.Method != http.MethodGet || !IsUpgrade(r) {
+		http.Error(w, "websocket upgrade required", http.StatusBadRequest)
+		return nil, ErrBadHandshake
+	}
+
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		http.Error(w, "unsupported websocket version", http.StatusUpgradeRequired)
+		return nil, ErrBadHandshake
+	}
+
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if k, err := base64.StdEncoding.DecodeString(key); err != nil || len(k) != 16 {
+		http.Error(w, "invalid Sec-WebSocket-Key", http.StatusBadRequest)
+		return nil, ErrBadHandshake
+	}
+
+	if u.CheckOrigin != nil && !u.CheckOrigin(r) {
+		http.Error(w, "origin not allowed", http.StatusForbidden)
+		return nil, ErrBadHandshake
+	}
+
+	netConn, rw, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		http.Error(w, "websocket not supported", http.StatusInternalServerError)
+		return nil, err
+	}
+
+	// drop the deadlines the HTTP server set for the request
+	if err := netConn.SetDeadline(time.Time{}); err != nil {
+		netConn.Close()
+		return nil, err
+	}
+
+	response := "HTTP/1.1 101 Switching Protocols\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + acceptKey(key) + "\r\n\r\n"
+
+	if _, err := rw.WriteString(response); err != nil {
+		netConn.Close()
+		return nil, err
+	}
+	if err := rw.Flush(); err != nil {
+		netConn.Close()
+		return nil, err
+	}
+
+	return &Conn{
+		conn:      netConn,
+		br:        rw.Reader,
+		readLimit: u.ReadLimit,
+	}, nil
+}
+
+func acceptKey(key string) string {
+	h := sha1.New()
+	h.Write([]byte(key + acceptGUID))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+func headerContains(h http.Header, name, token string) bool {
+	for _, v := range h.Values(name) {
+		for _, t := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(t), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Conn is a server side WebSocket connection. One goroutine may read while
+// others write; writes are serialized internally.
+type Conn struct {
+	conn      net.Conn
+	br        *bufio.Reader
+	readLimit int64
+
+	pongHandler func(string) error
+
+	wmu          sync.Mutex
+	writeTimeout time.Duration
+	closeSent    bool
+}
+
+// SetReadDeadline sets the deadline for the next reads, see net.Conn.
+func (c *Conn) SetReadDeadline(t time.Time) error {
+	return c.conn.SetReadDeadline(t)
+}
+
+// SetPongHandler sets a function called with the payload of every pong, e.g.
+// to extend the read deadline.
+func (c *Conn) SetPongHandler(h func(appData string) error) {
+	c.pongHandler = h
+}
+
+// RemoteAddr returns the address of the client.
+func (c *Conn) RemoteAddr() net.Addr {
+	return c.conn.RemoteAddr()
+}
+
+// ReadMessage returns the next data message. Control frames are handled
+// while reading: pings are answered and a close frame is echoed and
+// returned as a *CloseError.
+func (c *Conn) ReadMessage() (int, []byte, error) {
+	var (
+		opcode  int
+		message []byte
+	)
+
+	for {
+		f, err := c.readFrame()
+		if err != nil {
+			return 0, nil, c.fail(err)
+		}
+
+		switch f.opcode {
+		case PingMessage:
+			if err := c.WriteControl(PongMessage, f.payload, time.Now().Add(time.Second)); err != nil && !errors.Is(err, ErrCloseSent) {
+				return 0, nil, err
+			}
+			continue
+		case PongMessage:
+			if c.pongHandler != nil {
+				if err := c.pongHandler(string(f.payload)); err != nil {
+					return 0, nil, err
+				}
+			}
+			continue
+		case CloseMessage:
+			return 0, nil, c.handleClose(f.payload)
+		case TextMessage, BinaryMessage:
+			if opcode != 0 {
+				return 0, nil, c.fail(errProtocol)
+			}
+			opcode = f.opcode
+		case continuationFrame:
+			if opcode == 0 {
+				return 0, nil, c.fail(errProtocol)
+			}
+		default:
+			return 0, nil, c.fail(errProtocol)
+		}
+
+		if c.readLimit > 0 && int64(len(message)+len(f.payload)) > c.readLimit {
+			return 0, nil, c.fail(ErrMessageTooBig)
+		}
+		message = append(message, f.payload...)
+
+		if f.fin {
+			if opcode == TextMessage && !utf8.Valid(message) {
+				return 0, nil, c.fail(errInvalidPayload)
+			}
+			return opcode, message, nil
+		}
+	}
+}
+
+type frame struct {
+	fin     bool
+	opcode  int
+	payload []byte
+}
+
+func (c *Conn) readFrame() (frame, error) {
+	var head [2]byte
+	if _, err := io.ReadFull(c.br, head[:]); err != nil {
+		return frame{}, err
+	}
+
+	f := frame{
+		fin:    head[0]&0x80 != 0,
+		opcode: int(head[0] & 0x0f),
+	}
+
+	// no extensions are negotiated, so the reserved bits must be clear
+	if head[0]&0x70 != 0 {
+		return frame{}, errProtocol
+	}
+
+	// clients must mask every frame
+	if head[1]&0x80 == 0 {
+		return frame{}, errProtocol
+	}
+
+	length := uint64(head[1] & 0x7f)
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(c.br, ext[:]); err != nil {
+			return frame{}, err
+		}
+		length = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(c.br, ext[:]); err != nil {
+			return frame{}, err
+		}
+		length = binary.BigEndian.Uint64(ext[:])
+	}
+
+	if f.opcode >= CloseMessage && (!f.fin || length > maxControlPayload) {
+		return frame{}, errProtocol
+	}
+
+	if c.readLimit > 0 && length > uint64(c.readLimit) {
+		return frame{}, ErrMessageTooBig
+	}
+
+	var mask [4]byte
+	if _, err := io.ReadFull(c.br, mask[:]); err != nil {
+		return frame{}, err
+	}
+
+	f.payload = make([]byte, length)
+	if _, err := io.ReadFull(c.br, f.payload); err != nil {
+		return frame{}, err
+	}
+
+	for i := range f.payload {
+		f.payload[i] ^= mask[i%4]
+	}
+
+	return f, nil
+}
+
+// handleClose echoes the close frame of the peer and closes the connection.
+func (c *Conn) handleClose(payload []byte) error {
+	closeErr := &CloseError{Code: CloseNoStatusReceived}
+	if len(payload) >= 2 {
+		closeErr.Code = int(binary.BigEndian.Uint16(payload))
+		closeErr.Text = string(payload[2:])
+	}
+
+	echo := payload
+	if len(echo) >= 2 {
+		echo = echo[:2]
+	}
+	c.WriteControl(CloseMessage, echo, time.Now().Add(time.Second))
+	c.conn.Close()
+
+	return closeErr
+}
+
+// fail closes the connection with a status matching err.
+func (c *Conn) fail(err error) error {
+	code := 0
+	switch {
+	case errors.Is(err, errProtocol):
+		code = CloseProtocolError
+	case errors.Is(err, ErrMessageTooBig):
+		code = CloseMessageTooBig
+	case errors.Is(err, errInvalidPayload):
+		code = CloseInvalidPayload
+	}
+
+	if code != 0 {
+		c.CloseWithCode(code, "")
+	}
+	return err
+}
+
+// SetWriteTimeout bounds every following WriteMessage, so a client that
+// stops reading is dropped instead of blocking the writer. Zero means no
+// timeout.
+func (c *Conn) SetWriteTimeout(d time.Duration) {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+
+	c.writeTimeout = d
+}
+
+// WriteMessage sends a single frame data message.
+func (c *Conn) WriteMessage(opcode int, data []byte) error {
+	if opcode != TextMessage && opcode != BinaryMessage {
+		return fmt.Errorf("websocket: invalid message opcode %d", opcode)
+	}
+
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+
+	if c.closeSent {
+		return ErrCloseSent
+	}
+
+	var deadline time.Time
+	if c.writeTimeout > 0 {
+		deadline = time.Now().Add(c.writeTimeout)
+	}
+
+	return c.writeFrame(opcode, data, deadline)
+}
+
+// WriteControl sends a ping, pong or close frame before deadline.
+func (c *Conn) WriteControl(opcode int, data []byte, deadline time.Time) error {
+	if opcode < CloseMessage || len(data) > maxControlPayload {
+		return fmt.Errorf("websocket: invalid control frame")
+	}
+
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+
+	if c.closeSent {
+		return ErrCloseSent
+	}
+	if opcode == CloseMessage {
+		c.closeSent = true
+	}
+
+	return c.writeFrame(opcode, data, deadline)
+}
+
+// writeFrame must be called with c.wmu held.
+func (c *Conn) writeFrame(opcode int, data []byte, deadline time.Time) error {
+	if err := c.conn.SetWriteDeadline(deadline); err != nil {
+		return err
+	}
+
+	header := make([]byte, 2, 10)
+	header[0] = 0x80 | byte(opcode)
+
+	switch n := len(data); {
+	case n <= 125:
+		header[1] = byte(n)
+	case n <= 0xffff:
+		header[1] = 126
+		header = binary.BigEndian.AppendUint16(header, uint16(n))
+	default:
+		header[1] = 127
+		header = binary.BigEndian.AppendUint64(header, uint64(n))
+	}
+
+	bufs := net.Buffers{header, data}
+	_, err := bufs.WriteTo(c.conn)
+	return err
+}
+
+// CloseWithCode starts the closing handshake and closes the connection.
+func (c *Conn) CloseWithCode(code int, reason string) error {
+	payload := binary.BigEndian.AppendUint16(nil, uint16(code))
+	payload = append(payload, reason...)
+	if len(payload) > maxControlPayload {
+		payload = payload[:maxControlPayload]
+	}
+
+	err := c.WriteControl(CloseMessage, payload, time.Now().Add(time.Second))
+	c.conn.Close()
+
+	if errors.Is(err, ErrCloseSent) {
+		return nil
+	}
+	return err
+}
+
+// Close closes the underlying connection without a closing handshake.
+func (c *Conn) Close() error {
+	return c.conn.Close()
+}
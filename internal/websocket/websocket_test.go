@@ -0,0 +1,186 @@
+package websocket
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// dial performs the opening handshake against srv with a raw connection.
+func dial(t *testing.T, srv *httptest.Server) (net.Conn, *bufio.Reader) {
+	t.Helper()
+
+	conn, err := net.Dial("tcp", strings.TrimPrefix(srv.URL, "http://"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	// keep a broken server from hanging the test
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	key := "dGhlIHNhbXBsZSBub25jZQ=="
+	req := "GET / HTTP/1.1\r\nHost: example.com\r\nUpgrade: websocket\r\nConnection: keep-alive, Upgrade\r\n" +
+		"Sec-WebSocket-Key: " + key + "\r\nSec-WebSocket-Version: 13\r\n\r\n"
+	if _, err := conn.Write([]byte(req)); err != nil {
+		t.Fatal(err)
+	}
+
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("expected status 101; got %d", resp.StatusCode)
+	}
+	// the example key and accept value from RFC 6455 section 1.3
+	if got := resp.Header.Get("Sec-WebSocket-Accept"); got != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Fatalf("unexpected accept key %q", got)
+	}
+
+	return conn, br
+}
+
+func writeClientFrame(t *testing.T, conn net.Conn, fin bool, opcode int, payload []byte) {
+	t.Helper()
+
+	b0 := byte(opcode)
+	if fin {
+		b0 |= 0x80
+	}
+
+	frame := []byte{b0}
+	switch n := len(payload); {
+	case n <= 125:
+		frame = append(frame, 0x80|byte(n))
+	default:
+		frame = append(frame, 0x80|126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(n))
+	}
+
+	mask := []byte{1, 2, 3, 4}
+	frame = append(frame, mask...)
+	for i, b := range payload {
+		frame = append(frame, b^mask[i%4])
+	}
+
+	if _, err := conn.Write(frame); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readServerFrame(t *testing.T, br *bufio.Reader) (int, []byte) {
+	t.Helper()
+
+	var head [2]byte
+	if _, err := io.ReadFull(br, head[:]); err != nil {
+		t.Fatal(err)
+	}
+	if head[1]&0x80 != 0 {
+		t.Fatal("server frames must not be masked")
+	}
+
+	n := int(head[1] & 0x7f)
+	if n == 126 {
+		var ext [2]byte
+		io.ReadFull(br, ext[:])
+		n = int(binary.BigEndian.Uint16(ext[:]))
+	}
+
+	payload := make([]byte, n)
+	if _, err := io.ReadFull(br, payload); err != nil {
+		t.Fatal(err)
+	}
+
+	return int(head[0] & 0x0f), payload
+}
+
+func echoServer(t *testing.T, u *Upgrader, done chan<- error) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := u.Upgrade(w, r)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		for {
+			op, msg, err := conn.ReadMessage()
+			if err != nil {
+				done <- err
+				return
+			}
+			if err := conn.WriteMessage(op, msg); err != nil {
+				done <- err
+				return
+			}
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestConn(t *testing.T) {
+	t.Run("echoes fragmented messages and answers pings", func(t *testing.T) {
+		done := make(chan error, 1)
+		conn, br := dial(t, echoServer(t, &Upgrader{}, done))
+
+		writeClientFrame(t, conn, false, TextMessage, []byte("hello "))
+		writeClientFrame(t, conn, true, PingMessage, []byte("p"))
+		writeClientFrame(t, conn, true, continuationFrame, []byte(strings.Repeat("x", 200)))
+
+		if op, payload := readServerFrame(t, br); op != PongMessage || string(payload) != "p" {
+			t.Fatalf("expected pong; got opcode %d %q", op, payload)
+		}
+
+		op, payload := readServerFrame(t, br)
+		if op != TextMessage || string(payload) != "hello "+strings.Repeat("x", 200) {
+			t.Fatalf("unexpected echo: opcode %d %q", op, payload)
+		}
+
+		writeClientFrame(t, conn, true, CloseMessage, binary.BigEndian.AppendUint16(nil, CloseNormalClosure))
+		if op, payload := readServerFrame(t, br); op != CloseMessage || binary.BigEndian.Uint16(payload) != CloseNormalClosure {
+			t.Fatalf("expected close echo; got opcode %d %v", op, payload)
+		}
+
+		var closeErr *CloseError
+		if err := <-done; !errors.As(err, &closeErr) || closeErr.Code != CloseNormalClosure {
+			t.Fatalf("expected close error; got %v", err)
+		}
+	})
+
+	t.Run("rejects messages over the read limit", func(t *testing.T) {
+		done := make(chan error, 1)
+		conn, br := dial(t, echoServer(t, &Upgrader{ReadLimit: 8}, done))
+
+		writeClientFrame(t, conn, true, TextMessage, []byte("way too long"))
+
+		if op, payload := readServerFrame(t, br); op != CloseMessage || binary.BigEndian.Uint16(payload) != CloseMessageTooBig {
+			t.Fatalf("expected close 1009; got opcode %d %v", op, payload)
+		}
+		if err := <-done; !errors.Is(err, ErrMessageTooBig) {
+			t.Fatalf("expected ErrMessageTooBig; got %v", err)
+		}
+	})
+
+	t.Run("rejects plain http requests", func(t *testing.T) {
+		srv := echoServer(t, &Upgrader{}, make(chan error, 1))
+
+		resp, err := http.Get(srv.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Fatalf("expected status 400; got %d", resp.StatusCode)
+		}
+	})
+}